@@ -47,10 +47,15 @@ func (dm *DatabaseManager) GetNewsList(offset, limit int) ([]NewsItem, error) {
 
 	var items []NewsItem
 	for _, info := range newsInfos {
+		// 相对时间标签（如"3小时前"）在查看时已过时，有发布时间时显示发布时间
+		displayTime := info.Time
+		if info.PublishedAt != "" {
+			displayTime = info.PublishedAt
+		}
 		items = append(items, NewsItem{
 			ID:         info.SID,
 			Title:      info.Title,
-			Time:       info.Time,
+			Time:       displayTime,
 			CommentNum: info.CommentNum,
 			URL:        info.URL,
 		})
@@ -76,6 +81,7 @@ func (dm *DatabaseManager) GetComments(articleID string) ([]CommentItem, error)
 			Time:         comment.CommentTime,
 			SupportCount: comment.SupportCount,
 			ParentID:     comment.ParentID,
+			AnswerToID:   comment.AnswerToID,
 			AnswerToName: comment.AnswerToName,
 			UserLevel:    comment.UserLevel,
 			IPLocation:   comment.IPLocation,
@@ -27,6 +27,9 @@ type NewsItem struct {
 	URL        string
 }
 
+// maxIndentDepth 楼中楼最多缩进的层数
+const maxIndentDepth = 3
+
 // CommentItem 评论项
 type CommentItem struct {
 	ID           int64
@@ -35,9 +38,11 @@ type CommentItem struct {
 	Time         string
 	SupportCount int
 	ParentID     int64
+	AnswerToID   int64
 	AnswerToName string
 	UserLevel    int
 	IPLocation   string
+	Depth        int // 楼中楼的层级，一级评论为0
 }
 
 // NewNewsViewer 创建新闻查看器
@@ -280,24 +285,64 @@ func (nv *NewsViewer) organizeComments(comments []CommentItem) []CommentItem {
 		return topLevelComments[i].Time > topLevelComments[j].Time
 	})
 
-	// 对每个一级评论，找到其所有回复并添加到结果中
+	// 对每个一级评论，按回复关系添加其所有回复
 	for _, topComment := range topLevelComments {
-		// 添加一级评论
 		organized = append(organized, topComment)
+		organized = append(organized, organizeThread(topComment, comments)...)
+	}
 
-		// 查找并添加所有回复该评论的子评论
-		var replies []CommentItem
-		for _, comment := range comments {
-			if comment.ParentID == topComment.ID {
-				replies = append(replies, comment)
-			}
+	return organized
+}
+
+// organizeThread 按 AnswerToID 将一级评论的回复组织成楼中楼：
+// 每条回复排在被回复的评论之后，同一评论的回复按时间升序；被回复的评论不在本楼内时视为回复一级评论
+func organizeThread(topComment CommentItem, comments []CommentItem) []CommentItem {
+	var replies []CommentItem
+	inThread := make(map[int64]bool)
+	for _, comment := range comments {
+		if comment.ParentID == topComment.ID {
+			replies = append(replies, comment)
+			inThread[comment.ID] = true
+		}
+	}
+
+	sort.Slice(replies, func(i, j int) bool {
+		return replies[i].Time < replies[j].Time
+	})
+
+	children := make(map[int64][]CommentItem)
+	for _, reply := range replies {
+		answerTo := reply.AnswerToID
+		if !inThread[answerTo] || answerTo == reply.ID {
+			answerTo = topComment.ID
 		}
+		children[answerTo] = append(children[answerTo], reply)
+	}
 
-		// 将回复按时间降序排序后添加
-		sort.Slice(replies, func(i, j int) bool {
-			return replies[i].Time > replies[j].Time
-		})
-		organized = append(organized, replies...)
+	var organized []CommentItem
+	visited := make(map[int64]bool)
+	var walk func(id int64, depth int)
+	walk = func(id int64, depth int) {
+		for _, reply := range children[id] {
+			if visited[reply.ID] {
+				continue
+			}
+			visited[reply.ID] = true
+			reply.Depth = depth
+			organized = append(organized, reply)
+			walk(reply.ID, depth+1)
+		}
+	}
+	walk(topComment.ID, 1)
+
+	// 回复关系成环时无法从一级评论到达，直接作为一级评论的回复显示
+	for _, reply := range replies {
+		if !visited[reply.ID] {
+			visited[reply.ID] = true
+			reply.Depth = 1
+			organized = append(organized, reply)
+			walk(reply.ID, 2)
+		}
 	}
 
 	return organized
@@ -312,12 +357,11 @@ func (nv *NewsViewer) printComments(comments []CommentItem) {
 
 // printComment 打印单条评论
 func (nv *NewsViewer) printComment(comment CommentItem, isLast bool) {
-	// 评论层级标识
+	// 评论层级标识，楼中楼每层增加缩进（最多 maxIndentDepth 层）
 	var prefix string
 	if comment.ParentID != 0 {
-		// 二级评论使用更多缩进
-		prefix = "    │ "
-		color.Blue("    │ ↳ 回复 @%s", strings.TrimSpace(comment.AnswerToName))
+		prefix = strings.Repeat("    │ ", min(max(comment.Depth, 1), maxIndentDepth))
+		color.Blue("%s↳ 回复 @%s", prefix, strings.TrimSpace(comment.AnswerToName))
 		fmt.Println()
 	}
 
@@ -340,7 +384,7 @@ func (nv *NewsViewer) printComment(comment CommentItem, isLast bool) {
 	if !isLast {
 		if comment.ParentID != 0 {
 			// 二级评论的分隔线也使用更多缩进
-			fmt.Println("    │ " + strings.Repeat("─", 54))
+			fmt.Println(prefix + strings.Repeat("─", 54))
 		} else {
 			fmt.Println(strings.Repeat("─", 60))
 		}
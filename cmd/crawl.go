@@ -1,112 +1,157 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
-	"bili-comment/crawler"
+	"bili-comment/runlog"
+	"bili-comment/source"
+	"bili-comment/store"
 
 	"github.com/spf13/cobra"
 )
 
 // CrawlerConfig 爬虫配置
 type CrawlerConfig struct {
-	BV           string        // BV号
-	Mode         int           // 爬取模式 (2=最新, 3=热门)
+	Source       string        // 评论来源插件名称
+	ItemID       string        // 条目ID (B站为BV号，Gamersky为文章ID)
+	Order        string        // 评论排序
+	Pages        int           // 评论页数限制 (0=全部)
+	MinPraises   int           // 只获取点赞数不少于该值的评论 (Gamersky)
 	WithReplies  bool          // 是否爬取二级评论
-	MaxPages     int           // 最大页数限制
+	MaxPages     int           // 二级评论最大页数限制
 	OutputPath   string        // 输出数据库路径
+	StoreDSN     string        // 存储DSN
 	CookiePath   string        // Cookie文件路径
 	RequestDelay time.Duration // 请求间隔
+	Resume       bool          // 是否从断点继续
+	Since        time.Time     // 评论时间下限
+	Until        time.Time     // 评论时间上限
+	Run          *runlog.Run   // 本次运行记录
 }
 
 // crawlCmd represents the crawl command
 var crawlCmd = &cobra.Command{
-	Use:   "crawl [BV号]",
-	Short: "爬取B站视频评论",
-	Long: `爬取指定BV号视频的评论数据，支持一级和二级评论爬取。
+	Use:   "crawl [BV号或文章ID]",
+	Short: "爬取视频或文章的评论",
+	Long: `爬取指定条目的评论数据，--source 选择评论来源插件，默认为B站。
+
+B站条目为视频BV号，支持一级和二级评论；Gamersky条目为文章ID，回复随评论一起获取。
+Gamersky评论排序可选 recommended（推荐，默认）、latest（最新）、hottest（最热），--min-praises 只获取点赞数不少于该值的评论。
+使用 list 命令查看各来源可爬取的条目。
 
 示例：
   bili-comment crawl BV1HW4y1n7BF                      # 基本用法
-  bili-comment crawl BV1HW4y1n7BF --mode=3             # 爬取热门评论
+  bili-comment crawl BV1HW4y1n7BF --mode=3             # 爬取热门评论（等同 --order=hot）
   bili-comment crawl BV1HW4y1n7BF --with-replies=false # 不爬取二级评论
   bili-comment crawl BV1HW4y1n7BF --delay=1s           # 设置1秒请求延迟
-  bili-comment crawl BV1HW4y1n7BF --output=/tmp/comments.db # 指定输出路径`,
+  bili-comment crawl BV1HW4y1n7BF --output=/tmp/comments.db # 指定输出路径
+  bili-comment crawl BV1HW4y1n7BF --resume             # 从上次中断处继续
+  bili-comment crawl BV1HW4y1n7BF --since=2024-01-01 --until=2024-01-07 # 只爬取该时间段的评论
+  bili-comment crawl --source=gamersky 2014209 --pages=5 # 爬取Gamersky文章前5页评论
+  bili-comment crawl --source=gamersky 2014209 --order=hottest --min-praises=10 # 只爬取点赞数不少于10的热门评论`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 从命令行参数获取配置
 		config := &CrawlerConfig{
-			BV: args[0],
+			ItemID: args[0],
 		}
 
 		// 获取标志值
-		config.Mode, _ = cmd.Flags().GetInt("mode")
+		config.Source, _ = cmd.Flags().GetString("source")
+		config.Order, _ = cmd.Flags().GetString("order")
+		config.Pages, _ = cmd.Flags().GetInt("pages")
+		config.MinPraises, _ = cmd.Flags().GetInt("min-praises")
 		config.WithReplies, _ = cmd.Flags().GetBool("with-replies")
 		config.MaxPages, _ = cmd.Flags().GetInt("max-pages")
 		config.OutputPath, _ = cmd.Flags().GetString("output")
+		config.StoreDSN, _ = cmd.Flags().GetString("store")
 		config.CookiePath, _ = cmd.Flags().GetString("cookie")
 		config.RequestDelay, _ = cmd.Flags().GetDuration("delay")
+		config.Resume, _ = cmd.Flags().GetBool("resume")
+
+		// --mode 为B站原有参数，等同于 --order
+		if cmd.Flags().Changed("mode") && config.Order == "" {
+			mode, _ := cmd.Flags().GetInt("mode")
+			config.Order = map[int]string{2: "newest", 3: "hot"}[mode]
+			if config.Order == "" {
+				return fmt.Errorf("不支持的爬取模式: %d (可选 2、3)", mode)
+			}
+		}
 
-		return runCrawler(config)
+		src, err := source.Get(config.Source)
+		if err != nil {
+			return err
+		}
+		if config.OutputPath == "" {
+			config.OutputPath = src.DefaultOutputPath()
+		}
+
+		config.Since, config.Until, err = getTimeRangeFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := newSignalContext()
+		defer stop()
+
+		config.Run = startRun(cmd, config.OutputPath)
+		return finishRun(config.Run, runCrawler(ctx, src, config))
 	},
 }
 
-func runCrawler(config *CrawlerConfig) error {
-	log.Println("B站评论爬虫启动...")
+func runCrawler(ctx context.Context, src source.Source, config *CrawlerConfig) error {
+	log.Printf("评论爬虫启动，来源：%s", src.Name())
+
+	// 初始化存储
+	st, err := store.Open(config.StoreDSN, config.OutputPath)
+	if err != nil {
+		return fmt.Errorf("初始化数据库失败: %v", err)
+	}
+	defer st.Close()
 
-	// 转换配置格式
-	crawlerConfig := &crawler.Config{
-		BV:           config.BV,
-		Mode:         config.Mode,
+	opts := source.Options{
+		Order:        config.Order,
+		MinPraises:   config.MinPraises,
+		Pages:        config.Pages,
 		WithReplies:  config.WithReplies,
-		MaxPages:     config.MaxPages,
-		OutputPath:   config.OutputPath,
+		ReplyPages:   config.MaxPages,
+		Resume:       config.Resume,
 		CookiePath:   config.CookiePath,
 		RequestDelay: config.RequestDelay,
+		Since:        config.Since,
+		Until:        config.Until,
+		Run:          config.Run,
 	}
-
-	// 创建爬虫实例
-	crawlerInstance, err := crawler.NewBilibiliCommentCrawler(crawlerConfig)
-	if err != nil {
-		return fmt.Errorf("创建爬虫失败: %v", err)
+	logTimeRange(config.Since, config.Until)
+
+	// 插件获取的评论逐条写入存储
+	var comments, replies int
+	emit := func(record interface{}) (bool, error) {
+		if entry, err := src.Normalize(record); err == nil && entry.Kind == source.KindComment {
+			if entry.IsReply() {
+				replies++
+			} else {
+				comments++
+			}
+		}
+		return store.SaveRecord(st, record, config.Run.ID())
 	}
-	defer crawlerInstance.Close()
 
-	// 获取视频信息
-	oid, title, err := crawlerInstance.GetVideoInfo(config.BV)
-	if err != nil {
-		return fmt.Errorf("获取视频信息失败: %v", err)
+	_, err = src.FetchComments(ctx, config.ItemID, opts, emit)
+	if isInterrupted(err) {
+		log.Printf("已获取一级评论 %d 条，回复 %d 条", comments, replies)
+		return err
 	}
-
-	log.Printf("开始爬取视频 %s 的评论，标题：%s", config.BV, title)
-	log.Printf("爬取模式：%s", map[int]string{2: "最新", 3: "热门"}[config.Mode])
-	log.Printf("是否爬取二级评论：%t", config.WithReplies)
-	log.Printf("请求延迟：%v", config.RequestDelay)
-
-	// 初始化变量
-	nextPageID := ""
-	count := 0
-
-	// 开始爬取
-	for {
-		var err error
-		nextPageID, count, err = crawlerInstance.CrawlComments(config.BV, oid, nextPageID, count, title, config.WithReplies)
-		if err != nil {
-			log.Printf("爬取评论失败: %v", err)
-			break
-		}
-
-		if nextPageID == "" || nextPageID == "0" {
-			log.Printf("评论爬取完成！总共爬取 %d 条评论", count)
-			break
-		}
-
-		log.Printf("当前爬取 %d 条评论", count)
-		time.Sleep(config.RequestDelay) // 使用配置的延迟
+	if err != nil {
+		return fmt.Errorf("爬取评论失败: %v", err)
 	}
 
-	log.Printf("所有评论已保存到 SQLite 数据库：%s", config.OutputPath)
+	log.Printf("共获取一级评论 %d 条，回复 %d 条", comments, replies)
+	log.Printf("所有评论已保存到 %s", describeStore(config.StoreDSN, config.OutputPath))
 	return nil
 }
 
@@ -114,10 +159,16 @@ func init() {
 	rootCmd.AddCommand(crawlCmd)
 
 	// 添加命令行参数
-	crawlCmd.Flags().Int("mode", 2, "爬取模式 (2=最新评论, 3=热门评论)")
+	crawlCmd.Flags().String("source", source.Bilibili, "评论来源 ("+strings.Join(source.Names(), "、")+")")
+	crawlCmd.Flags().String("order", "", "评论排序 (B站: newest、hot；Gamersky: recommended、latest、hottest)，为空时使用来源默认排序")
+	crawlCmd.Flags().Int("pages", 0, "最多爬取的评论页数 (0=全部)")
+	crawlCmd.Flags().Int("min-praises", 0, "Gamersky只获取点赞数不少于该值的评论 (0=不限制)")
+	crawlCmd.Flags().Int("mode", 2, "B站爬取模式 (2=最新评论, 3=热门评论)")
 	crawlCmd.Flags().Bool("with-replies", true, "是否爬取二级评论")
 	crawlCmd.Flags().Int("max-pages", 10, "二级评论最大页数限制 (0=无限制)")
-	crawlCmd.Flags().String("output", "./data/crawler.db", "输出数据库文件路径")
+	crawlCmd.Flags().String("output", "", "输出数据库文件路径 (默认 B站为 ./data/crawler.db，Gamersky为 ./data/gamersky.db)")
 	crawlCmd.Flags().String("cookie", "", "Cookie文件路径 (为空时自动查找)")
 	crawlCmd.Flags().Duration("delay", 500*time.Millisecond, "请求间隔时间")
+	crawlCmd.Flags().Bool("resume", false, "从上次中断保存的断点继续爬取 (B站)")
+	addTimeRangeFlags(crawlCmd)
 }
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"bili-comment/store"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/spf13/cobra"
+)
+
+// dbCmd represents the db command
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "管理数据库结构版本",
+	Long: `查看和执行数据库结构迁移。
+
+数据库版本保存在 PRAGMA user_version 中，爬取和查询命令打开数据库时会自动执行未执行的迁移，
+也可以使用 db migrate 手动迁移。
+
+示例：
+  bili-comment db status                           # 查看默认数据库的版本和迁移状态
+  bili-comment db status --db=./data/gamersky.db   # 查看Gamersky数据库
+  bili-comment db migrate                          # 执行未执行的迁移
+  bili-comment db merge merged.db run1.db run2.db  # 合并多个数据库`,
+}
+
+// dbStatusCmd represents the db status command
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "查看数据库版本和迁移状态",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbPath, _ := cmd.Flags().GetString("db")
+
+		return runDBStatus(dbPath)
+	},
+}
+
+// dbMigrateCmd represents the db migrate command
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "执行未执行的数据库迁移",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbPath, _ := cmd.Flags().GetString("db")
+
+		return runDBMigrate(dbPath)
+	},
+}
+
+// dbMergeCmd represents the db merge command
+var dbMergeCmd = &cobra.Command{
+	Use:   "merge [输出数据库] [输入数据库...]",
+	Short: "合并多个数据库",
+	Long: `将多个数据库中的Gamersky新闻、新闻评论数历史、评论爬取状态、文章正文、评论及评论图片、B站视频和B站评论合并到输出数据库。
+
+输出数据库不存在时自动创建；输入数据库可以是任意版本，合并前在临时副本上迁移到最新版本，不会修改输入文件。
+同一条记录在多个数据库中出现时保留较大的计数（评论数、点赞数、回复数、播放量等），其余字段保留先合并的值；
+新闻的评论数较大时同时采用该数据库中的标题、链接、图片和发布时间。
+新闻评论数历史按新闻ID、记录时间和评论数去重。
+评论爬取状态以爬取时间较晚的一方为准；
+评论排序记录保留较早的首次出现时间和较晚的最近出现时间，名次以最近一次出现时为准。
+合并后的数据行 run_id 为0。
+
+示例：
+  bili-comment db merge ./data/gamersky.db ./artifacts/*/gamersky.db`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDBMerge(args[0], args[1:])
+	},
+}
+
+// openExistingDB 打开已存在的数据库，不执行迁移
+func openExistingDB(dbPath string) (*sql.DB, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("数据库不存在: %s", dbPath)
+	}
+
+	db, err := sql.Open("sqlite3", dbPath+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+	return db, nil
+}
+
+func runDBStatus(dbPath string) error {
+	db, err := openExistingDB(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	version, err := store.SchemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("读取数据库版本失败: %v", err)
+	}
+
+	fmt.Printf("数据库: %s\n", dbPath)
+	fmt.Printf("当前版本: %d\n", version)
+	fmt.Printf("最新版本: %d\n", store.LatestVersion())
+	if version > store.LatestVersion() {
+		fmt.Println("数据库版本高于程序支持的版本，请升级程序")
+	}
+
+	if store.FTSAvailable(db) {
+		fmt.Println("全文索引: 已启用 (FTS5 trigram)")
+	} else {
+		fmt.Println("全文索引: 未启用 (需使用 -tags sqlite_fts5 构建程序)")
+	}
+
+	fmt.Printf("\n%-6s %-8s %s\n", "版本", "状态", "说明")
+	fmt.Println(strings.Repeat("-", 60))
+	for _, m := range store.Migrations() {
+		status := "待执行"
+		if m.Version <= version {
+			status = "已执行"
+		}
+		fmt.Printf("%-6d %-8s %s\n", m.Version, status, m.Description)
+	}
+
+	return nil
+}
+
+func runDBMigrate(dbPath string) error {
+	db, err := openExistingDB(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	applied, err := store.Migrate(db)
+	for _, m := range applied {
+		fmt.Printf("已执行迁移 %d: %s\n", m.Version, m.Description)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 维护评论全文索引
+	if _, err := store.EnsureSearchIndex(db); err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Printf("数据库 %s 已是最新版本 %d\n", dbPath, store.LatestVersion())
+		return nil
+	}
+
+	fmt.Printf("数据库 %s 已迁移到版本 %d\n", dbPath, applied[len(applied)-1].Version)
+	return nil
+}
+
+func runDBMerge(outPath string, inPaths []string) error {
+	outAbs, err := filepath.Abs(outPath)
+	if err != nil {
+		return err
+	}
+	for _, inPath := range inPaths {
+		if inAbs, err := filepath.Abs(inPath); err == nil && inAbs == outAbs {
+			return fmt.Errorf("输入数据库不能与输出数据库相同: %s", inPath)
+		}
+	}
+
+	out, err := store.OpenSQLite(outPath)
+	if err != nil {
+		return fmt.Errorf("打开输出数据库失败: %v", err)
+	}
+	defer out.Close()
+
+	totals := make(map[string]*store.MergeResult)
+	var tables []string
+
+	fmt.Printf("%-40s %-24s %-10s %-10s\n", "输入", "数据表", "插入", "更新")
+	fmt.Println(strings.Repeat("-", 88))
+	for _, inPath := range inPaths {
+		results, err := store.MergeDatabase(out, inPath)
+		if err != nil {
+			return fmt.Errorf("合并 %s 失败: %v", inPath, err)
+		}
+
+		for _, result := range results {
+			fmt.Printf("%-40s %-24s %-10d %-10d\n", inPath, result.Table, result.Inserted, result.Updated)
+
+			total, ok := totals[result.Table]
+			if !ok {
+				total = &store.MergeResult{Table: result.Table}
+				totals[result.Table] = total
+				tables = append(tables, result.Table)
+			}
+			total.Inserted += result.Inserted
+			total.Updated += result.Updated
+		}
+	}
+
+	fmt.Println(strings.Repeat("-", 88))
+	for _, table := range tables {
+		fmt.Printf("%-40s %-24s %-10d %-10d\n", "合计", table, totals[table].Inserted, totals[table].Updated)
+	}
+	fmt.Printf("\n已将 %d 个数据库合并到 %s\n", len(inPaths), outPath)
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbStatusCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbMergeCmd)
+
+	// 添加命令行参数
+	dbCmd.PersistentFlags().String("db", "./data/crawler.db", "数据库文件路径")
+}
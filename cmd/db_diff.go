@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"bili-comment/store"
+
+	"github.com/spf13/cobra"
+)
+
+// dbDiffCmd represents the db diff command
+var dbDiffCmd = &cobra.Command{
+	Use:   "diff [旧数据库] [新数据库]",
+	Short: "比较两个数据库的差异",
+	Long: `比较两个数据库快照，列出新增和删除的新闻、按文章或视频统计的新增评论，以及点赞数或回复数变化达到阈值的评论。
+
+两个数据库可以是任意版本，比较前在临时副本上迁移到最新版本，不会修改输入文件。
+
+示例：
+  bili-comment db diff old.db new.db                          # 以表格输出
+  bili-comment db diff old.db new.db --threshold=50           # 计数变化达到50才列出
+  bili-comment db diff old.db new.db --format=markdown        # 输出Markdown，适合贴到PR或群聊
+  bili-comment db diff old.db new.db --format=json > diff.json`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		threshold, _ := cmd.Flags().GetInt64("threshold")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		return runDBDiff(args[0], args[1], format, threshold, limit)
+	},
+}
+
+func runDBDiff(oldPath, newPath, format string, threshold int64, limit int) error {
+	var render func(w io.Writer, report *store.DiffReport) error
+	switch format {
+	case "table":
+		render = renderDiffTable
+	case "json":
+		render = renderDiffJSON
+	case "markdown", "md":
+		render = renderDiffMarkdown
+	default:
+		return fmt.Errorf("不支持的输出格式: %s (可选 table、json、markdown)", format)
+	}
+
+	report, err := store.DiffDatabases(oldPath, newPath, threshold, limit)
+	if err != nil {
+		return fmt.Errorf("比较数据库失败: %v", err)
+	}
+
+	return render(os.Stdout, report)
+}
+
+// renderDiffJSON 以JSON输出差异
+func renderDiffJSON(w io.Writer, report *store.DiffReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// renderDiffTable 以表格输出差异
+func renderDiffTable(w io.Writer, report *store.DiffReport) error {
+	fmt.Fprintf(w, "旧数据库: %s\n新数据库: %s\n", report.Old, report.New)
+	fmt.Fprintf(w, "新增新闻 %d 条，删除新闻 %d 条，新增评论 %d 条，计数变化达到 %d 的评论 %d 条\n",
+		report.Summary.NewNews, report.Summary.RemovedNews, report.Summary.NewComments,
+		report.Threshold, report.Summary.ChangedComments)
+
+	printNews := func(title string, news []store.NewsChange) {
+		fmt.Fprintf(w, "\n%s：\n", title)
+		if len(news) == 0 {
+			fmt.Fprintln(w, "  无")
+			return
+		}
+		fmt.Fprintf(w, "%-12s %-8s %s\n", "SID", "评论数", "标题")
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		for _, item := range news {
+			fmt.Fprintf(w, "%-12s %-8d %s\n", item.SID, item.CommentNum, item.Title)
+		}
+	}
+	printNews("新增新闻", report.NewNews)
+	printNews("删除新闻", report.RemovedNews)
+
+	fmt.Fprintln(w, "\n新增评论：")
+	if len(report.NewComments) == 0 {
+		fmt.Fprintln(w, "  无")
+	} else {
+		fmt.Fprintf(w, "%-10s %-16s %-8s %s\n", "来源", "文章/视频", "新增", "标题")
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		for _, group := range report.NewComments {
+			fmt.Fprintf(w, "%-10s %-16s %-8d %s\n", group.Source, group.TargetID, group.Count, group.Title)
+		}
+	}
+
+	fmt.Fprintln(w, "\n计数变化的评论：")
+	if len(report.ChangedComments) == 0 {
+		fmt.Fprintln(w, "  无")
+	} else {
+		fmt.Fprintf(w, "%-10s %-16s %-14s %-14s %-16s %s\n", "来源", "文章/视频", "点赞数", "回复数", "用户名", "评论内容")
+		fmt.Fprintln(w, strings.Repeat("-", 110))
+		for _, change := range report.ChangedComments {
+			fmt.Fprintf(w, "%-10s %-16s %-14s %-14s %-16s %s\n", change.Source, change.TargetID,
+				formatCounterChange(change.OldLikes, change.NewLikes),
+				formatCounterChange(change.OldReplies, change.NewReplies),
+				change.Username, truncateRunes(change.Content, 40))
+		}
+	}
+
+	return nil
+}
+
+// renderDiffMarkdown 以Markdown输出差异
+func renderDiffMarkdown(w io.Writer, report *store.DiffReport) error {
+	fmt.Fprintf(w, "## 数据库差异\n\n")
+	fmt.Fprintf(w, "`%s` → `%s`\n\n", report.Old, report.New)
+	fmt.Fprintf(w, "| 项目 | 数量 |\n|------|------|\n")
+	fmt.Fprintf(w, "| 新增新闻 | %d |\n", report.Summary.NewNews)
+	fmt.Fprintf(w, "| 删除新闻 | %d |\n", report.Summary.RemovedNews)
+	fmt.Fprintf(w, "| 新增评论 | %d |\n", report.Summary.NewComments)
+	fmt.Fprintf(w, "| 计数变化 ≥ %d 的评论 | %d |\n", report.Threshold, report.Summary.ChangedComments)
+
+	writeNews := func(title string, news []store.NewsChange) {
+		if len(news) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n### %s\n\n| SID | 标题 | 评论数 |\n|-----|------|--------|\n", title)
+		for _, item := range news {
+			fmt.Fprintf(w, "| %s | %s | %d |\n", item.SID, escapeMarkdownCell(item.Title), item.CommentNum)
+		}
+	}
+	writeNews("新增新闻", report.NewNews)
+	writeNews("删除新闻", report.RemovedNews)
+
+	if len(report.NewComments) > 0 {
+		fmt.Fprintf(w, "\n### 新增评论\n\n| 来源 | 文章/视频 | 标题 | 新增 |\n|------|-----------|------|------|\n")
+		for _, group := range report.NewComments {
+			fmt.Fprintf(w, "| %s | %s | %s | %d |\n", group.Source, group.TargetID, escapeMarkdownCell(group.Title), group.Count)
+		}
+	}
+
+	if len(report.ChangedComments) > 0 {
+		fmt.Fprintf(w, "\n### 计数变化的评论\n\n| 来源 | 文章/视频 | 用户名 | 评论内容 | 点赞数 | 回复数 |\n|------|-----------|--------|----------|--------|--------|\n")
+		for _, change := range report.ChangedComments {
+			fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s |\n", change.Source, change.TargetID,
+				escapeMarkdownCell(change.Username), escapeMarkdownCell(truncateRunes(change.Content, 40)),
+				formatCounterChange(change.OldLikes, change.NewLikes),
+				formatCounterChange(change.OldReplies, change.NewReplies))
+		}
+	}
+
+	return nil
+}
+
+// formatCounterChange 格式化计数变化，如 "12→30 (+18)"
+func formatCounterChange(oldValue, newValue int64) string {
+	if oldValue == newValue {
+		return fmt.Sprintf("%d", newValue)
+	}
+	return fmt.Sprintf("%d→%d (%+d)", oldValue, newValue, newValue-oldValue)
+}
+
+// truncateRunes 按字符截断字符串
+func truncateRunes(text string, n int) string {
+	text = strings.ReplaceAll(text, "\n", " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n]) + "..."
+}
+
+// escapeMarkdownCell 转义Markdown表格单元格中的竖线和换行
+func escapeMarkdownCell(text string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "\r", "").Replace(text)
+}
+
+func init() {
+	dbCmd.AddCommand(dbDiffCmd)
+
+	// 添加命令行参数
+	dbDiffCmd.Flags().String("format", "table", "输出格式 (table、json、markdown)")
+	dbDiffCmd.Flags().Int64("threshold", 10, "点赞数或回复数变化达到该值的评论才列出")
+	dbDiffCmd.Flags().Int("limit", 50, "每个列表最多显示的条数 (0=全部)")
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bili-comment/export"
+	"bili-comment/store"
+
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "导出数据为CSV、JSONL、Excel或Parquet文件",
+	Long: `将数据库中的B站评论、B站视频、Gamersky新闻或Gamersky评论导出为文件，支持与查询命令相同的过滤条件。
+
+数据逐行读取并写出，导出大表时内存占用保持稳定。
+  csv      UTF-8 BOM 编码，可直接用 Excel 打开
+  jsonl    每行一个JSON对象，写出前按 schema 命令生成的JSON Schema校验，NULL 输出为对应类型的零值
+  xlsx     Excel 工作簿，首行表头冻结，单个文件最多 1048576 行
+  parquet  整数、布尔值和时间列保留类型，适合 pandas、DuckDB 等分析工具
+
+未指定 --format 时根据 --output 的扩展名判断格式；未指定 --output 时 csv 和 jsonl 输出到标准输出。
+
+示例：
+  bili-comment export --source=bilibili-comments --output=comments.csv
+  bili-comment export --source=bilibili-comments --bv=BV1xxx --format=jsonl > comments.jsonl
+  bili-comment export --source=bilibili-videos --keyword=原神 --output=videos.xlsx
+  bili-comment export --source=gamersky-news --output=news.parquet
+  bili-comment export --source=gamersky-comments --article-id=2014209 --since=2024-01-01 --output=comments.parquet`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sourceName, _ := cmd.Flags().GetString("source")
+		format, _ := cmd.Flags().GetString("format")
+		outputPath, _ := cmd.Flags().GetString("output")
+		dbPath, _ := cmd.Flags().GetString("db")
+
+		var filter export.Filter
+		filter.BV, _ = cmd.Flags().GetString("bv")
+		filter.User, _ = cmd.Flags().GetString("user")
+		filter.Keyword, _ = cmd.Flags().GetString("keyword")
+		filter.ArticleID, _ = cmd.Flags().GetString("article-id")
+		filter.Limit, _ = cmd.Flags().GetInt("limit")
+
+		var err error
+		filter.Since, filter.Until, err = getTimeRangeFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		return runExport(sourceName, format, outputPath, dbPath, filter)
+	},
+}
+
+func runExport(sourceName, format, outputPath, dbPath string, filter export.Filter) error {
+	source, ok := export.Sources[sourceName]
+	if !ok {
+		return fmt.Errorf("不支持的数据来源: %s (可选 %s)", sourceName, strings.Join(export.SourceNames(), "、"))
+	}
+
+	// 未指定格式时根据文件扩展名判断
+	if format == "" {
+		if outputPath == "" {
+			return fmt.Errorf("请指定 --format 或 --output")
+		}
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(outputPath)), ".")
+	}
+	if outputPath == "" && format != export.FormatCSV && format != export.FormatJSONL {
+		return fmt.Errorf("%s 格式需要通过 --output 指定输出文件", format)
+	}
+
+	if dbPath == "" {
+		dbPath = source.DefaultPath
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("数据库不存在: %s", dbPath)
+	}
+
+	// 连接数据库（自动执行结构迁移）
+	sqliteStore, err := store.OpenSQLite(dbPath)
+	if err != nil {
+		return fmt.Errorf("连接数据库失败: %v", err)
+	}
+	defer sqliteStore.Close()
+
+	var out io.Writer = os.Stdout
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("创建输出文件失败: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	writer, err := export.NewWriter(format, out, source)
+	if err != nil {
+		removeOutput(outputPath)
+		return err
+	}
+
+	start := time.Now()
+	count, err := export.Export(sqliteStore.DB(), source, filter, writer)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		removeOutput(outputPath)
+		return fmt.Errorf("导出失败: %v", err)
+	}
+
+	if outputPath != "" {
+		log.Printf("已导出 %d 条 %s 数据到 %s，耗时 %v", count, sourceName, outputPath, time.Since(start).Round(time.Millisecond))
+	} else {
+		log.Printf("已导出 %d 条 %s 数据", count, sourceName)
+	}
+	return nil
+}
+
+// removeOutput 导出失败时删除不完整的输出文件
+func removeOutput(outputPath string) {
+	if outputPath != "" {
+		os.Remove(outputPath)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	// 添加命令行参数
+	exportCmd.Flags().String("source", "", "数据来源 ("+strings.Join(export.SourceNames(), "、")+")")
+	exportCmd.Flags().String("format", "", "导出格式 ("+strings.Join(export.Formats, "、")+")，默认根据输出文件扩展名判断")
+	exportCmd.Flags().String("output", "", "输出文件路径 (为空时输出到标准输出，仅支持 csv 和 jsonl)")
+	exportCmd.Flags().String("db", "", "数据库文件路径 (默认 B站数据为 ./data/crawler.db，Gamersky数据为 ./data/gamersky.db)")
+	exportCmd.Flags().String("bv", "", "只导出指定BV号的评论 (bilibili-comments)")
+	exportCmd.Flags().String("user", "", "只导出指定用户的评论 (bilibili-comments)")
+	exportCmd.Flags().String("keyword", "", "只导出指定搜索关键词的视频 (bilibili-videos)")
+	exportCmd.Flags().String("article-id", "", "只导出指定文章的评论 (gamersky-comments)")
+	exportCmd.Flags().Int("limit", 0, "最多导出的条数 (0=全部)")
+	addTimeRangeFlags(exportCmd)
+	exportCmd.MarkFlagRequired("source")
+}
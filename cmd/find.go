@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"bili-comment/store"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// snippetRadius 摘要中命中词前后保留的字符数
+const snippetRadius = 30
+
+// findCmd represents the find command
+var findCmd = &cobra.Command{
+	Use:   "find [检索表达式]",
+	Short: "全文检索B站和Gamersky评论",
+	Long: `在已爬取的B站评论和Gamersky评论中全文检索，显示高亮摘要、来源、视频或文章标题、点赞数和评论时间。
+
+检索语法：
+  续航 价格          同时包含两个词（AND 可省略）
+  续航 OR 价格       包含任意一个词
+  续航 NOT 价格      包含前者但不包含后者
+  "电池 续航"        短语，按完整字符串匹配
+  电池*              与 电池 相同，兼容前缀写法
+  (续航 OR 电池) 价格 括号分组
+
+评论内容使用 trigram 全文索引，检索词按子串匹配；少于三个字符的词回退为 LIKE 扫描。
+由于按子串匹配，词末尾的 * 会被忽略，"电池*" 与 "电池" 匹配相同的评论。
+全文索引需要使用 -tags sqlite_fts5 构建程序，否则所有检索词都使用 LIKE 扫描。
+
+示例：
+  bili-comment find "续航"                                   # 检索默认的两个数据库
+  bili-comment find "续航 OR 充电" --source=bilibili         # 只检索B站评论
+  bili-comment find '"显卡 驱动" NOT 黑屏' --limit=50        # 短语与排除
+  bili-comment find "优化*" --db=./data/gamersky.db          # 只检索指定数据库`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbPaths, _ := cmd.Flags().GetStringSlice("db")
+		source, _ := cmd.Flags().GetString("source")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		return runFind(args[0], dbPaths, source, limit)
+	},
+}
+
+func runFind(query string, dbPaths []string, source string, limit int) error {
+	parsed, err := store.ParseSearchQuery(query)
+	if err != nil {
+		return fmt.Errorf("解析检索表达式失败: %v", err)
+	}
+
+	var sources []string
+	switch source {
+	case "", "all":
+	case store.SourceBilibili, store.SourceGamersky:
+		sources = []string{source}
+	default:
+		return fmt.Errorf("不支持的来源: %s (可选 all、bilibili、gamersky)", source)
+	}
+
+	var hits []store.SearchHit
+	for _, dbPath := range dbPaths {
+		if _, err := os.Stat(dbPath); err != nil {
+			log.Printf("数据库 %s 不存在，跳过", dbPath)
+			continue
+		}
+
+		dbHits, err := findInDB(dbPath, parsed, sources, limit)
+		if err != nil {
+			return err
+		}
+		hits = append(hits, dbHits...)
+	}
+
+	store.SortSearchHits(hits)
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	if len(hits) == 0 {
+		fmt.Println("没有找到匹配的评论")
+		return nil
+	}
+
+	terms := parsed.Terms()
+	highlight := color.New(color.FgRed, color.Bold).SprintFunc()
+
+	fmt.Printf("找到 %d 条评论：\n", len(hits))
+	fmt.Println(strings.Repeat("=", 80))
+	for i, hit := range hits {
+		title := hit.Title
+		if title == "" {
+			title = hit.TargetID
+		}
+
+		fmt.Printf("%d. [%s] %s (%s)\n", i+1, hit.Source, title, hit.TargetID)
+		fmt.Printf("  用户: %s  点赞数: %d  时间: %s\n", hit.Username, hit.Likes, hit.Time)
+		fmt.Printf("  %s\n", buildSnippet(hit.Content, terms, highlight))
+		fmt.Println(strings.Repeat("-", 80))
+	}
+
+	return nil
+}
+
+// findInDB 在一个数据库中检索评论，只读取数据库，版本低于最新版本时提示先执行迁移
+func findInDB(dbPath string, parsed *store.SearchQuery, sources []string, limit int) ([]store.SearchHit, error) {
+	db, err := openExistingDB(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	version, err := store.SchemaVersion(db)
+	if err != nil {
+		return nil, fmt.Errorf("读取数据库版本失败: %v", err)
+	}
+	if version < store.LatestVersion() {
+		return nil, fmt.Errorf("数据库 %s 的版本为 %d，低于最新版本 %d，请先运行 bili-comment db migrate --db=%s",
+			dbPath, version, store.LatestVersion(), dbPath)
+	}
+	if !store.SearchIndexReady(db) {
+		log.Printf("%s 的全文索引不可用，使用LIKE扫描检索（需使用 -tags sqlite_fts5 构建程序并运行 db migrate 创建索引）", dbPath)
+	}
+
+	hits, err := store.SearchComments(db, parsed, sources, limit)
+	if err != nil {
+		return nil, fmt.Errorf("检索 %s 失败: %v", dbPath, err)
+	}
+	return hits, nil
+}
+
+// buildSnippet 截取第一个命中词附近的内容作为摘要，并高亮所有命中词
+func buildSnippet(content string, terms []string, highlight func(a ...interface{}) string) string {
+	runes := []rune(strings.ReplaceAll(content, "\n", " "))
+	lower := []rune(strings.ToLower(string(runes)))
+
+	// 找到第一个命中位置，大小写不敏感
+	first := -1
+	for _, term := range terms {
+		if pos := indexRunes(lower, []rune(strings.ToLower(term))); pos >= 0 && (first < 0 || pos < first) {
+			first = pos
+		}
+	}
+
+	start, end := 0, len(runes)
+	if first > snippetRadius {
+		start = first - snippetRadius
+	}
+	if end-start > snippetRadius*3 {
+		end = start + snippetRadius*3
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	for i := start; i < end; {
+		matched := 0
+		for _, term := range terms {
+			termRunes := []rune(strings.ToLower(term))
+			if len(termRunes) > matched && i+len(termRunes) <= len(lower) && indexRunes(lower[i:i+len(termRunes)], termRunes) == 0 {
+				matched = len(termRunes)
+			}
+		}
+
+		if matched > 0 {
+			b.WriteString(highlight(string(runes[i : i+matched])))
+			i += matched
+			continue
+		}
+		b.WriteRune(runes[i])
+		i++
+	}
+	if end < len(runes) {
+		b.WriteString("...")
+	}
+
+	return b.String()
+}
+
+// indexRunes 在 s 中查找 sub 的位置，不存在时返回 -1
+func indexRunes(s, sub []rune) int {
+	if len(sub) == 0 {
+		return -1
+	}
+	for i := 0; i+len(sub) <= len(s); i++ {
+		match := true
+		for j := range sub {
+			if s[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+func init() {
+	rootCmd.AddCommand(findCmd)
+
+	// 添加命令行参数
+	findCmd.Flags().StringSlice("db", []string{"./data/crawler.db", "./data/gamersky.db"}, "要检索的数据库文件路径，可指定多个")
+	findCmd.Flags().String("source", "all", "检索来源 (all、bilibili、gamersky)")
+	findCmd.Flags().Int("limit", 20, "显示的结果数量 (0=全部)")
+}
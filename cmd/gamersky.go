@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
 
 	"bili-comment/gamersky"
+	"bili-comment/httpclient"
+	"bili-comment/runlog"
 
 	"github.com/spf13/cobra"
 )
@@ -14,7 +17,10 @@ import (
 type GamerskyConfig struct {
 	Pages        int           // 爬取页数
 	OutputPath   string        // 输出数据库路径
+	StoreDSN     string        // 存储DSN
+	Channel      string        // 新闻频道
 	RequestDelay time.Duration // 请求间隔
+	Run          *runlog.Run   // 本次运行记录
 }
 
 // gamerskyCmd represents the gamersky command
@@ -27,7 +33,8 @@ var gamerskyCmd = &cobra.Command{
   bili-comment gamersky                           # 爬取第1页新闻
   bili-comment gamersky --pages=5                # 爬取前5页新闻
   bili-comment gamersky --delay=1s               # 设置1秒请求延迟
-  bili-comment gamersky --output=/tmp/news.db    # 指定输出路径`,
+  bili-comment gamersky --output=/tmp/news.db    # 指定输出路径
+  bili-comment gamersky --channel=review        # 爬取评测频道第1页`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 从命令行参数获取配置
 		config := &GamerskyConfig{}
@@ -35,6 +42,8 @@ var gamerskyCmd = &cobra.Command{
 		// 获取标志值
 		config.Pages, _ = cmd.Flags().GetInt("pages")
 		config.OutputPath, _ = cmd.Flags().GetString("output")
+		config.StoreDSN, _ = cmd.Flags().GetString("store")
+		config.Channel, _ = cmd.Flags().GetString("channel")
 		config.RequestDelay, _ = cmd.Flags().GetDuration("delay")
 
 		// 确保延迟时间有默认值
@@ -42,17 +51,28 @@ var gamerskyCmd = &cobra.Command{
 			config.RequestDelay = 1 * time.Second
 		}
 
-		return runGamerskyrawler(config)
+		if _, _, err := gamersky.LookupChannel(config.Channel); err != nil {
+			return err
+		}
+
+		ctx, stop := newSignalContext()
+		defer stop()
+
+		config.Run = startRun(cmd, config.OutputPath)
+		return finishRun(config.Run, runGamerskyrawler(ctx, config))
 	},
 }
 
-func runGamerskyrawler(config *GamerskyConfig) error {
+func runGamerskyrawler(ctx context.Context, config *GamerskyConfig) error {
 	log.Println("Gamersky新闻爬虫启动...")
 
 	// 转换配置格式
 	crawlerConfig := &gamersky.Config{
 		OutputPath:   config.OutputPath,
+		StoreDSN:     config.StoreDSN,
+		Channel:      config.Channel,
 		RequestDelay: config.RequestDelay,
+		Run:          config.Run,
 	}
 
 	// 创建爬虫实例
@@ -62,7 +82,7 @@ func runGamerskyrawler(config *GamerskyConfig) error {
 	}
 	defer crawlerInstance.Close()
 
-	log.Printf("开始爬取Gamersky新闻，页数：%d", config.Pages)
+	log.Printf("开始爬取Gamersky新闻，频道：%s，页数：%d", gamersky.ChannelLabel(config.Channel), config.Pages)
 	log.Printf("请求延迟：%v", config.RequestDelay)
 
 	// 开始爬取
@@ -70,8 +90,12 @@ func runGamerskyrawler(config *GamerskyConfig) error {
 	for page := 1; page <= config.Pages; page++ {
 		log.Printf("正在爬取第 %d 页...", page)
 
-		count, err := crawlerInstance.CrawlNews(page)
+		count, err := crawlerInstance.CrawlNews(ctx, page)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
+			config.Run.RecordError(err)
 			log.Printf("爬取第 %d 页失败: %v", page, err)
 			continue
 		}
@@ -87,11 +111,13 @@ func runGamerskyrawler(config *GamerskyConfig) error {
 
 		// 延迟
 		if page < config.Pages {
-			time.Sleep(config.RequestDelay)
+			if err := httpclient.Sleep(ctx, config.RequestDelay); err != nil {
+				return err
+			}
 		}
 	}
 
-	log.Printf("爬取完成！总共爬取 %d 条新闻，已保存到 SQLite 数据库：%s", totalCount, config.OutputPath)
+	log.Printf("爬取完成！总共爬取 %d 条新闻，已保存到 %s", totalCount, describeStore(config.StoreDSN, config.OutputPath))
 	return nil
 }
 
@@ -101,5 +127,6 @@ func init() {
 	// 添加命令行参数
 	gamerskyCmd.Flags().Int("pages", 1, "爬取页数")
 	gamerskyCmd.Flags().String("output", "./data/gamersky.db", "输出数据库文件路径")
+	gamerskyCmd.Flags().String("channel", "", "新闻频道，为空时爬取手机版首页信息流 (可选 "+gamersky.ChannelNames()+")")
 	gamerskyCmd.Flags().Duration("delay", 1*time.Second, "请求间隔时间")
 }
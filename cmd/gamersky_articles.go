@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"bili-comment/gamersky"
+	"bili-comment/httpclient"
+	"bili-comment/runlog"
+
+	"github.com/spf13/cobra"
+)
+
+// GamerskyArticlesConfig Gamersky文章正文爬虫配置
+type GamerskyArticlesConfig struct {
+	ArticleIDs   []string      // 指定的文章ID，为空时处理数据库中的新闻
+	Limit        int           // 最多处理的新闻数
+	Refetch      bool          // 是否重新获取已保存的文章
+	OutputPath   string        // 输出数据库路径
+	StoreDSN     string        // 存储DSN
+	RequestDelay time.Duration // 请求间隔
+	Run          *runlog.Run   // 本次运行记录
+}
+
+// gamerskyArticlesCmd represents the gamersky-articles command
+var gamerskyArticlesCmd = &cobra.Command{
+	Use:   "gamersky-articles [文章ID...]",
+	Short: "爬取Gamersky新闻的文章正文",
+	Long: `按 gamersky_news 中新闻的链接爬取文章正文，保存到 gamersky_articles 表。
+
+提取标题、作者、编辑、来源、发布时间、标签、正文段落和正文图片，多页文章会沿“下一页”链接合并全部分页。
+不指定文章ID时按记录时间倒序处理数据库中的新闻，跳过已保存正文的文章；指定文章ID时直接获取对应的手机版文章页。
+
+示例：
+  bili-comment gamersky-articles                      # 获取最近20条新闻的正文
+  bili-comment gamersky-articles --limit=0            # 获取所有尚未保存正文的新闻
+  bili-comment gamersky-articles 1905789 1895887      # 获取指定文章的正文
+  bili-comment gamersky-articles 1905789 --refetch    # 重新获取已保存的文章`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 从命令行参数获取配置
+		config := &GamerskyArticlesConfig{ArticleIDs: args}
+
+		// 获取标志值
+		config.Limit, _ = cmd.Flags().GetInt("limit")
+		config.Refetch, _ = cmd.Flags().GetBool("refetch")
+		config.OutputPath, _ = cmd.Flags().GetString("output")
+		config.StoreDSN, _ = cmd.Flags().GetString("store")
+		config.RequestDelay, _ = cmd.Flags().GetDuration("delay")
+
+		// 确保延迟时间有默认值
+		if config.RequestDelay == 0 {
+			config.RequestDelay = 1 * time.Second
+		}
+
+		ctx, stop := newSignalContext()
+		defer stop()
+
+		config.Run = startRun(cmd, config.OutputPath)
+		return finishRun(config.Run, runGamerskyArticles(ctx, config))
+	},
+}
+
+func runGamerskyArticles(ctx context.Context, config *GamerskyArticlesConfig) error {
+	log.Println("Gamersky文章正文爬虫启动...")
+
+	// 转换配置格式
+	crawlerConfig := &gamersky.Config{
+		OutputPath:   config.OutputPath,
+		StoreDSN:     config.StoreDSN,
+		RequestDelay: config.RequestDelay,
+		Run:          config.Run,
+	}
+
+	newsList, err := articleNewsList(crawlerConfig, config)
+	if err != nil {
+		return err
+	}
+
+	// 创建爬虫实例
+	crawlerInstance, err := gamersky.NewArticleCrawler(crawlerConfig)
+	if err != nil {
+		return fmt.Errorf("创建文章爬虫失败: %v", err)
+	}
+	defer crawlerInstance.Close()
+
+	log.Printf("共 %d 条新闻待处理，请求延迟：%v", len(newsList), config.RequestDelay)
+
+	saved, skipped, failed := 0, 0, 0
+	for _, news := range newsList {
+		if !config.Refetch {
+			exists, err := crawlerInstance.HasArticle(news.SID)
+			if err != nil {
+				return fmt.Errorf("查询文章失败: %v", err)
+			}
+			if exists {
+				skipped++
+				continue
+			}
+		}
+
+		if saved+failed > 0 {
+			if err := httpclient.Sleep(ctx, config.RequestDelay); err != nil {
+				return err
+			}
+		}
+
+		log.Printf("正在获取文章 %s: %s", news.SID, news.URL)
+		article, err := crawlerInstance.CrawlArticle(ctx, news)
+		if ctx.Err() != nil {
+			log.Printf("已保存 %d 篇文章", saved)
+			return ctx.Err()
+		}
+		if err != nil {
+			config.Run.RecordError(err)
+			log.Printf("获取文章 %s 失败: %v", news.SID, err)
+			failed++
+			continue
+		}
+
+		saved++
+		log.Printf("文章 %s 保存完成：%s（%d 页，%d 段，%d 张图片）",
+			article.SID, article.Title, article.Pages, len(article.Paragraphs), len(article.Images))
+	}
+
+	log.Printf("文章正文爬取完成！保存 %d 篇，跳过已保存的 %d 篇，失败 %d 篇，已保存到 %s",
+		saved, skipped, failed, describeStore(config.StoreDSN, config.OutputPath))
+	if failed > 0 {
+		return fmt.Errorf("%d 篇文章获取失败", failed)
+	}
+	return nil
+}
+
+// articleNewsList 获取待处理的新闻：指定了文章ID时使用手机版文章链接，否则读取数据库中的新闻
+func articleNewsList(crawlerConfig *gamersky.Config, config *GamerskyArticlesConfig) ([]gamersky.NewsInfo, error) {
+	if len(config.ArticleIDs) > 0 {
+		newsList := make([]gamersky.NewsInfo, 0, len(config.ArticleIDs))
+		for _, id := range config.ArticleIDs {
+			newsList = append(newsList, gamersky.NewsInfo{SID: id, URL: gamersky.ArticleURL(id)})
+		}
+		return newsList, nil
+	}
+
+	newsCrawler, err := gamersky.NewNewsCrawler(crawlerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("创建新闻爬虫失败: %v", err)
+	}
+	defer newsCrawler.Close()
+
+	newsList, err := newsCrawler.QueryNews(config.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询新闻失败: %v", err)
+	}
+	return newsList, nil
+}
+
+func init() {
+	rootCmd.AddCommand(gamerskyArticlesCmd)
+
+	// 添加命令行参数
+	gamerskyArticlesCmd.Flags().Int("limit", 20, "最多处理的新闻数 (0=全部)")
+	gamerskyArticlesCmd.Flags().Bool("refetch", false, "重新获取已保存正文的文章")
+	gamerskyArticlesCmd.Flags().String("output", gamersky.DefaultOutputPath, "输出数据库文件路径")
+	gamerskyArticlesCmd.Flags().Duration("delay", 1*time.Second, "请求间隔时间")
+}
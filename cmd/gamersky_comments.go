@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
 
 	"bili-comment/gamersky"
+	"bili-comment/runlog"
 
 	"github.com/spf13/cobra"
 )
@@ -15,7 +17,14 @@ type GamerskyCommentsConfig struct {
 	ArticleID    string        // 文章ID
 	Pages        int           // 爬取页数
 	OutputPath   string        // 输出数据库路径
+	StoreDSN     string        // 存储DSN
 	RequestDelay time.Duration // 请求间隔
+	Since        time.Time     // 评论时间下限
+	Until        time.Time     // 评论时间上限
+	ImageDir     string        // 评论图片下载目录，为空时不下载
+	CommentOrder string        // 评论排序方式
+	MinPraises   int           // 只获取点赞数不少于该值的评论
+	Run          *runlog.Run   // 本次运行记录
 }
 
 // gamerskyCommentsCmd represents the gamersky-comments command
@@ -24,11 +33,24 @@ var gamerskyCommentsCmd = &cobra.Command{
 	Short: "爬取Gamersky游戏天空网站文章评论",
 	Long: `爬取Gamersky游戏天空网站的文章评论数据。
 
+总页数由接口返回的评论总数计算，失败的页面在其余页面完成后重试，
+结束时报告接口评论数与数据库中评论数的对比。
+评论中的图片信息保存到 gamersky_comment_images 表，--download-images 同时下载图片到按内容寻址的本地缓存。
+
+--order 选择评论排序方式：recommended（推荐，默认）、latest（最新）、hottest（最热），
+每条一级评论在该排序下的名次记录在 gamersky_comment_orders 表中，用于比较推荐排序和时间排序。
+最新排序配合 --since 使用时，翻到早于该时间的评论后停止翻页；--min-praises 只获取点赞数不少于该值的评论。
+
 示例：
   bili-comment gamersky-comments --article-id=2014209          # 爬取指定文章的评论
   bili-comment gamersky-comments --article-id=2014209 --pages=5  # 爬取前5页评论
+  bili-comment gamersky-comments --article-id=2014209 --pages=0  # 爬取全部评论
   bili-comment gamersky-comments --article-id=2014209 --delay=1s # 设置1秒请求延迟
-  bili-comment gamersky-comments --article-id=2014209 --output=/tmp/comments.db # 指定输出路径`,
+  bili-comment gamersky-comments --article-id=2014209 --output=/tmp/comments.db # 指定输出路径
+  bili-comment gamersky-comments --article-id=2014209 --since=2024-01-01 # 只保存该时间之后的评论
+  bili-comment gamersky-comments --article-id=2014209 --download-images # 下载评论中的图片
+  bili-comment gamersky-comments --article-id=2014209 --order=latest --since=2024-01-01 # 按最新排序爬取，早于该时间后停止
+  bili-comment gamersky-comments --article-id=2014209 --order=hottest --min-praises=10  # 只爬取点赞数不少于10的热门评论`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 从命令行参数获取配置
 		config := &GamerskyCommentsConfig{}
@@ -37,29 +59,55 @@ var gamerskyCommentsCmd = &cobra.Command{
 		config.ArticleID, _ = cmd.Flags().GetString("article-id")
 		config.Pages, _ = cmd.Flags().GetInt("pages")
 		config.OutputPath, _ = cmd.Flags().GetString("output")
+		config.StoreDSN, _ = cmd.Flags().GetString("store")
 		config.RequestDelay, _ = cmd.Flags().GetDuration("delay")
+		if downloadImages, _ := cmd.Flags().GetBool("download-images"); downloadImages {
+			config.ImageDir, _ = cmd.Flags().GetString("image-dir")
+		}
+		config.CommentOrder, _ = cmd.Flags().GetString("order")
+		config.MinPraises, _ = cmd.Flags().GetInt("min-praises")
+
+		var err error
+		config.Since, config.Until, err = getTimeRangeFlags(cmd)
+		if err != nil {
+			return err
+		}
 
 		// 验证必要参数
 		if config.ArticleID == "" {
 			return fmt.Errorf("必须指定 --article-id 参数")
 		}
+		if _, err := gamersky.LookupCommentOrder(config.CommentOrder); err != nil {
+			return err
+		}
 
 		// 确保延迟时间有默认值
 		if config.RequestDelay == 0 {
 			config.RequestDelay = 1 * time.Second
 		}
 
-		return runGamerskyCommentsCrawler(config)
+		ctx, stop := newSignalContext()
+		defer stop()
+
+		config.Run = startRun(cmd, config.OutputPath)
+		return finishRun(config.Run, runGamerskyCommentsCrawler(ctx, config))
 	},
 }
 
-func runGamerskyCommentsCrawler(config *GamerskyCommentsConfig) error {
+func runGamerskyCommentsCrawler(ctx context.Context, config *GamerskyCommentsConfig) error {
 	log.Println("Gamersky评论爬虫启动...")
 
 	// 转换配置格式
 	crawlerConfig := &gamersky.Config{
 		OutputPath:   config.OutputPath,
+		StoreDSN:     config.StoreDSN,
 		RequestDelay: config.RequestDelay,
+		Run:          config.Run,
+		Since:        config.Since,
+		Until:        config.Until,
+		ImageDir:     config.ImageDir,
+		CommentOrder: config.CommentOrder,
+		MinPraises:   config.MinPraises,
 	}
 
 	// 创建爬虫实例
@@ -69,27 +117,93 @@ func runGamerskyCommentsCrawler(config *GamerskyCommentsConfig) error {
 	}
 	defer crawlerInstance.Close()
 
-	log.Printf("开始爬取文章 %s 的评论，页数：%d", config.ArticleID, config.Pages)
+	if config.Pages > 0 {
+		log.Printf("开始爬取文章 %s 的评论，最多 %d 页", config.ArticleID, config.Pages)
+	} else {
+		log.Printf("开始爬取文章 %s 的全部评论", config.ArticleID)
+	}
 	log.Printf("请求延迟：%v", config.RequestDelay)
+	log.Printf("评论排序：%s", gamersky.CommentOrderLabel(config.CommentOrder))
+	if config.MinPraises > 0 {
+		log.Printf("只获取点赞数不少于 %d 的评论", config.MinPraises)
+	}
+	logTimeRange(config.Since, config.Until)
+	if config.ImageDir != "" {
+		log.Printf("评论图片下载到：%s", config.ImageDir)
+	}
 
 	// 开始爬取评论
-	totalCount, err := crawlerInstance.CrawlComments(config.ArticleID, config.Pages)
+	report, err := crawlerInstance.CrawlCommentsWithReport(ctx, config.ArticleID, config.Pages)
+	if isInterrupted(err) {
+		log.Printf("已爬取 %d 条评论", report.Saved)
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("爬取评论失败: %v", err)
 	}
 
-	log.Printf("评论爬取完成！总共爬取 %d 条评论，已保存到 SQLite 数据库：%s", totalCount, config.OutputPath)
+	if report.Stored, err = crawlerInstance.CountStored(config.ArticleID); err != nil {
+		log.Printf("统计已保存的评论失败: %v", err)
+		report.Stored = -1
+	}
+	logCommentsReport(report, config)
+
+	log.Printf("评论爬取完成！总共爬取 %d 条评论、%d 张图片，已保存到 %s", report.Saved, report.Images, describeStore(config.StoreDSN, config.OutputPath))
+	if len(report.FailedPages) > 0 {
+		return fmt.Errorf("%d 页评论重试后仍然失败: %v", len(report.FailedPages), report.FailedPages)
+	}
 	return nil
 }
 
+// logCommentsReport 输出评论完整性报告
+func logCommentsReport(report *gamersky.CrawlReport, config *GamerskyCommentsConfig) {
+	log.Printf("评论完整性：接口报告 %d 条一级评论（%d 页），成功爬取 %d 页，获取 %d 条",
+		report.Reported, report.TotalPages, report.Crawled, report.Fetched)
+
+	if report.Stored >= 0 {
+		ratio := 100.0
+		if report.Reported > 0 {
+			ratio = float64(report.Stored) * 100 / float64(report.Reported)
+		}
+		log.Printf("数据库中该文章共 %d 条一级评论，为接口报告数的 %.1f%%", report.Stored, ratio)
+	}
+
+	if len(report.IncompleteThreads) > 0 {
+		log.Printf("以下评论的回复未能全部获取: %v", report.IncompleteThreads)
+	}
+
+	switch {
+	case len(report.FailedPages) > 0:
+		log.Printf("以下页面重试后仍然失败，可稍后重新运行补全: %v", report.FailedPages)
+	case report.StoppedEarly:
+		log.Printf("最新排序下已翻到时间范围之前的评论，提前停止翻页")
+	case report.Complete() && report.MinPraises > 0:
+		log.Printf("已获取点赞数不少于 %d 的全部一级评论", report.MinPraises)
+	case report.Complete():
+		log.Printf("已获取全部一级评论")
+	case config.Pages > 0 && config.Pages < report.TotalPages:
+		log.Printf("只爬取了前 %d 页，使用 --pages=0 爬取全部评论", config.Pages)
+	default:
+		log.Printf("获取的评论少于接口报告数，可能有评论已被删除或隐藏")
+	}
+	if !config.Since.IsZero() || !config.Until.IsZero() {
+		log.Printf("设置了时间范围，只保存了范围内的评论")
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(gamerskyCommentsCmd)
 
 	// 添加命令行参数
 	gamerskyCommentsCmd.Flags().String("article-id", "", "文章ID（必需）")
-	gamerskyCommentsCmd.Flags().Int("pages", 10, "爬取页数")
+	gamerskyCommentsCmd.Flags().Int("pages", 10, "最多爬取的页数 (0=全部)")
 	gamerskyCommentsCmd.Flags().String("output", "./data/gamersky.db", "输出数据库文件路径")
 	gamerskyCommentsCmd.Flags().Duration("delay", 1*time.Second, "请求间隔时间")
+	gamerskyCommentsCmd.Flags().Bool("download-images", false, "下载评论中的图片")
+	gamerskyCommentsCmd.Flags().String("image-dir", gamersky.DefaultImageDir, "评论图片缓存目录，按内容SHA-256保存")
+	gamerskyCommentsCmd.Flags().String("order", gamersky.OrderRecommended, "评论排序方式 (可选 "+gamersky.CommentOrderNames()+")")
+	gamerskyCommentsCmd.Flags().Int("min-praises", 0, "只获取点赞数不少于该值的评论 (0=不限制)")
+	addTimeRangeFlags(gamerskyCommentsCmd)
 
 	// 标记必需的参数
 	gamerskyCommentsCmd.MarkFlagRequired("article-id")
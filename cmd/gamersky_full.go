@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
 
 	"bili-comment/gamersky"
+	"bili-comment/httpclient"
+	"bili-comment/runlog"
 
 	"github.com/spf13/cobra"
 )
@@ -15,7 +18,17 @@ type GamerskyFullConfig struct {
 	NewsPages    int           // 爬取新闻页数
 	CommentPages int           // 每条新闻爬取的评论页数
 	OutputPath   string        // 输出数据库路径
+	StoreDSN     string        // 存储DSN
+	Channel      string        // 新闻频道
 	RequestDelay time.Duration // 请求间隔
+	Resume       bool          // 是否从断点继续爬取评论
+	MaxAge       time.Duration // 新闻发布超过该时间后不再重爬评论
+	MinInterval  time.Duration // 最热文章的重爬间隔
+	MaxInterval  time.Duration // 最冷文章的重爬间隔
+	Budget       int           // 每次运行最多请求评论接口的次数
+	CommentOrder string        // 评论排序方式
+	MinPraises   int           // 只获取点赞数不少于该值的评论
+	Run          *runlog.Run   // 本次运行记录
 }
 
 // gamerskyFullCmd represents the gamersky-full command
@@ -24,14 +37,32 @@ var gamerskyFullCmd = &cobra.Command{
 	Short: "完整爬取Gamersky新闻和评论",
 	Long: `完整爬取Gamersky新闻和对应的评论数据，存储在同一个SQLite数据库中。
 
-此命令会先爬取指定页数的新闻，然后为每条新闻爬取指定页数的评论。
+此命令会先爬取指定页数的新闻，然后按调度计划为新闻爬取评论，每条新闻最多爬取指定页数的评论。
 适用于GitHub Actions等CI/CD环境的一次性完整数据爬取。
 
+评论重爬调度：
+  * 从未爬取过评论的新闻优先爬取
+  * 已爬取的新闻按年龄和上次爬取后的评论数增长计算重爬间隔：基础间隔为新闻年龄的1/4，
+    每新增10条评论间隔缩短一倍，限制在 --min-interval 和 --max-interval 之间，到期后才重爬
+  * 发布超过 --max-age 的新闻不再重爬评论（没有发布时间时按首次记录时间计算）
+  * 每次运行最多请求 --budget 次评论接口（包括失败重试和回复翻页），按评论数估算页数安排计划，
+    运行时按实际请求数扣除，预算用完后其余新闻推迟到下次运行
+  * 评论爬取状态保存在 gamersky_comment_crawls 表中
+
+评论排序：--order 选择 recommended（推荐，默认）、latest（最新）或 hottest（最热），
+--min-praises 只获取点赞数不少于该值的评论；每条一级评论在该排序下的名次记录在 gamersky_comment_orders 表中。
+
 示例：
   bili-comment gamersky-full                                    # 爬取3页新闻，每条新闻3页评论
   bili-comment gamersky-full --news-pages=5 --comment-pages=2  # 爬取5页新闻，每条新闻2页评论
   bili-comment gamersky-full --delay=2s                        # 设置2秒请求延迟
-  bili-comment gamersky-full --output=/tmp/full.db             # 指定输出路径`,
+  bili-comment gamersky-full --output=/tmp/full.db             # 指定输出路径
+  bili-comment gamersky-full --resume                          # 从上次中断的文章继续爬取评论
+  bili-comment gamersky-full --max-age=72h --budget=100        # 只跟踪3天内的新闻，每次最多请求100次评论接口
+  bili-comment gamersky-full --max-age=0 --budget=0 --max-interval=0 # 每次都爬取所有新闻的评论
+  bili-comment gamersky-full --channel=news                    # 爬取新闻频道，再按调度计划爬取评论
+  bili-comment gamersky-full --order=latest                    # 按最新排序爬取评论
+  bili-comment gamersky-full --order=hottest --min-praises=10  # 只爬取点赞数不少于10的热门评论`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 从命令行参数获取配置
 		config := &GamerskyFullConfig{}
@@ -40,52 +71,122 @@ var gamerskyFullCmd = &cobra.Command{
 		config.NewsPages, _ = cmd.Flags().GetInt("news-pages")
 		config.CommentPages, _ = cmd.Flags().GetInt("comment-pages")
 		config.OutputPath, _ = cmd.Flags().GetString("output")
+		config.StoreDSN, _ = cmd.Flags().GetString("store")
+		config.Channel, _ = cmd.Flags().GetString("channel")
 		config.RequestDelay, _ = cmd.Flags().GetDuration("delay")
+		config.Resume, _ = cmd.Flags().GetBool("resume")
+		config.MaxAge, _ = cmd.Flags().GetDuration("max-age")
+		config.MinInterval, _ = cmd.Flags().GetDuration("min-interval")
+		config.MaxInterval, _ = cmd.Flags().GetDuration("max-interval")
+		config.Budget, _ = cmd.Flags().GetInt("budget")
+		config.CommentOrder, _ = cmd.Flags().GetString("order")
+		config.MinPraises, _ = cmd.Flags().GetInt("min-praises")
 
 		// 确保延迟时间有默认值
 		if config.RequestDelay == 0 {
 			config.RequestDelay = 1 * time.Second
 		}
 
-		return runGamerskyFull(config)
+		if _, _, err := gamersky.LookupChannel(config.Channel); err != nil {
+			return err
+		}
+		if _, err := gamersky.LookupCommentOrder(config.CommentOrder); err != nil {
+			return err
+		}
+
+		ctx, stop := newSignalContext()
+		defer stop()
+
+		config.Run = startRun(cmd, config.OutputPath)
+		return finishRun(config.Run, runGamerskyFull(ctx, config))
 	},
 }
 
-func runGamerskyFull(config *GamerskyFullConfig) error {
+func runGamerskyFull(ctx context.Context, config *GamerskyFullConfig) error {
 	log.Println("开始执行Gamersky完整爬取任务（新闻+评论）...")
 	startTime := time.Now()
 
 	// 转换配置格式
 	crawlerConfig := &gamersky.Config{
 		OutputPath:   config.OutputPath,
+		StoreDSN:     config.StoreDSN,
+		Channel:      config.Channel,
 		RequestDelay: config.RequestDelay,
+		Run:          config.Run,
+		CommentOrder: config.CommentOrder,
+		MinPraises:   config.MinPraises,
 	}
 
 	log.Printf("配置信息：")
+	log.Printf("  新闻频道：%s", gamersky.ChannelLabel(config.Channel))
 	log.Printf("  新闻页数：%d", config.NewsPages)
 	log.Printf("  每条新闻评论页数：%d", config.CommentPages)
+	log.Printf("  评论排序：%s，最低点赞数：%d", gamersky.CommentOrderLabel(config.CommentOrder), config.MinPraises)
+	log.Printf("  评论重爬：跟踪 %v 内的新闻，间隔 %v ~ %v，预算 %d 次请求", config.MaxAge, config.MinInterval, config.MaxInterval, config.Budget)
 	log.Printf("  请求延迟：%v", config.RequestDelay)
-	log.Printf("  输出路径：%s", config.OutputPath)
+	log.Printf("  输出：%s", describeStore(config.StoreDSN, config.OutputPath))
 
 	// 第一步：爬取新闻
 	log.Println("\n=== 第一步：爬取新闻 ===")
-	newsCount, newsSids, err := crawlNews(crawlerConfig, config.NewsPages)
+	newsCount, err := crawlNews(ctx, crawlerConfig, config.NewsPages)
+	if isInterrupted(err) {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("爬取新闻失败: %v", err)
 	}
 
 	log.Printf("新闻爬取完成！总共爬取 %d 条新闻", newsCount)
 
-	// 如果没有新闻或不需要爬取评论，直接返回
-	if len(newsSids) == 0 || config.CommentPages <= 0 {
+	// 如果不需要爬取评论，直接返回
+	if config.CommentPages <= 0 {
 		duration := time.Since(startTime)
 		log.Printf("任务完成！总耗时：%v", duration)
 		return nil
 	}
 
-	// 第二步：为每条新闻爬取评论
+	// 第二步：按调度计划为新闻爬取评论
 	log.Println("\n=== 第二步：爬取评论 ===")
-	totalComments, err := crawlCommentsForNews(crawlerConfig, newsSids, config.CommentPages)
+	scheduler, err := gamersky.NewRecrawlScheduler(crawlerConfig, gamersky.RecrawlPolicy{
+		MaxAge:       config.MaxAge,
+		MinInterval:  config.MinInterval,
+		MaxInterval:  config.MaxInterval,
+		Budget:       config.Budget,
+		CommentPages: config.CommentPages,
+	})
+	if err != nil {
+		return fmt.Errorf("创建评论调度器失败: %v", err)
+	}
+	defer scheduler.Close()
+
+	plan, err := scheduler.Plan(time.Now())
+	if err != nil {
+		return err
+	}
+	log.Printf("跟踪 %d 条新闻，%d 条到期，本次爬取 %d 条（约 %d 页评论），%d 条超出预算推迟到下次",
+		plan.Tracked, plan.Due, len(plan.Tasks), plan.Pages, plan.Deferred)
+	if len(plan.Tasks) == 0 {
+		log.Printf("任务完成！总耗时：%v", time.Since(startTime))
+		return nil
+	}
+
+	startSid := ""
+	if config.Resume {
+		cursor, _, ok, err := config.Run.LoadCheckpoint(fullCheckpointKey)
+		if err != nil {
+			return fmt.Errorf("读取断点失败: %v", err)
+		}
+		if ok {
+			startSid = cursor
+			log.Printf("从断点继续：从新闻 %s 开始爬取评论", startSid)
+		}
+	}
+
+	totalComments, err := crawlCommentsForNews(ctx, crawlerConfig, scheduler, plan.Tasks, startSid)
+	if isInterrupted(err) {
+		log.Printf("已爬取 %d 条新闻，%d 条评论", newsCount, totalComments)
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("爬取评论失败: %v", err)
 	}
@@ -94,28 +195,34 @@ func runGamerskyFull(config *GamerskyFullConfig) error {
 	log.Printf("\n=== 任务完成！===")
 	log.Printf("总共爬取 %d 条新闻，%d 条评论", newsCount, totalComments)
 	log.Printf("总耗时：%v", duration)
-	log.Printf("数据已保存到：%s", config.OutputPath)
+	log.Printf("数据已保存到 %s", describeStore(config.StoreDSN, config.OutputPath))
 
 	return nil
 }
 
-// crawlNews 爬取新闻并返回新闻ID列表
-func crawlNews(config *gamersky.Config, pages int) (int, []string, error) {
+// fullCheckpointKey gamersky-full 评论阶段的断点key
+const fullCheckpointKey = "comments"
+
+// crawlNews 爬取新闻，已有新闻的评论数随之更新，返回新增的新闻数
+func crawlNews(ctx context.Context, config *gamersky.Config, pages int) (int, error) {
 	// 创建新闻爬虫实例
 	newsCrawler, err := gamersky.NewNewsCrawler(config)
 	if err != nil {
-		return 0, nil, fmt.Errorf("创建新闻爬虫失败: %v", err)
+		return 0, fmt.Errorf("创建新闻爬虫失败: %v", err)
 	}
 	defer newsCrawler.Close()
 
 	totalCount := 0
-	var allSids []string
 
 	for page := 1; page <= pages; page++ {
 		log.Printf("正在爬取新闻第 %d 页...", page)
 
-		count, err := newsCrawler.CrawlNews(page)
+		count, err := newsCrawler.CrawlNews(ctx, page)
+		if ctx.Err() != nil {
+			return totalCount, ctx.Err()
+		}
 		if err != nil {
+			config.Run.RecordError(err)
 			log.Printf("爬取新闻第 %d 页失败: %v", page, err)
 			continue
 		}
@@ -131,26 +238,17 @@ func crawlNews(config *gamersky.Config, pages int) (int, []string, error) {
 
 		// 延迟
 		if page < pages {
-			time.Sleep(config.RequestDelay)
+			if err := httpclient.Sleep(ctx, config.RequestDelay); err != nil {
+				return totalCount, err
+			}
 		}
 	}
 
-	// 查询所有新闻的SID
-	news, err := newsCrawler.QueryNews(0) // 0表示查询所有
-	if err != nil {
-		return totalCount, nil, fmt.Errorf("查询新闻失败: %v", err)
-	}
-
-	for _, item := range news {
-		allSids = append(allSids, item.SID)
-	}
-
-	log.Printf("获取到 %d 条新闻的SID", len(allSids))
-	return totalCount, allSids, nil
+	return totalCount, nil
 }
 
-// crawlCommentsForNews 为新闻列表爬取评论
-func crawlCommentsForNews(config *gamersky.Config, newsSids []string, commentPages int) (int, error) {
+// crawlCommentsForNews 按重爬计划爬取评论并记录爬取状态，startSid 非空时从该新闻开始
+func crawlCommentsForNews(ctx context.Context, config *gamersky.Config, scheduler *gamersky.RecrawlScheduler, tasks []gamersky.RecrawlTask, startSid string) (int, error) {
 	// 创建评论爬虫实例
 	commentCrawler, err := gamersky.NewCommentCrawler(config)
 	if err != nil {
@@ -160,24 +258,64 @@ func crawlCommentsForNews(config *gamersky.Config, newsSids []string, commentPag
 
 	totalComments := 0
 
-	for i, sid := range newsSids {
-		log.Printf("正在爬取新闻 %s 的评论 (%d/%d)...", sid, i+1, len(newsSids))
+	// 跳过断点之前已完成的新闻
+	start := 0
+	for i, task := range tasks {
+		if task.News.SID == startSid {
+			start = i
+			break
+		}
+	}
 
-		count, err := commentCrawler.CrawlComments(sid, commentPages)
+	for i := start; i < len(tasks); i++ {
+		task := tasks[i]
+		sid := task.News.SID
+		pages, ok := scheduler.Allow(task)
+		if !ok {
+			log.Printf("已请求 %d 次评论接口，预算用完，其余 %d 条新闻推迟到下次运行", scheduler.Spent(), len(tasks)-i)
+			break
+		}
+		log.Printf("正在爬取新闻 %s 的评论 (%d/%d，评论增长 %d 条，最多 %d 页)...", sid, i+1, len(tasks), task.Growth, pages)
+
+		report, err := commentCrawler.CrawlCommentsWithReport(ctx, sid, pages)
+		scheduler.Charge(report.Requests)
+		count := report.Saved
+		totalComments += count
+		if isInterrupted(err) {
+			// 当前新闻未爬完，恢复时从该新闻重新开始
+			config.Run.TrySaveCheckpoint(fullCheckpointKey, sid, totalComments)
+			return totalComments, err
+		}
 		if err != nil {
+			config.Run.RecordError(err)
 			log.Printf("爬取新闻 %s 评论失败: %v", sid, err)
 			continue
 		}
 
-		totalComments += count
-		log.Printf("新闻 %s 评论爬取完成，新增 %d 条评论", sid, count)
+		log.Printf("新闻 %s 评论爬取完成，新增 %d 条评论，请求 %d 次", sid, count, report.Requests)
+		if len(report.FailedPages) > 0 {
+			log.Printf("新闻 %s 第 %v 页评论重试后仍然失败", sid, report.FailedPages)
+		}
+		if len(report.IncompleteThreads) > 0 {
+			log.Printf("新闻 %s 有 %d 条评论未能获取全部回复: %v", sid, len(report.IncompleteThreads), report.IncompleteThreads)
+		}
+		if err := scheduler.MarkCrawled(task.News, time.Now()); err != nil {
+			log.Printf("记录新闻 %s 的评论爬取状态失败: %v", sid, err)
+		}
 
 		// 在每条新闻之间延迟
-		if i < len(newsSids)-1 {
-			time.Sleep(config.RequestDelay)
+		if i < len(tasks)-1 {
+			if err := httpclient.Sleep(ctx, config.RequestDelay); err != nil {
+				config.Run.TrySaveCheckpoint(fullCheckpointKey, tasks[i+1].News.SID, totalComments)
+				return totalComments, err
+			}
 		}
 	}
 
+	if err := config.Run.ClearCheckpoint(fullCheckpointKey); err != nil {
+		log.Printf("清除断点失败: %v", err)
+	}
+
 	return totalComments, nil
 }
 
@@ -188,5 +326,13 @@ func init() {
 	gamerskyFullCmd.Flags().Int("news-pages", 3, "爬取新闻页数")
 	gamerskyFullCmd.Flags().Int("comment-pages", 3, "每条新闻爬取的评论页数")
 	gamerskyFullCmd.Flags().String("output", "./data/gamersky.db", "输出数据库文件路径")
+	gamerskyFullCmd.Flags().String("channel", "", "新闻频道，为空时爬取手机版首页信息流 (可选 "+gamersky.ChannelNames()+")")
 	gamerskyFullCmd.Flags().Duration("delay", 1*time.Second, "请求间隔时间")
+	gamerskyFullCmd.Flags().Bool("resume", false, "从上次中断保存的断点继续爬取评论")
+	gamerskyFullCmd.Flags().Duration("max-age", gamersky.DefaultRecrawlMaxAge, "新闻发布超过该时间后不再重爬评论 (0=不限制)")
+	gamerskyFullCmd.Flags().Duration("min-interval", gamersky.DefaultRecrawlMinInterval, "最热文章的评论重爬间隔")
+	gamerskyFullCmd.Flags().Duration("max-interval", gamersky.DefaultRecrawlMaxInterval, "最冷文章的评论重爬间隔 (0=每次都重爬)")
+	gamerskyFullCmd.Flags().Int("budget", gamersky.DefaultRecrawlBudget, "每次运行最多请求评论接口的次数，包括重试和回复翻页 (0=不限制)")
+	gamerskyFullCmd.Flags().String("order", gamersky.OrderRecommended, "评论排序方式 (可选 "+gamersky.CommentOrderNames()+")")
+	gamerskyFullCmd.Flags().Int("min-praises", 0, "只获取点赞数不少于该值的评论 (0=不限制)")
 }
@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
 
 	"bili-comment/gamersky"
+	"bili-comment/httpclient"
+	"bili-comment/runlog"
 
 	"github.com/spf13/cobra"
 )
@@ -14,7 +17,10 @@ import (
 type GamerskyOnceConfig struct {
 	Pages        int           // 爬取页数
 	OutputPath   string        // 输出数据库路径
+	StoreDSN     string        // 存储DSN
+	Channel      string        // 新闻频道
 	RequestDelay time.Duration // 请求间隔
+	Run          *runlog.Run   // 本次运行记录
 }
 
 // gamerskyOnceCmd represents the gamersky-once command
@@ -29,7 +35,8 @@ var gamerskyOnceCmd = &cobra.Command{
   bili-comment gamersky-once                           # 爬取1页新闻
   bili-comment gamersky-once --pages=5                # 爬取5页新闻
   bili-comment gamersky-once --delay=2s               # 设置2秒请求延迟
-  bili-comment gamersky-once --output=/tmp/news.db    # 指定输出路径`,
+  bili-comment gamersky-once --output=/tmp/news.db    # 指定输出路径
+  bili-comment gamersky-once --channel=hardware  # 爬取硬件频道3页新闻`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 从命令行参数获取配置
 		config := &GamerskyOnceConfig{}
@@ -37,6 +44,8 @@ var gamerskyOnceCmd = &cobra.Command{
 		// 获取标志值
 		config.Pages, _ = cmd.Flags().GetInt("pages")
 		config.OutputPath, _ = cmd.Flags().GetString("output")
+		config.StoreDSN, _ = cmd.Flags().GetString("store")
+		config.Channel, _ = cmd.Flags().GetString("channel")
 		config.RequestDelay, _ = cmd.Flags().GetDuration("delay")
 
 		// 确保延迟时间有默认值
@@ -44,18 +53,29 @@ var gamerskyOnceCmd = &cobra.Command{
 			config.RequestDelay = 1 * time.Second
 		}
 
-		return runGamerskyOnce(config)
+		if _, _, err := gamersky.LookupChannel(config.Channel); err != nil {
+			return err
+		}
+
+		ctx, stop := newSignalContext()
+		defer stop()
+
+		config.Run = startRun(cmd, config.OutputPath)
+		return finishRun(config.Run, runGamerskyOnce(ctx, config))
 	},
 }
 
-func runGamerskyOnce(config *GamerskyOnceConfig) error {
+func runGamerskyOnce(ctx context.Context, config *GamerskyOnceConfig) error {
 	log.Println("开始执行Gamersky新闻爬取任务...")
 	startTime := time.Now()
 
 	// 转换配置格式
 	crawlerConfig := &gamersky.Config{
 		OutputPath:   config.OutputPath,
+		StoreDSN:     config.StoreDSN,
+		Channel:      config.Channel,
 		RequestDelay: config.RequestDelay,
+		Run:          config.Run,
 	}
 
 	// 创建爬虫实例
@@ -65,17 +85,21 @@ func runGamerskyOnce(config *GamerskyOnceConfig) error {
 	}
 	defer crawlerInstance.Close()
 
-	log.Printf("开始爬取Gamersky新闻，页数：%d", config.Pages)
+	log.Printf("开始爬取Gamersky新闻，频道：%s，页数：%d", gamersky.ChannelLabel(config.Channel), config.Pages)
 	log.Printf("请求延迟：%v", config.RequestDelay)
-	log.Printf("输出路径：%s", config.OutputPath)
+	log.Printf("输出：%s", describeStore(config.StoreDSN, config.OutputPath))
 
 	// 开始爬取
 	totalCount := 0
 	for page := 1; page <= config.Pages; page++ {
 		log.Printf("正在爬取第 %d 页...", page)
 
-		count, err := crawlerInstance.CrawlNews(page)
+		count, err := crawlerInstance.CrawlNews(ctx, page)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
+			config.Run.RecordError(err)
 			log.Printf("爬取第 %d 页失败: %v", page, err)
 			continue
 		}
@@ -91,13 +115,15 @@ func runGamerskyOnce(config *GamerskyOnceConfig) error {
 
 		// 延迟
 		if page < config.Pages {
-			time.Sleep(config.RequestDelay)
+			if err := httpclient.Sleep(ctx, config.RequestDelay); err != nil {
+				return err
+			}
 		}
 	}
 
 	duration := time.Since(startTime)
 	log.Printf("爬取完成！总共爬取 %d 条新闻，耗时：%v", totalCount, duration)
-	log.Printf("数据已保存到：%s", config.OutputPath)
+	log.Printf("数据已保存到 %s", describeStore(config.StoreDSN, config.OutputPath))
 
 	return nil
 }
@@ -108,5 +134,6 @@ func init() {
 	// 添加命令行参数
 	gamerskyOnceCmd.Flags().Int("pages", 3, "爬取页数")
 	gamerskyOnceCmd.Flags().String("output", "./data/gamersky.db", "输出数据库文件路径")
+	gamerskyOnceCmd.Flags().String("channel", "", "新闻频道，为空时爬取手机版首页信息流 (可选 "+gamersky.ChannelNames()+")")
 	gamerskyOnceCmd.Flags().Duration("delay", 1*time.Second, "请求间隔时间")
 }
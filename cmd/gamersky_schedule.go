@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"bili-comment/gamersky"
+	"bili-comment/httpclient"
+	"bili-comment/runlog"
+	"bili-comment/source"
+	"bili-comment/store"
 
 	"github.com/robfig/cron/v3"
 	"github.com/spf13/cobra"
@@ -17,10 +19,14 @@ import (
 
 // GamerskyScheduleConfig 定时任务配置
 type GamerskyScheduleConfig struct {
+	Source       string        // 评论来源插件名称
 	Pages        int           // 每次爬取页数
 	OutputPath   string        // 输出数据库路径
+	StoreDSN     string        // 存储DSN
+	Channel      string        // 条目列表的频道（Gamersky新闻频道）
 	RequestDelay time.Duration // 请求间隔
 	CronSpec     string        // Cron表达式
+	CommandName  string        // 命令名称（用于运行记录）
 }
 
 // gamerskyScheduleCmd represents the gamersky schedule command
@@ -29,13 +35,15 @@ var gamerskyScheduleCmd = &cobra.Command{
 	Short: "启动Gamersky新闻定时爬取任务",
 	Long: `启动Gamersky新闻定时爬取任务，支持自定义Cron表达式。
 
-默认每5分钟执行一次爬取任务。
+默认每5分钟执行一次爬取任务。--source 可指定其他评论来源（如YAML站点定义），定时爬取其条目列表。
 
 示例：
   bili-comment gamersky-schedule                          # 每5分钟爬取1页新闻
   bili-comment gamersky-schedule --pages=3               # 每5分钟爬取3页新闻
   bili-comment gamersky-schedule --cron="0 */10 * * * *" # 每10分钟执行一次
   bili-comment gamersky-schedule --cron="0 0 */2 * * *"  # 每2小时执行一次
+  bili-comment gamersky-schedule --source=example-news   # 定时爬取YAML站点的条目列表
+  bili-comment gamersky-schedule --channel=esports       # 定时爬取电竞频道
 
 Cron表达式格式：秒 分 时 日 月 周
   * 每5分钟: "0 */5 * * * *"
@@ -46,25 +54,48 @@ Cron表达式格式：秒 分 时 日 月 周
 		config := &GamerskyScheduleConfig{}
 
 		// 获取标志值
+		config.Source, _ = cmd.Flags().GetString("source")
 		config.Pages, _ = cmd.Flags().GetInt("pages")
 		config.OutputPath, _ = cmd.Flags().GetString("output")
+		config.StoreDSN, _ = cmd.Flags().GetString("store")
+		config.Channel, _ = cmd.Flags().GetString("channel")
 		config.RequestDelay, _ = cmd.Flags().GetDuration("delay")
 		config.CronSpec, _ = cmd.Flags().GetString("cron")
+		config.CommandName = cmd.Name()
 
 		// 确保延迟时间有默认值
 		if config.RequestDelay == 0 {
 			config.RequestDelay = 1 * time.Second
 		}
 
-		return runGamerskyScheduler(config)
+		src, err := source.Get(config.Source)
+		if err != nil {
+			return err
+		}
+		if config.OutputPath == "" {
+			config.OutputPath = src.DefaultOutputPath()
+		}
+		if src.Name() == source.Gamersky {
+			if _, _, err := gamersky.LookupChannel(config.Channel); err != nil {
+				return err
+			}
+		}
+
+		ctx, stop := newSignalContext()
+		defer stop()
+
+		return runGamerskyScheduler(ctx, src, config)
 	},
 }
 
-func runGamerskyScheduler(config *GamerskyScheduleConfig) error {
-	log.Println("启动Gamersky新闻定时爬取服务...")
+func runGamerskyScheduler(ctx context.Context, src source.Source, config *GamerskyScheduleConfig) error {
+	log.Printf("启动定时爬取服务，来源：%s", src.Name())
 	log.Printf("定时规则: %s", config.CronSpec)
+	if config.Channel != "" {
+		log.Printf("频道: %s", config.Channel)
+	}
 	log.Printf("每次爬取页数: %d", config.Pages)
-	log.Printf("输出路径: %s", config.OutputPath)
+	log.Printf("输出: %s", describeStore(config.StoreDSN, config.OutputPath))
 	log.Printf("请求延迟: %v", config.RequestDelay)
 
 	// 创建cron调度器
@@ -75,7 +106,7 @@ func runGamerskyScheduler(config *GamerskyScheduleConfig) error {
 		log.Println("开始执行定时爬取任务...")
 		startTime := time.Now()
 
-		err := executeCrawlTask(config)
+		err := executeCrawlTask(ctx, src, config)
 		if err != nil {
 			log.Printf("定时爬取任务执行失败: %v", err)
 		} else {
@@ -94,39 +125,74 @@ func runGamerskyScheduler(config *GamerskyScheduleConfig) error {
 
 	// 立即执行一次
 	log.Println("立即执行一次爬取任务...")
-	if err := executeCrawlTask(config); err != nil {
+	if err := executeCrawlTask(ctx, src, config); err != nil {
 		log.Printf("初始爬取任务执行失败: %v", err)
 	}
 
 	// 等待信号来优雅关闭
-	return waitForShutdown(c)
+	return waitForShutdown(ctx, c)
 }
 
-func executeCrawlTask(config *GamerskyScheduleConfig) error {
-	// 转换配置格式
-	crawlerConfig := &gamersky.Config{
-		OutputPath:   config.OutputPath,
-		RequestDelay: config.RequestDelay,
+func executeCrawlTask(ctx context.Context, src source.Source, config *GamerskyScheduleConfig) error {
+	// 已收到退出信号时不再启动新任务
+	if ctx.Err() != nil {
+		return nil
 	}
 
-	// 创建爬虫实例
-	crawlerInstance, err := gamersky.NewNewsCrawler(crawlerConfig)
+	// 每次定时任务单独登记一条运行记录，非SQLite存储不登记
+	var run *runlog.Run
+	if dbPath, ok := store.SQLitePath(config.StoreDSN, config.OutputPath); ok {
+		var err error
+		run, err = runlog.Start(dbPath, config.CommandName, os.Args[1:])
+		if err != nil {
+			log.Printf("登记运行记录失败: %v", err)
+		}
+	}
+
+	return finishRun(run, crawlNewsTask(ctx, src, config, run))
+}
+
+// crawlNewsTask 执行一次条目列表爬取
+func crawlNewsTask(ctx context.Context, src source.Source, config *GamerskyScheduleConfig, run *runlog.Run) error {
+	// 初始化存储
+	st, err := store.Open(config.StoreDSN, config.OutputPath)
 	if err != nil {
-		return fmt.Errorf("创建爬虫失败: %v", err)
+		return fmt.Errorf("初始化数据库失败: %v", err)
+	}
+	defer st.Close()
+
+	opts := source.Options{
+		Channel:      config.Channel,
+		RequestDelay: config.RequestDelay,
+		Run:          run,
 	}
-	defer crawlerInstance.Close()
 
 	// 开始爬取
 	totalCount := 0
 	for page := 1; page <= config.Pages; page++ {
 		log.Printf("正在爬取第 %d 页...", page)
 
-		count, err := crawlerInstance.CrawlNews(page)
+		items, err := src.ListItems(ctx, "", page, opts)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
+			run.RecordError(err)
 			log.Printf("爬取第 %d 页失败: %v", page, err)
 			continue
 		}
 
+		count := 0
+		for _, item := range items {
+			inserted, err := store.SaveRecord(st, item, run.ID())
+			run.RecordSave(inserted, err)
+			if err != nil {
+				log.Printf("保存条目失败: %v", err)
+				continue
+			}
+			count++
+		}
+
 		totalCount += count
 		log.Printf("第 %d 页爬取完成，新增 %d 条新闻", page, count)
 
@@ -138,7 +204,9 @@ func executeCrawlTask(config *GamerskyScheduleConfig) error {
 
 		// 延迟
 		if page < config.Pages {
-			time.Sleep(config.RequestDelay)
+			if err := httpclient.Sleep(ctx, config.RequestDelay); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -146,19 +214,15 @@ func executeCrawlTask(config *GamerskyScheduleConfig) error {
 	return nil
 }
 
-func waitForShutdown(c *cron.Cron) error {
-	// 创建一个接收系统信号的通道
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
+func waitForShutdown(ctx context.Context, c *cron.Cron) error {
 	log.Println("定时任务正在运行中... (按 Ctrl+C 退出)")
 
-	// 等待信号
-	sig := <-sigChan
-	log.Printf("收到信号 %v，开始优雅关闭...", sig)
+	// 等待信号，正在执行的任务会在当前页完成后停止
+	<-ctx.Done()
+	log.Println("开始优雅关闭...")
 
 	// 创建一个带超时的context
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	// 停止调度器
@@ -167,7 +231,7 @@ func waitForShutdown(c *cron.Cron) error {
 	select {
 	case <-stopCtx.Done():
 		log.Println("调度器已优雅关闭")
-	case <-ctx.Done():
+	case <-timeoutCtx.Done():
 		log.Println("关闭超时，强制退出")
 	}
 
@@ -179,8 +243,10 @@ func init() {
 	rootCmd.AddCommand(gamerskyScheduleCmd)
 
 	// 添加命令行参数
+	gamerskyScheduleCmd.Flags().String("source", source.Gamersky, "评论来源，定时爬取其条目列表")
 	gamerskyScheduleCmd.Flags().Int("pages", 1, "每次爬取的页数")
-	gamerskyScheduleCmd.Flags().String("output", "./data/gamersky.db", "输出数据库文件路径")
+	gamerskyScheduleCmd.Flags().String("channel", "", "Gamersky新闻频道，为空时爬取手机版首页信息流 (可选 "+gamersky.ChannelNames()+")")
+	gamerskyScheduleCmd.Flags().String("output", "", "输出数据库文件路径 (默认为来源的数据库，Gamersky为 ./data/gamersky.db)")
 	gamerskyScheduleCmd.Flags().Duration("delay", 1*time.Second, "请求间隔时间")
 	gamerskyScheduleCmd.Flags().String("cron", "0 */5 * * * *", "Cron表达式 (秒 分 时 日 月 周)，默认每5分钟")
 }
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"bili-comment/runlog"
+	"bili-comment/source"
+	"bili-comment/store"
+
+	// 注册内置的评论来源插件
+	_ "bili-comment/crawler"
+	_ "bili-comment/gamersky"
+
+	"github.com/spf13/cobra"
+)
+
+// ListConfig 条目列表配置
+type ListConfig struct {
+	Source       string        // 评论来源插件名称
+	Query        string        // 搜索关键词
+	Page         int           // 页码
+	PageSize     int           // 每页数量
+	OutputPath   string        // 输出数据库路径
+	StoreDSN     string        // 存储DSN
+	CookiePath   string        // Cookie文件路径
+	RequestDelay time.Duration // 请求间隔
+	Run          *runlog.Run   // 本次运行记录
+}
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list [关键词]",
+	Short: "列出可爬取评论的视频或文章",
+	Long: `通过评论来源插件获取可爬取评论的条目并保存到数据库，输出的ID可直接用于 crawl 命令。
+
+B站条目为关键词搜索到的视频（需要关键词和Cookie）；Gamersky条目为首页新闻列表。
+
+示例：
+  bili-comment list --source=bilibili 极氪001             # 搜索B站视频
+  bili-comment list --source=bilibili 极氪001 --page=2    # 搜索结果第2页
+  bili-comment list --source=gamersky                     # Gamersky首页新闻
+  bili-comment list --source=gamersky --page=3            # Gamersky新闻第3页
+  bili-comment list --sources                             # 列出所有评论来源`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if showSources, _ := cmd.Flags().GetBool("sources"); showSources {
+			printSources()
+			return nil
+		}
+
+		// 从命令行参数获取配置
+		config := &ListConfig{}
+		if len(args) > 0 {
+			config.Query = args[0]
+		}
+
+		// 获取标志值
+		config.Source, _ = cmd.Flags().GetString("source")
+		config.Page, _ = cmd.Flags().GetInt("page")
+		config.PageSize, _ = cmd.Flags().GetInt("page-size")
+		config.OutputPath, _ = cmd.Flags().GetString("output")
+		config.StoreDSN, _ = cmd.Flags().GetString("store")
+		config.CookiePath, _ = cmd.Flags().GetString("cookie")
+		config.RequestDelay, _ = cmd.Flags().GetDuration("delay")
+
+		src, err := source.Get(config.Source)
+		if err != nil {
+			return err
+		}
+		if config.OutputPath == "" {
+			config.OutputPath = src.DefaultOutputPath()
+		}
+
+		ctx, stop := newSignalContext()
+		defer stop()
+
+		config.Run = startRun(cmd, config.OutputPath)
+		return finishRun(config.Run, runList(ctx, src, config))
+	},
+}
+
+func runList(ctx context.Context, src source.Source, config *ListConfig) error {
+	// 初始化存储
+	st, err := store.Open(config.StoreDSN, config.OutputPath)
+	if err != nil {
+		return fmt.Errorf("初始化数据库失败: %v", err)
+	}
+	defer st.Close()
+
+	opts := source.Options{
+		PageSize:     config.PageSize,
+		CookiePath:   config.CookiePath,
+		RequestDelay: config.RequestDelay,
+		Run:          config.Run,
+	}
+
+	items, err := src.ListItems(ctx, config.Query, config.Page, opts)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("获取列表失败: %v", err)
+	}
+
+	if len(items) == 0 {
+		fmt.Println("没有找到条目")
+		return nil
+	}
+
+	fmt.Printf("%s 第 %d 页，共 %d 个条目：\n", src.Name(), config.Page, len(items))
+	fmt.Printf("%-14s %-8s %-20s %s\n", "ID", "评论数", "时间", "标题")
+	fmt.Println(strings.Repeat("-", 100))
+
+	savedCount := 0
+	for _, item := range items {
+		inserted, err := store.SaveRecord(st, item, config.Run.ID())
+		config.Run.RecordSave(inserted, err)
+		if err != nil {
+			log.Printf("保存条目失败: %v", err)
+		} else {
+			savedCount++
+		}
+
+		entry, err := src.Normalize(item)
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+		fmt.Printf("%-14s %-8d %-20s %s\n", entry.ID, entry.Replies, entry.Time, entry.Title)
+	}
+
+	log.Printf("已保存 %d 个条目到 %s", savedCount, describeStore(config.StoreDSN, config.OutputPath))
+	return nil
+}
+
+// printSources 列出所有已注册的评论来源
+func printSources() {
+	fmt.Printf("%-12s %-22s %s\n", "来源", "默认数据库", "说明")
+	fmt.Println(strings.Repeat("-", 80))
+	for _, name := range source.Names() {
+		src, _ := source.Get(name)
+		fmt.Printf("%-12s %-22s %s\n", name, src.DefaultOutputPath(), src.Description())
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	// 添加命令行参数
+	listCmd.Flags().String("source", source.Bilibili, "评论来源 ("+strings.Join(source.Names(), "、")+")")
+	listCmd.Flags().Bool("sources", false, "列出所有评论来源")
+	listCmd.Flags().Int("page", 1, "页码")
+	listCmd.Flags().Int("page-size", 20, "每页数量 (B站)")
+	listCmd.Flags().String("output", "", "输出数据库文件路径 (默认 B站为 ./data/crawler.db，Gamersky为 ./data/gamersky.db)")
+	listCmd.Flags().String("cookie", "", "Cookie文件路径 (为空时自动查找)")
+	listCmd.Flags().Duration("delay", 500*time.Millisecond, "请求间隔时间")
+}
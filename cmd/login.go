@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"bili-comment/crawler"
+	"bili-comment/httpclient"
+
+	"github.com/spf13/cobra"
+)
+
+// LoginConfig 登录配置
+type LoginConfig struct {
+	CookiePath   string        // Cookie保存路径
+	PollInterval time.Duration // 轮询间隔
+	Timeout      time.Duration // 等待扫码超时时间
+}
+
+// loginCmd represents the login command
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "扫码登录B站并保存Cookie",
+	Long: `在终端中显示B站登录二维码，使用B站App扫码确认后自动保存Cookie。
+
+保存的Cookie文件可直接被 crawl、search 等命令读取，无需手动从浏览器复制。
+
+示例：
+  bili-comment login                              # 保存到 ./bili_cookie.txt
+  bili-comment login --cookie=/tmp/bili_cookie.txt # 指定Cookie保存路径
+  bili-comment login --timeout=5m                 # 设置扫码等待时间`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 从命令行参数获取配置
+		config := &LoginConfig{}
+
+		// 获取标志值
+		config.CookiePath, _ = cmd.Flags().GetString("cookie")
+		config.PollInterval, _ = cmd.Flags().GetDuration("interval")
+		config.Timeout, _ = cmd.Flags().GetDuration("timeout")
+
+		ctx, stop := newSignalContext()
+		defer stop()
+
+		return runLogin(ctx, config)
+	},
+}
+
+func runLogin(ctx context.Context, config *LoginConfig) error {
+	login := crawler.NewQRLogin("")
+
+	// 申请二维码
+	info, err := login.GenerateQRCode()
+	if err != nil {
+		return err
+	}
+
+	qr, err := crawler.RenderQRCode(info.URL)
+	if err != nil {
+		return fmt.Errorf("生成二维码失败: %v", err)
+	}
+
+	fmt.Println(qr)
+	fmt.Println("请使用B站App扫描上方二维码登录")
+
+	// 轮询扫码状态
+	deadline := time.Now().Add(config.Timeout)
+	lastCode := -1
+	for time.Now().Before(deadline) {
+		result, err := login.PollQRCode(info.Key)
+		if err != nil {
+			return err
+		}
+
+		switch result.Code {
+		case crawler.QRStatusSuccess:
+			if err := crawler.SaveCookie(config.CookiePath, result.Cookie); err != nil {
+				return fmt.Errorf("保存Cookie失败: %v", err)
+			}
+			log.Printf("登录成功！Cookie已保存到：%s", config.CookiePath)
+			return nil
+		case crawler.QRStatusExpired:
+			return fmt.Errorf("二维码已失效，请重新执行 login")
+		case crawler.QRStatusConfirming:
+			if lastCode != result.Code {
+				log.Println("已扫码，请在手机上确认登录")
+			}
+		case crawler.QRStatusWaiting:
+			// 等待扫码
+		default:
+			log.Printf("未知扫码状态: %s (代码: %d)", result.Message, result.Code)
+		}
+
+		lastCode = result.Code
+		if err := httpclient.Sleep(ctx, config.PollInterval); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("等待扫码超时")
+}
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+
+	// 添加命令行参数
+	loginCmd.Flags().String("cookie", "bili_cookie.txt", "Cookie保存路径")
+	loginCmd.Flags().Duration("interval", 2*time.Second, "扫码状态轮询间隔")
+	loginCmd.Flags().Duration("timeout", 3*time.Minute, "等待扫码超时时间")
+}
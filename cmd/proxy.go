@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"bili-comment/httpclient"
+
+	"github.com/spf13/cobra"
+)
+
+// proxyHealthCheckInterval 代理健康检查间隔
+const proxyHealthCheckInterval = time.Minute
+
+// stopProxyHealthCheck 停止代理健康检查
+var stopProxyHealthCheck func()
+
+// setupProxy 根据 --proxy / --proxy-file 配置共享HTTP客户端的代理池
+func setupProxy(cmd *cobra.Command) error {
+	proxy, _ := cmd.Flags().GetString("proxy")
+	proxyFile, _ := cmd.Flags().GetString("proxy-file")
+	maxFailures, _ := cmd.Flags().GetInt("proxy-max-failures")
+
+	var proxies []string
+	if proxy != "" {
+		proxies = append(proxies, proxy)
+	}
+	if proxyFile != "" {
+		fileProxies, err := httpclient.LoadProxyFile(proxyFile)
+		if err != nil {
+			return fmt.Errorf("读取代理文件失败: %v", err)
+		}
+		proxies = append(proxies, fileProxies...)
+	}
+
+	if len(proxies) == 0 {
+		return nil
+	}
+
+	pool, err := httpclient.NewProxyPool(proxies, maxFailures)
+	if err != nil {
+		return fmt.Errorf("创建代理池失败: %v", err)
+	}
+
+	// 代理池有多个代理时先做一次健康检查，无法连接的代理立即剔除
+	if len(proxies) > 1 {
+		pool.CheckHealth("")
+		if pool.Available() == 0 {
+			return fmt.Errorf("代理池中没有可用的代理")
+		}
+	}
+
+	httpclient.UseProxyPool(pool)
+	stopProxyHealthCheck = pool.StartHealthCheck(proxyHealthCheckInterval, "")
+
+	log.Printf("已启用代理池，可用代理 %d/%d 个", pool.Available(), len(proxies))
+	return nil
+}
@@ -1,12 +1,13 @@
 package cmd
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
 	"strings"
+	"time"
+
+	"bili-comment/store"
 
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/spf13/cobra"
 )
 
@@ -20,7 +21,8 @@ var queryCmd = &cobra.Command{
   bili-comment query --count           # 统计评论总数
   bili-comment query --list=10         # 显示前10条评论
   bili-comment query --bv=BV1xxx       # 查询指定视频的评论
-  bili-comment query --user="用户名"    # 查询指定用户的评论`,
+  bili-comment query --user="用户名"    # 查询指定用户的评论
+  bili-comment query --list=10 --since=2024-01-01 --until=2024-01-07 # 查询该时间段的评论`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		dbPath, _ := cmd.Flags().GetString("db")
 		showCount, _ := cmd.Flags().GetBool("count")
@@ -28,35 +30,62 @@ var queryCmd = &cobra.Command{
 		bv, _ := cmd.Flags().GetString("bv")
 		user, _ := cmd.Flags().GetString("user")
 
-		return runQuery(dbPath, showCount, listLimit, bv, user)
+		since, until, err := getTimeRangeFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		return runQuery(dbPath, showCount, listLimit, bv, user, since, until)
 	},
 }
 
-func runQuery(dbPath string, showCount bool, listLimit int, bv, user string) error {
+// buildCommentFilter 根据查询条件构造 WHERE 子句
+func buildCommentFilter(bv, user string, since, until time.Time) (string, []interface{}) {
+	var conditions []string
+	args := []interface{}{}
+
+	if bv != "" {
+		conditions = append(conditions, "bv = ?")
+		args = append(args, bv)
+	} else if user != "" {
+		conditions = append(conditions, "username = ?")
+		args = append(args, user)
+	}
+
+	// 评论时间以本地时间字符串保存，可直接按字符串比较
+	if !since.IsZero() {
+		conditions = append(conditions, "comment_time >= ?")
+		args = append(args, since.Local().Format("2006-01-02 15:04:05"))
+	}
+	if !until.IsZero() {
+		conditions = append(conditions, "comment_time < ?")
+		args = append(args, until.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func runQuery(dbPath string, showCount bool, listLimit int, bv, user string, since, until time.Time) error {
 	if dbPath == "" {
 		dbPath = "./data/crawler.db"
 	}
 
-	// 连接数据库
-	db, err := sql.Open("sqlite3", dbPath)
+	// 连接数据库（自动执行结构迁移）
+	sqliteStore, err := store.OpenSQLite(dbPath)
 	if err != nil {
 		return fmt.Errorf("连接数据库失败: %v", err)
 	}
-	defer db.Close()
+	defer sqliteStore.Close()
+	db := sqliteStore.DB()
 
 	// 统计评论总数
 	if showCount {
 		var total int
-		query := "SELECT COUNT(*) FROM bilibili_comments"
-		args := []interface{}{}
-
-		if bv != "" {
-			query += " WHERE 视频BV号 = ?"
-			args = append(args, bv)
-		} else if user != "" {
-			query += " WHERE 用户名 = ?"
-			args = append(args, user)
-		}
+		where, args := buildCommentFilter(bv, user, since, until)
+		query := "SELECT COUNT(*) FROM bilibili_comments" + where
 
 		err := db.QueryRow(query, args...).Scan(&total)
 		if err != nil {
@@ -70,20 +99,13 @@ func runQuery(dbPath string, showCount bool, listLimit int, bv, user string) err
 	// 列出评论
 	if listLimit > 0 {
 		query := `
-		SELECT 序号, 用户名, 评论内容, 评论时间, 点赞数, 回复数, 视频BV号 
+		SELECT serial_number, username, content, comment_time, like_count, reply_count, bv 
 		FROM bilibili_comments 
 		`
-		args := []interface{}{}
-
-		if bv != "" {
-			query += " WHERE 视频BV号 = ?"
-			args = append(args, bv)
-		} else if user != "" {
-			query += " WHERE 用户名 = ?"
-			args = append(args, user)
-		}
+		where, args := buildCommentFilter(bv, user, since, until)
+		query += where
 
-		query += " ORDER BY 序号 LIMIT ?"
+		query += " ORDER BY serial_number LIMIT ?"
 		args = append(args, listLimit)
 
 		rows, err := db.Query(query, args...)
@@ -132,4 +154,5 @@ func init() {
 	queryCmd.Flags().Int("list", 0, "显示指定数量的评论列表")
 	queryCmd.Flags().String("bv", "", "查询指定BV号的评论")
 	queryCmd.Flags().String("user", "", "查询指定用户的评论")
+	addTimeRangeFlags(queryCmd)
 }
@@ -3,8 +3,10 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"bili-comment/gamersky"
+	"bili-comment/store"
 
 	"github.com/spf13/cobra"
 )
@@ -16,22 +18,31 @@ var queryGamerskyCmd = &cobra.Command{
 	Long: `查询数据库中已爬取的Gamersky新闻数据。
 
 示例：
-  bili-comment query-gamersky                    # 查询所有新闻
+  bili-comment query-gamersky                    # 查询所有新闻（按发布时间倒序）
   bili-comment query-gamersky --limit=10         # 限制查询结果数量
-  bili-comment query-gamersky --output=/tmp/news.db # 指定数据库路径`,
+  bili-comment query-gamersky --output=/tmp/news.db # 指定数据库路径
+  bili-comment query-gamersky --trending=24h     # 最近24小时评论数增长最多的新闻
+
+新闻重新爬取时会更新评论数，评论数变化记录在 gamersky_news_history 表中，--trending 据此列出评论数增长最快的新闻。`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 获取参数
 		limit, _ := cmd.Flags().GetInt("limit")
 		outputPath, _ := cmd.Flags().GetString("output")
+		storeDSN, _ := cmd.Flags().GetString("store")
+		trending, _ := cmd.Flags().GetDuration("trending")
 
-		return queryGamerskyNews(outputPath, limit)
+		if trending > 0 {
+			return queryGamerskyTrends(outputPath, storeDSN, trending, limit)
+		}
+		return queryGamerskyNews(outputPath, storeDSN, limit)
 	},
 }
 
-func queryGamerskyNews(dbPath string, limit int) error {
+func queryGamerskyNews(dbPath, storeDSN string, limit int) error {
 	// 创建配置
 	config := &gamersky.Config{
 		OutputPath: dbPath,
+		StoreDSN:   storeDSN,
 	}
 
 	// 创建爬虫实例（用于查询）
@@ -57,6 +68,12 @@ func queryGamerskyNews(dbPath string, limit int) error {
 	for i, item := range news {
 		fmt.Printf("\n%d. [%s] %s\n", i+1, item.SID, item.Title)
 		fmt.Printf("   时间: %s\n", item.Time)
+		if item.PublishedAt != "" {
+			fmt.Printf("   发布时间: %s\n", item.PublishedAt)
+		}
+		if item.Channel != "" {
+			fmt.Printf("   频道: %s\n", gamersky.ChannelLabel(item.Channel))
+		}
 		fmt.Printf("   评论数: %d\n", item.CommentNum)
 		fmt.Printf("   链接: %s\n", item.URL)
 		if item.ImageURL != "" {
@@ -69,10 +86,45 @@ func queryGamerskyNews(dbPath string, limit int) error {
 	return nil
 }
 
+// queryGamerskyTrends 列出最近一段时间评论数增长最多的新闻，只支持SQLite存储
+func queryGamerskyTrends(dbPath, storeDSN string, window time.Duration, limit int) error {
+	path, ok := store.SQLitePath(storeDSN, dbPath)
+	if !ok {
+		return fmt.Errorf("--trending 只支持SQLite存储")
+	}
+
+	// 连接数据库（自动执行结构迁移）
+	sqliteStore, err := store.OpenSQLite(path)
+	if err != nil {
+		return fmt.Errorf("连接数据库失败: %v", err)
+	}
+	defer sqliteStore.Close()
+
+	trends, err := sqliteStore.NewsTrends(time.Now().Add(-window), limit)
+	if err != nil {
+		return fmt.Errorf("查询评论数变化失败: %v", err)
+	}
+
+	if len(trends) == 0 {
+		log.Printf("最近 %v 内没有新闻的评论数发生变化", window)
+		return nil
+	}
+
+	log.Printf("最近 %v 内评论数变化的新闻 %d 条（按增长排序）：", window, len(trends))
+	for i, trend := range trends {
+		fmt.Printf("\n%d. [%s] %s\n", i+1, trend.SID, trend.Title)
+		fmt.Printf("   评论数: %d → %d (%+d，%d 次记录)\n", trend.Baseline, trend.CommentNum, trend.Growth, trend.Samples)
+		fmt.Printf("   链接: %s\n", trend.URL)
+	}
+
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(queryGamerskyCmd)
 
 	// 添加命令行参数
 	queryGamerskyCmd.Flags().Int("limit", 20, "限制查询结果数量 (0=无限制)")
 	queryGamerskyCmd.Flags().String("output", "./data/gamersky.db", "数据库文件路径")
+	queryGamerskyCmd.Flags().Duration("trending", 0, "列出该时间段内评论数增长最多的新闻，如 24h")
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"bili-comment/gamersky"
 
@@ -20,23 +21,31 @@ var queryGamerskyCommentsCmd = &cobra.Command{
   bili-comment query-gamersky-comments                           # 查询所有评论（默认限制20条）
   bili-comment query-gamersky-comments --article-id=2014209     # 查询指定文章的评论
   bili-comment query-gamersky-comments --limit=50               # 查询50条评论
-  bili-comment query-gamersky-comments --article-id=2014209 --limit=100 # 查询指定文章的100条评论`,
+  bili-comment query-gamersky-comments --article-id=2014209 --limit=100 # 查询指定文章的100条评论
+  bili-comment query-gamersky-comments --since=2024-01-01 --until=2024-01-07 # 查询该时间段的评论`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 从命令行参数获取配置
 		articleID, _ := cmd.Flags().GetString("article-id")
 		limit, _ := cmd.Flags().GetInt("limit")
 		outputPath, _ := cmd.Flags().GetString("output")
+		storeDSN, _ := cmd.Flags().GetString("store")
 
-		return runQueryGamerskyComments(articleID, limit, outputPath)
+		since, until, err := getTimeRangeFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		return runQueryGamerskyComments(articleID, since, until, limit, outputPath, storeDSN)
 	},
 }
 
-func runQueryGamerskyComments(articleID string, limit int, outputPath string) error {
+func runQueryGamerskyComments(articleID string, since, until time.Time, limit int, outputPath, storeDSN string) error {
 	log.Println("开始查询Gamersky评论数据...")
 
 	// 创建配置
 	config := &gamersky.Config{
 		OutputPath: outputPath,
+		StoreDSN:   storeDSN,
 	}
 
 	// 创建爬虫实例
@@ -47,7 +56,7 @@ func runQueryGamerskyComments(articleID string, limit int, outputPath string) er
 	defer crawlerInstance.Close()
 
 	// 查询评论
-	comments, err := crawlerInstance.QueryComments(articleID, limit)
+	comments, err := crawlerInstance.QueryCommentsInRange(articleID, since, until, limit)
 	if err != nil {
 		return fmt.Errorf("查询评论失败: %v", err)
 	}
@@ -99,4 +108,5 @@ func init() {
 	queryGamerskyCommentsCmd.Flags().String("article-id", "", "文章ID（可选，不指定则查询所有文章的评论）")
 	queryGamerskyCommentsCmd.Flags().Int("limit", 20, "限制返回的评论数量")
 	queryGamerskyCommentsCmd.Flags().String("output", "./data/gamersky.db", "数据库文件路径")
+	addTimeRangeFlags(queryGamerskyCommentsCmd)
 }
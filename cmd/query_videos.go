@@ -14,6 +14,7 @@ type QueryConfig struct {
 	Keyword    string // 搜索关键词
 	List       int    // 列出数量
 	OutputPath string // 数据库路径
+	StoreDSN   string // 存储DSN
 }
 
 // queryVideosCmd represents the query videos command
@@ -35,6 +36,7 @@ var queryVideosCmd = &cobra.Command{
 		config.Keyword, _ = cmd.Flags().GetString("keyword")
 		config.List, _ = cmd.Flags().GetInt("list")
 		config.OutputPath, _ = cmd.Flags().GetString("output")
+		config.StoreDSN, _ = cmd.Flags().GetString("store")
 
 		return runQueryVideos(config)
 	},
@@ -46,6 +48,7 @@ func runQueryVideos(config *QueryConfig) error {
 	// 转换配置格式
 	crawlerConfig := &crawler.Config{
 		OutputPath: config.OutputPath,
+		StoreDSN:   config.StoreDSN,
 	}
 
 	// 创建搜索实例
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 
+	"bili-comment/httpclient"
+	"bili-comment/site"
+
 	"github.com/spf13/cobra"
 )
 
@@ -20,7 +23,21 @@ var rootCmd = &cobra.Command{
   bili-comment crawl BV1HW4y1n7BF --mode=3           # 爬取热门评论
   bili-comment crawl BV1HW4y1n7BF --with-replies=false # 不爬取二级评论
   bili-comment search 极氪001                        # 搜索关键词相关的视频
-  bili-comment search 极氪001 --page=2               # 搜索第2页结果`,
+  bili-comment search 极氪001 --page=2               # 搜索第2页结果
+  bili-comment crawl BV1HW4y1n7BF --proxy=socks5://127.0.0.1:1080 # 通过代理爬取
+  bili-comment gamersky-full --proxy-file=proxies.txt # 使用代理池轮换爬取
+  bili-comment gamersky-full --store=jsonl:./data/jsonl # 以JSONL格式追加写入目录`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadSites(cmd); err != nil {
+			return err
+		}
+		return setupProxy(cmd)
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if stopProxyHealthCheck != nil {
+			stopProxyHealthCheck()
+		}
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -34,5 +51,31 @@ func Execute() {
 }
 
 func init() {
-	// 这里可以添加全局标志
+	// 全局代理参数，对所有爬虫的HTTP请求和Colly收集器生效
+	rootCmd.PersistentFlags().String("proxy", "", "代理地址 (支持 http://、https://、socks5://)")
+	rootCmd.PersistentFlags().String("proxy-file", "", "代理池文件路径，每行一个代理地址")
+	rootCmd.PersistentFlags().Int("proxy-max-failures", httpclient.DefaultMaxFailures, "代理连续失败多少次后被剔除")
+
+	// 全局存储参数，为空时使用 --output 指定的SQLite数据库
+	rootCmd.PersistentFlags().String("store", "", "存储DSN (sqlite:路径、jsonl:目录、memory:)，为空时使用 --output 指定的SQLite数据库")
+
+	// 全局站点定义目录，其中的YAML站点定义注册为评论来源
+	rootCmd.PersistentFlags().String("sites", site.DefaultDir, "YAML站点定义目录，其中的站点可通过 --source 使用")
+}
+
+// loadSites 读取站点定义目录并注册为评论来源，未指定 --sites 时默认目录不存在不报错
+func loadSites(cmd *cobra.Command) error {
+	dir, _ := cmd.Flags().GetString("sites")
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) && !cmd.Flags().Changed("sites") {
+			return nil
+		}
+		return fmt.Errorf("读取站点定义目录失败: %v", err)
+	}
+
+	defs, err := site.LoadDir(dir)
+	if err != nil {
+		return err
+	}
+	return site.Register(defs)
 }
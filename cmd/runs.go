@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bili-comment/runlog"
+	"bili-comment/store"
+
+	"github.com/spf13/cobra"
+)
+
+// startRun 在输出数据库中登记本次运行，登记失败或使用非SQLite存储时仅记录日志并返回nil
+func startRun(cmd *cobra.Command, outputPath string) *runlog.Run {
+	storeDSN, _ := cmd.Flags().GetString("store")
+	dbPath, ok := store.SQLitePath(storeDSN, outputPath)
+	if !ok {
+		log.Printf("存储 %s 不是SQLite数据库，不登记运行记录", storeDSN)
+		return nil
+	}
+
+	run, err := runlog.Start(dbPath, cmd.Name(), os.Args[1:])
+	if err != nil {
+		log.Printf("登记运行记录失败: %v", err)
+		return nil
+	}
+
+	log.Printf("运行ID：%d", run.ID())
+	return run
+}
+
+// finishRun 结束运行记录并返回原始错误，收到信号中断时打印摘要并正常退出
+func finishRun(run *runlog.Run, runErr error) error {
+	if isInterrupted(runErr) {
+		log.Println("爬取已中断，数据已保存")
+		printRunSummary(run)
+	}
+
+	if err := run.Finish(runErr); err != nil {
+		log.Printf("%v", err)
+	}
+
+	if isInterrupted(runErr) {
+		return nil
+	}
+	return runErr
+}
+
+// runsCmd represents the runs command
+var runsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "查看爬取运行记录",
+	Long: `查看每次爬取运行的参数、耗时、请求数、插入/忽略行数和错误信息。
+
+示例：
+  bili-comment runs list                            # 列出最近的运行记录
+  bili-comment runs list --db=./data/gamersky.db    # 查看Gamersky数据库的运行记录
+  bili-comment runs show 12                         # 查看运行详情
+  bili-comment runs compare 11 12                   # 对比两次运行`,
+}
+
+// runsListCmd represents the runs list command
+var runsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出运行记录",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbPath, _ := cmd.Flags().GetString("db")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		return runRunsList(dbPath, limit)
+	},
+}
+
+// runsShowCmd represents the runs show command
+var runsShowCmd = &cobra.Command{
+	Use:   "show [运行ID]",
+	Short: "查看运行详情",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbPath, _ := cmd.Flags().GetString("db")
+
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("运行ID无效: %s", args[0])
+		}
+
+		return runRunsShow(dbPath, id)
+	},
+}
+
+// runsCompareCmd represents the runs compare command
+var runsCompareCmd = &cobra.Command{
+	Use:   "compare [运行ID] [运行ID]",
+	Short: "对比两次运行",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbPath, _ := cmd.Flags().GetString("db")
+
+		var ids [2]int64
+		for i, arg := range args {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				return fmt.Errorf("运行ID无效: %s", arg)
+			}
+			ids[i] = id
+		}
+
+		return runRunsCompare(dbPath, ids[0], ids[1])
+	},
+}
+
+func runRunsList(dbPath string, limit int) error {
+	db, err := runlog.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("连接数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	runs, err := runlog.ListRuns(db, limit)
+	if err != nil {
+		return fmt.Errorf("查询运行记录失败: %v", err)
+	}
+
+	if len(runs) == 0 {
+		fmt.Println("没有找到运行记录")
+		return nil
+	}
+
+	fmt.Printf("%-6s %-20s %-20s %-10s %-8s %-8s %-8s %-6s %-8s\n",
+		"ID", "命令", "开始时间", "耗时", "请求数", "插入", "忽略", "错误", "状态")
+	fmt.Println(strings.Repeat("-", 110))
+
+	for _, run := range runs {
+		fmt.Printf("%-6d %-20s %-20s %-10s %-8d %-8d %-8d %-6d %-8s\n",
+			run.ID, run.Command, run.StartTime, runDuration(run), run.Requests,
+			run.Inserted, run.Ignored, run.Errors, run.Status)
+	}
+
+	return nil
+}
+
+func runRunsShow(dbPath string, id int64) error {
+	db, err := runlog.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("连接数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	run, err := runlog.GetRun(db, id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("运行ID: %d\n", run.ID)
+	fmt.Printf("命令: %s\n", run.Command)
+	fmt.Printf("参数: %s\n", run.Args)
+	fmt.Printf("开始时间: %s\n", run.StartTime)
+	fmt.Printf("结束时间: %s\n", run.EndTime)
+	fmt.Printf("耗时: %s\n", runDuration(*run))
+	fmt.Printf("请求数: %d\n", run.Requests)
+	fmt.Printf("插入行数: %d\n", run.Inserted)
+	fmt.Printf("忽略行数: %d\n", run.Ignored)
+	fmt.Printf("错误数: %d\n", run.Errors)
+	fmt.Printf("状态: %s\n", run.Status)
+
+	// 统计各表中由本次运行首次插入的数据
+	counts, err := runlog.CountRowsByRun(db, id)
+	if err != nil {
+		return fmt.Errorf("统计数据行失败: %v", err)
+	}
+	if len(counts) > 0 {
+		fmt.Println("首次插入的数据：")
+		tables := make([]string, 0, len(counts))
+		for table := range counts {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+		for _, table := range tables {
+			fmt.Printf("  %s: %d\n", table, counts[table])
+		}
+	}
+
+	if run.ErrorMessage != "" {
+		fmt.Println("错误信息：")
+		for _, line := range strings.Split(run.ErrorMessage, "\n") {
+			fmt.Printf("  - %s\n", line)
+		}
+	}
+
+	return nil
+}
+
+func runRunsCompare(dbPath string, leftID, rightID int64) error {
+	db, err := runlog.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("连接数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	left, err := runlog.GetRun(db, leftID)
+	if err != nil {
+		return err
+	}
+	right, err := runlog.GetRun(db, rightID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-12s %-24s %-24s %-10s\n", "", fmt.Sprintf("#%d", left.ID), fmt.Sprintf("#%d", right.ID), "差值")
+	fmt.Println(strings.Repeat("-", 74))
+	fmt.Printf("%-12s %-24s %-24s\n", "命令", left.Command, right.Command)
+	fmt.Printf("%-12s %-24s %-24s\n", "开始时间", left.StartTime, right.StartTime)
+	fmt.Printf("%-12s %-24s %-24s\n", "耗时", runDuration(*left), runDuration(*right))
+	fmt.Printf("%-12s %-24s %-24s\n", "状态", left.Status, right.Status)
+
+	printCompareRow := func(name string, l, r int64) {
+		fmt.Printf("%-12s %-24d %-24d %+d\n", name, l, r, r-l)
+	}
+	printCompareRow("请求数", left.Requests, right.Requests)
+	printCompareRow("插入行数", left.Inserted, right.Inserted)
+	printCompareRow("忽略行数", left.Ignored, right.Ignored)
+	printCompareRow("错误数", left.Errors, right.Errors)
+
+	if left.Args != right.Args {
+		fmt.Println("\n参数差异：")
+		fmt.Printf("  #%d: %s\n", left.ID, left.Args)
+		fmt.Printf("  #%d: %s\n", right.ID, right.Args)
+	}
+
+	return nil
+}
+
+// runDuration 计算运行耗时
+func runDuration(run runlog.RunInfo) string {
+	start, err := parseLocalTime(run.StartTime)
+	if err != nil {
+		return "-"
+	}
+	end, err := parseLocalTime(run.EndTime)
+	if err != nil {
+		return "-"
+	}
+	return end.Sub(start).String()
+}
+
+// parseLocalTime 解析数据库中的本地时间字符串
+func parseLocalTime(value string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+}
+
+func init() {
+	rootCmd.AddCommand(runsCmd)
+	runsCmd.AddCommand(runsListCmd)
+	runsCmd.AddCommand(runsShowCmd)
+	runsCmd.AddCommand(runsCompareCmd)
+
+	// 添加命令行参数
+	runsCmd.PersistentFlags().String("db", "./data/crawler.db", "数据库文件路径")
+	runsListCmd.Flags().Int("limit", 20, "显示的记录数量 (0=全部)")
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"bili-comment/schema"
+
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "根据数据结构生成JSON Schema文件",
+	Long: `根据 CommentInfo、VideoInfo、NewsInfo 和 GamerskyComment 结构体的 json 与 jsonschema 标签生成JSON Schema文件。
+
+生成的文件：
+  comment_info_schema.json       B站评论 (CommentInfo)
+  video_info_schema.json         B站视频 (VideoInfo)
+  news_info_schema.json          Gamersky新闻 (gamersky.NewsInfo)
+  gamersky_comment_schema.json   Gamersky评论 (gamersky.Comment)
+
+修改结构体字段后运行本命令重新生成并提交；--check 只比较不写入，文件与生成结果不一致时返回错误，可用于CI。
+
+示例：
+  bili-comment schema                # 在当前目录重新生成所有模式文件
+  bili-comment schema --dir=./docs   # 生成到指定目录
+  bili-comment schema --check        # 检查已提交的模式文件是否最新`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		check, _ := cmd.Flags().GetBool("check")
+
+		if check {
+			return runSchemaCheck(dir)
+		}
+		return runSchemaWrite(dir)
+	},
+}
+
+func runSchemaWrite(dir string) error {
+	written, err := schema.WriteFiles(dir)
+	if err != nil {
+		return err
+	}
+	for _, path := range written {
+		log.Printf("已生成 %s", path)
+	}
+	return nil
+}
+
+func runSchemaCheck(dir string) error {
+	stale, err := schema.CheckFiles(dir)
+	if err != nil {
+		return err
+	}
+	if len(stale) > 0 {
+		return fmt.Errorf("以下模式文件与结构体不一致，请运行 bili-comment schema 重新生成: %s", strings.Join(stale, "、"))
+	}
+	log.Printf("%d 个模式文件均为最新", len(schema.Documents))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+
+	// 添加命令行参数
+	schemaCmd.Flags().String("dir", ".", "模式文件所在目录")
+	schemaCmd.Flags().Bool("check", false, "只检查模式文件是否与结构体一致，不写入")
+}
@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
 
 	"bili-comment/crawler"
+	"bili-comment/runlog"
 
 	"github.com/spf13/cobra"
 )
@@ -16,8 +18,10 @@ type SearchConfig struct {
 	Page         int           // 页数
 	PageSize     int           // 每页大小
 	OutputPath   string        // 输出数据库路径
+	StoreDSN     string        // 存储DSN
 	CookiePath   string        // Cookie文件路径
 	RequestDelay time.Duration // 请求间隔
+	Run          *runlog.Run   // 本次运行记录
 }
 
 // searchCmd represents the search command
@@ -43,21 +47,28 @@ var searchCmd = &cobra.Command{
 		config.Page, _ = cmd.Flags().GetInt("page")
 		config.PageSize, _ = cmd.Flags().GetInt("page-size")
 		config.OutputPath, _ = cmd.Flags().GetString("output")
+		config.StoreDSN, _ = cmd.Flags().GetString("store")
 		config.CookiePath, _ = cmd.Flags().GetString("cookie")
 		config.RequestDelay, _ = cmd.Flags().GetDuration("delay")
 
-		return runSearch(config)
+		ctx, stop := newSignalContext()
+		defer stop()
+
+		config.Run = startRun(cmd, config.OutputPath)
+		return finishRun(config.Run, runSearch(ctx, config))
 	},
 }
 
-func runSearch(config *SearchConfig) error {
+func runSearch(ctx context.Context, config *SearchConfig) error {
 	log.Printf("B站视频搜索启动，关键词：%s", config.Keyword)
 
 	// 转换配置格式
 	crawlerConfig := &crawler.Config{
 		OutputPath:   config.OutputPath,
+		StoreDSN:     config.StoreDSN,
 		CookiePath:   config.CookiePath,
 		RequestDelay: config.RequestDelay,
+		Run:          config.Run,
 	}
 
 	// 创建搜索实例
@@ -72,7 +83,10 @@ func runSearch(config *SearchConfig) error {
 	log.Printf("请求延迟：%v", config.RequestDelay)
 
 	// 执行搜索
-	videos, err := searcher.SearchVideos(config.Keyword, config.Page, config.PageSize)
+	videos, err := searcher.SearchVideos(ctx, config.Keyword, config.Page, config.PageSize)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	if err != nil {
 		return fmt.Errorf("搜索视频失败: %v", err)
 	}
@@ -88,7 +102,7 @@ func runSearch(config *SearchConfig) error {
 	}
 
 	log.Printf("搜索完成！共找到 %d 个视频，成功保存 %d 个", len(videos), savedCount)
-	log.Printf("搜索结果已保存到 SQLite 数据库：%s", config.OutputPath)
+	log.Printf("搜索结果已保存到 %s", describeStore(config.StoreDSN, config.OutputPath))
 
 	return nil
 }
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"bili-comment/runlog"
+)
+
+// newSignalContext 返回收到 SIGINT/SIGTERM 时取消的context
+// 第一次收到信号时取消context，爬虫完成当前页、保存断点后退出；再次收到信号时立即退出
+func newSignalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sigChan := make(chan os.Signal, 2)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// stopped 在调用停止函数后关闭，之后不再处理信号
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-sigChan:
+			log.Printf("收到信号 %v，完成当前页后停止（再次按 Ctrl+C 立即退出）...", sig)
+			cancel()
+		case <-stopped:
+			return
+		}
+
+		// 收尾时间不限，直到调用停止函数前再次收到信号都立即退出
+		select {
+		case <-sigChan:
+			log.Println("再次收到信号，立即退出")
+			os.Exit(130)
+		case <-stopped:
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(sigChan)
+			close(stopped)
+			cancel()
+		})
+	}
+}
+
+// isInterrupted 判断错误是否由收到信号取消context引起
+func isInterrupted(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// printRunSummary 打印运行摘要
+func printRunSummary(run *runlog.Run) {
+	info := run.Info()
+	log.Printf("运行摘要：运行ID %d，请求 %d 次，插入 %d 行，忽略 %d 行，错误 %d 次",
+		info.ID, info.Requests, info.Inserted, info.Ignored, info.Errors)
+}
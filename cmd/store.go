@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"fmt"
+
+	"bili-comment/store"
+)
+
+// describeStore 描述数据的存储位置，用于日志输出
+func describeStore(storeDSN, outputPath string) string {
+	kind, path, err := store.ParseDSN(storeDSN, outputPath)
+	if err != nil {
+		return storeDSN
+	}
+
+	switch kind {
+	case store.KindJSONL:
+		return fmt.Sprintf("JSONL 目录：%s", path)
+	case store.KindMemory:
+		return "内存（不落盘）"
+	default:
+		return fmt.Sprintf("SQLite 数据库：%s", path)
+	}
+}
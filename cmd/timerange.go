@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// timeFlagLayouts --since/--until 支持的时间格式
+var timeFlagLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// addTimeRangeFlags 添加 --since/--until 参数
+func addTimeRangeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("since", "", "只保留该时间及之后的评论，如 2024-01-01 或 \"2024-01-01 08:00\"")
+	cmd.Flags().String("until", "", "只保留该时间之前的评论，只写日期时包含当天")
+}
+
+// getTimeRangeFlags 读取 --since/--until 参数，未设置时返回零值
+func getTimeRangeFlags(cmd *cobra.Command) (since, until time.Time, err error) {
+	sinceValue, _ := cmd.Flags().GetString("since")
+	untilValue, _ := cmd.Flags().GetString("until")
+
+	if sinceValue != "" {
+		since, _, err = parseTimeFlag(sinceValue)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("--since 参数无效: %v", err)
+		}
+	}
+
+	if untilValue != "" {
+		var dateOnly bool
+		until, dateOnly, err = parseTimeFlag(untilValue)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("--until 参数无效: %v", err)
+		}
+		// 只写日期时包含当天全天
+		if dateOnly {
+			until = until.AddDate(0, 0, 1)
+		}
+	}
+
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--since 必须早于 --until")
+	}
+
+	return since, until, nil
+}
+
+// parseTimeFlag 按本地时区解析时间参数，同时支持 RFC3339 格式
+func parseTimeFlag(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+
+	for _, layout := range timeFlagLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, layout == "2006-01-02", nil
+		}
+	}
+
+	return time.Time{}, false, fmt.Errorf("无法解析时间 %q，请使用 2006-01-02 或 \"2006-01-02 15:04:05\" 格式", value)
+}
+
+// logTimeRange 打印时间范围
+func logTimeRange(since, until time.Time) {
+	if since.IsZero() && until.IsZero() {
+		return
+	}
+
+	format := func(t time.Time) string {
+		if t.IsZero() {
+			return "不限"
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+	log.Printf("评论时间范围：%s ~ %s", format(since), format(until))
+}
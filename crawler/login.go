@@ -0,0 +1,218 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"bili-comment/httpclient"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// DefaultPassportURL B站通行证接口地址
+const DefaultPassportURL = "https://passport.bilibili.com"
+
+// 二维码扫描状态码
+const (
+	QRStatusSuccess    = 0     // 登录成功
+	QRStatusExpired    = 86038 // 二维码已失效
+	QRStatusConfirming = 86090 // 已扫码，等待确认
+	QRStatusWaiting    = 86101 // 未扫码
+)
+
+// QRCodeInfo 登录二维码信息
+type QRCodeInfo struct {
+	URL string `json:"url"`        // 二维码内容
+	Key string `json:"qrcode_key"` // 轮询使用的key
+}
+
+// QRPollResult 扫码状态轮询结果
+type QRPollResult struct {
+	Code         int    `json:"code"`          // 状态码
+	Message      string `json:"message"`       // 状态描述
+	URL          string `json:"url"`           // 登录成功后的跳转地址
+	RefreshToken string `json:"refresh_token"` // 刷新令牌
+	Cookie       string `json:"-"`             // 登录成功后得到的Cookie
+}
+
+// QRCodeGenerateResponse 申请二维码响应结构体
+type QRCodeGenerateResponse struct {
+	Code    int        `json:"code"`
+	Message string     `json:"message"`
+	Data    QRCodeInfo `json:"data"`
+}
+
+// QRCodePollResponse 轮询扫码状态响应结构体
+type QRCodePollResponse struct {
+	Code    int          `json:"code"`
+	Message string       `json:"message"`
+	Data    QRPollResult `json:"data"`
+}
+
+// QRLogin B站二维码登录
+type QRLogin struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewQRLogin 创建二维码登录实例，baseURL为空时使用B站通行证地址
+func NewQRLogin(baseURL string) *QRLogin {
+	if baseURL == "" {
+		baseURL = DefaultPassportURL
+	}
+
+	return &QRLogin{
+		client:  httpclient.Default(),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// getLoginHeader 获取登录请求头
+func (ql *QRLogin) getLoginHeader() map[string]string {
+	return map[string]string{
+		"User-Agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:135.0) Gecko/20100101 Firefox/135.0",
+		"Referer":    "https://www.bilibili.com/",
+	}
+}
+
+// get 发送GET请求并返回响应
+func (ql *QRLogin) get(path string) (*http.Response, []byte, error) {
+	req, err := http.NewRequest("GET", ql.baseURL+path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 设置请求头
+	for key, value := range ql.getLoginHeader() {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := ql.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp, body, nil
+}
+
+// GenerateQRCode 申请登录二维码
+func (ql *QRLogin) GenerateQRCode() (*QRCodeInfo, error) {
+	_, body, err := ql.get("/x/passport-login/web/qrcode/generate")
+	if err != nil {
+		return nil, fmt.Errorf("申请二维码失败: %v", err)
+	}
+
+	var generateResp QRCodeGenerateResponse
+	if err := json.Unmarshal(body, &generateResp); err != nil {
+		return nil, fmt.Errorf("解析JSON失败: %v", err)
+	}
+
+	if generateResp.Code != 0 {
+		return nil, fmt.Errorf("API错误: %s (代码: %d)", generateResp.Message, generateResp.Code)
+	}
+
+	if generateResp.Data.Key == "" || generateResp.Data.URL == "" {
+		return nil, fmt.Errorf("二维码信息不完整")
+	}
+
+	return &generateResp.Data, nil
+}
+
+// PollQRCode 查询二维码扫描状态，登录成功时结果中携带Cookie
+func (ql *QRLogin) PollQRCode(key string) (*QRPollResult, error) {
+	resp, body, err := ql.get("/x/passport-login/web/qrcode/poll?qrcode_key=" + url.QueryEscape(key))
+	if err != nil {
+		return nil, fmt.Errorf("查询扫码状态失败: %v", err)
+	}
+
+	var pollResp QRCodePollResponse
+	if err := json.Unmarshal(body, &pollResp); err != nil {
+		return nil, fmt.Errorf("解析JSON失败: %v", err)
+	}
+
+	if pollResp.Code != 0 {
+		return nil, fmt.Errorf("API错误: %s (代码: %d)", pollResp.Message, pollResp.Code)
+	}
+
+	result := pollResp.Data
+	if result.Code == QRStatusSuccess {
+		result.Cookie = joinCookies(resp.Cookies())
+		if result.Cookie == "" {
+			return nil, fmt.Errorf("登录成功但未返回Cookie")
+		}
+	}
+
+	return &result, nil
+}
+
+// joinCookies 将响应中的Cookie拼接为请求头格式
+func joinCookies(cookies []*http.Cookie) string {
+	var parts []string
+	seen := make(map[string]bool)
+	for _, cookie := range cookies {
+		if cookie.Name == "" || seen[cookie.Name] {
+			continue
+		}
+		seen[cookie.Name] = true
+		parts = append(parts, cookie.Name+"="+cookie.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// RenderQRCode 将内容渲染为终端可显示的Unicode方块二维码
+func RenderQRCode(content string) (string, error) {
+	qr, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return "", err
+	}
+
+	// 每个字符表示上下两个模块，bitmap自带静区
+	bitmap := qr.Bitmap()
+	var sb strings.Builder
+	for y := 0; y < len(bitmap); y += 2 {
+		for x := 0; x < len(bitmap[y]); x++ {
+			top := bitmap[y][x]
+			bottom := y+1 < len(bitmap) && bitmap[y+1][x]
+			switch {
+			case top && bottom:
+				sb.WriteString(" ")
+			case top:
+				sb.WriteString("▄")
+			case bottom:
+				sb.WriteString("▀")
+			default:
+				sb.WriteString("█")
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
+
+// SaveCookie 将Cookie写入readCookie使用的文件
+func SaveCookie(cookiePath, cookie string) error {
+	if cookiePath == "" {
+		cookiePath = "bili_cookie.txt"
+	}
+
+	if dir := filepath.Dir(cookiePath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	return os.WriteFile(cookiePath, []byte(cookie+"\n"), 0600)
+}
@@ -0,0 +1,95 @@
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// fakePassport 模拟B站通行证接口：每个二维码按预设的状态序列依次返回扫码状态
+func fakePassport(t *testing.T, statuses map[string][]int) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	polls := make(map[string]int)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/x/passport-login/web/qrcode/generate", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(QRCodeGenerateResponse{
+			Data: QRCodeInfo{URL: "https://account.bilibili.com/h5/account-h5/auth/scan-web?qrcode_key=login", Key: "login"},
+		})
+	})
+	mux.HandleFunc("/x/passport-login/web/qrcode/poll", func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("qrcode_key")
+		mu.Lock()
+		sequence := statuses[key]
+		i := min(polls[key], len(sequence)-1)
+		polls[key]++
+		mu.Unlock()
+
+		code := sequence[i]
+		if code == QRStatusSuccess {
+			http.SetCookie(w, &http.Cookie{Name: "SESSDATA", Value: "sess"})
+			http.SetCookie(w, &http.Cookie{Name: "bili_jct", Value: "csrf"})
+		}
+		json.NewEncoder(w).Encode(QRCodePollResponse{Data: QRPollResult{Code: code}})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestQRLoginFlow(t *testing.T) {
+	server := fakePassport(t, map[string][]int{
+		"login":   {QRStatusWaiting, QRStatusConfirming, QRStatusSuccess},
+		"expired": {QRStatusWaiting, QRStatusExpired},
+	})
+	login := NewQRLogin(server.URL + "/")
+
+	info, err := login.GenerateQRCode()
+	if err != nil {
+		t.Fatalf("申请二维码失败: %v", err)
+	}
+	if info.Key != "login" || info.URL == "" {
+		t.Fatalf("二维码信息 = %+v", info)
+	}
+
+	// 未扫码 → 已扫码待确认 → 登录成功
+	var result *QRPollResult
+	for _, want := range []int{QRStatusWaiting, QRStatusConfirming, QRStatusSuccess} {
+		result, err = login.PollQRCode(info.Key)
+		if err != nil {
+			t.Fatalf("查询扫码状态失败: %v", err)
+		}
+		if result.Code != want {
+			t.Fatalf("扫码状态 = %d, 期望 %d", result.Code, want)
+		}
+	}
+	if result.Cookie != "SESSDATA=sess; bili_jct=csrf" {
+		t.Errorf("Cookie = %q", result.Cookie)
+	}
+
+	// Cookie文件能被爬虫读取
+	cookiePath := filepath.Join(t.TempDir(), "cookies", "bili_cookie.txt")
+	if err := SaveCookie(cookiePath, result.Cookie); err != nil {
+		t.Fatalf("保存Cookie失败: %v", err)
+	}
+	if cookie, err := readCookie(cookiePath); err != nil || cookie != result.Cookie {
+		t.Errorf("读取Cookie = %q, %v", cookie, err)
+	}
+
+	// 二维码失效
+	for _, want := range []int{QRStatusWaiting, QRStatusExpired} {
+		result, err := login.PollQRCode("expired")
+		if err != nil {
+			t.Fatalf("查询扫码状态失败: %v", err)
+		}
+		if result.Code != want || result.Cookie != "" {
+			t.Errorf("扫码状态 = %+v, 期望 %d 且没有Cookie", result, want)
+		}
+	}
+}
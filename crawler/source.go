@@ -0,0 +1,146 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"bili-comment/source"
+)
+
+// bilibiliSource B站评论来源插件：条目为关键词搜索到的视频，评论按BV号爬取
+type bilibiliSource struct{}
+
+func init() {
+	source.Register(bilibiliSource{})
+}
+
+// bilibiliModes 评论排序与B站API爬取模式的对应关系
+var bilibiliModes = map[string]int{
+	"":       2,
+	"newest": 2,
+	"hot":    3,
+}
+
+func (bilibiliSource) Name() string {
+	return source.Bilibili
+}
+
+func (bilibiliSource) Description() string {
+	return "B站视频评论，条目为关键词搜索结果，评论需要Cookie"
+}
+
+func (bilibiliSource) DefaultOutputPath() string {
+	return DefaultOutputPath
+}
+
+// config 根据通用参数创建爬虫配置
+func (bilibiliSource) config(opts source.Options) (*Config, error) {
+	mode, ok := bilibiliModes[opts.Order]
+	if !ok {
+		return nil, fmt.Errorf("B站评论不支持排序 %s (可选 newest、hot)", opts.Order)
+	}
+	if opts.MinPraises > 0 {
+		return nil, fmt.Errorf("B站评论不支持按最低点赞数过滤")
+	}
+
+	return &Config{
+		Mode:         mode,
+		WithReplies:  opts.WithReplies,
+		MaxPages:     opts.ReplyPages,
+		CookiePath:   opts.CookiePath,
+		RequestDelay: opts.RequestDelay,
+		Run:          opts.Run,
+		Since:        opts.Since,
+		Until:        opts.Until,
+	}, nil
+}
+
+func (s bilibiliSource) ListItems(ctx context.Context, query string, page int, opts source.Options) ([]interface{}, error) {
+	if query == "" {
+		return nil, fmt.Errorf("B站视频列表需要指定搜索关键词")
+	}
+	if opts.Channel != "" {
+		return nil, fmt.Errorf("B站视频列表不支持频道 %s", opts.Channel)
+	}
+
+	config, err := s.config(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cookie, err := readCookie(config.CookiePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取cookie失败: %v", err)
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	searcher := NewBilibiliVideoSearcherWithStore(config, nil, cookie)
+	videos, err := searcher.SearchVideos(ctx, query, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]interface{}, len(videos))
+	for i, video := range videos {
+		items[i] = video
+	}
+	return items, nil
+}
+
+func (s bilibiliSource) FetchComments(ctx context.Context, itemID string, opts source.Options, emit source.EmitFunc) (int, error) {
+	config, err := s.config(opts)
+	if err != nil {
+		return 0, err
+	}
+
+	cookie, err := readCookie(config.CookiePath)
+	if err != nil {
+		return 0, fmt.Errorf("读取cookie失败: %v", err)
+	}
+
+	crawlerInstance := NewBilibiliCommentCrawlerWithStore(config, source.NewEmitStore(emit), cookie)
+	return crawlerInstance.CrawlAll(ctx, itemID, opts.Pages, opts.Resume)
+}
+
+func (bilibiliSource) Normalize(record interface{}) (source.Entry, error) {
+	switch r := record.(type) {
+	case CommentInfo:
+		entry := source.Entry{
+			Source:  source.Bilibili,
+			Kind:    source.KindComment,
+			ID:      strconv.FormatInt(r.CommentID, 10),
+			ItemID:  r.BV,
+			Title:   r.VideoTitle,
+			Author:  r.Username,
+			Content: r.Content,
+			Time:    r.CommentTime,
+			Likes:   int64(r.LikeCount),
+			Replies: int64(r.ReplyCount),
+		}
+		if r.ParentID != 0 {
+			entry.ParentID = strconv.FormatInt(r.ParentID, 10)
+		}
+		return entry, nil
+	case VideoInfo:
+		return source.Entry{
+			Source:  source.Bilibili,
+			Kind:    source.KindItem,
+			ID:      r.BVID,
+			Title:   r.Title,
+			Author:  r.Author,
+			Content: r.Description,
+			Time:    time.Unix(r.PubDate, 0).Format("2006-01-02 15:04:05"),
+			Likes:   int64(r.Like),
+			Replies: int64(r.VideoReview),
+			URL:     "https://www.bilibili.com/video/" + r.BVID,
+		}, nil
+	default:
+		return source.Entry{}, fmt.Errorf("B站来源不支持的记录类型: %T", record)
+	}
+}
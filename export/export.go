@@ -0,0 +1,356 @@
+package export
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"bili-comment/schema"
+)
+
+// ColumnType 导出列的数据类型
+type ColumnType int
+
+const (
+	TypeString ColumnType = iota // 文本
+	TypeInt                      // 整数
+	TypeBool                     // 布尔值
+	TypeTime                     // 以 "2006-01-02 15:04:05" 文本保存的时间
+)
+
+// TimeLayout 数据库中时间文本的格式
+const TimeLayout = "2006-01-02 15:04:05"
+
+// Column 导出的一列
+type Column struct {
+	Name string     // 列名，与数据库列名和JSON字段名一致
+	Type ColumnType // 数据类型
+	Expr string     // SQL表达式，为空时使用列名
+}
+
+// Source 可导出的数据来源
+type Source struct {
+	Name        string         // 来源名称，如 bilibili-comments
+	Table       string         // 数据表
+	Columns     []Column       // 导出的列
+	TimeColumn  string         // --since/--until 过滤的时间列
+	Location    *time.Location // 时间列文本所在的时区
+	OrderBy     string         // 排序
+	DefaultPath string         // 默认数据库路径
+	Schema      string         // JSON导出校验使用的模式名称，见 schema.Documents
+}
+
+// cst Gamersky时间以北京时间保存
+var cst = time.FixedZone("CST", 8*3600)
+
+// Sources 所有可导出的数据来源
+var Sources = map[string]Source{
+	"bilibili-comments": {
+		Name:  "bilibili-comments",
+		Table: "bilibili_comments",
+		Columns: []Column{
+			{Name: "serial_number", Type: TypeInt},
+			{Name: "parent_id", Type: TypeInt},
+			{Name: "comment_id", Type: TypeInt},
+			{Name: "user_id", Type: TypeInt},
+			{Name: "username", Type: TypeString},
+			{Name: "user_level", Type: TypeInt},
+			{Name: "gender", Type: TypeString},
+			{Name: "content", Type: TypeString},
+			{Name: "comment_time", Type: TypeTime},
+			{Name: "reply_count", Type: TypeInt},
+			{Name: "like_count", Type: TypeInt},
+			{Name: "signature", Type: TypeString},
+			{Name: "ip_location", Type: TypeString},
+			{Name: "is_vip", Type: TypeString},
+			{Name: "avatar", Type: TypeString},
+			{Name: "bv", Type: TypeString},
+			{Name: "video_title", Type: TypeString},
+		},
+		TimeColumn:  "comment_time",
+		Location:    time.Local,
+		OrderBy:     "bv, serial_number",
+		DefaultPath: "./data/crawler.db",
+		Schema:      "comment-info",
+	},
+	"bilibili-videos": {
+		Name:  "bilibili-videos",
+		Table: "bilibili_videos",
+		Columns: []Column{
+			{Name: "keyword", Type: TypeString},
+			{Name: "bvid", Type: TypeString},
+			{Name: "title", Type: TypeString},
+			{Name: "author", Type: TypeString},
+			{Name: "play", Type: TypeInt},
+			{Name: "video_review", Type: TypeInt},
+			{Name: "favorites", Type: TypeInt},
+			{Name: "pubdate", Type: TypeInt},
+			{Name: "duration", Type: TypeString},
+			{Name: "like", Type: TypeInt, Expr: "like_count"}, // 与 VideoInfo 的JSON字段名一致
+			{Name: "danmaku", Type: TypeInt},
+			{Name: "description", Type: TypeString},
+			{Name: "pic", Type: TypeString},
+			{Name: "create_time", Type: TypeTime},
+		},
+		TimeColumn:  "create_time",
+		Location:    time.Local,
+		OrderBy:     "keyword, play DESC",
+		DefaultPath: "./data/crawler.db",
+		Schema:      "video-info",
+	},
+	"gamersky-news": {
+		Name:  "gamersky-news",
+		Table: "gamersky_news",
+		Columns: []Column{
+			{Name: "sid", Type: TypeString},
+			{Name: "title", Type: TypeString},
+			{Name: "time", Type: TypeString},
+			{Name: "comment_num", Type: TypeInt},
+			{Name: "url", Type: TypeString},
+			{Name: "image_url", Type: TypeString},
+			{Name: "topline_time", Type: TypeString},
+			{Name: "create_time", Type: TypeTime},
+			{Name: "channel", Type: TypeString},
+			{Name: "published_at", Type: TypeTime},
+		},
+		TimeColumn:  "create_time",
+		Location:    time.Local,
+		OrderBy:     "COALESCE(NULLIF(published_at, ''), create_time) DESC, create_time DESC",
+		DefaultPath: "./data/gamersky.db",
+		Schema:      "news-info",
+	},
+	"gamersky-comments": {
+		Name:  "gamersky-comments",
+		Table: "gamersky_comments",
+		Columns: []Column{
+			{Name: "id", Type: TypeInt},
+			{Name: "article_id", Type: TypeString},
+			{Name: "user_id", Type: TypeInt},
+			{Name: "username", Type: TypeString},
+			{Name: "content", Type: TypeString},
+			{Name: "comment_time", Type: TypeTime},
+			{Name: "support_count", Type: TypeInt},
+			{Name: "reply_count", Type: TypeInt},
+			{Name: "parent_id", Type: TypeInt},
+			{Name: "answer_to_id", Type: TypeInt},
+			{Name: "answer_to_name", Type: TypeString},
+			{Name: "user_avatar", Type: TypeString},
+			{Name: "user_level", Type: TypeInt},
+			{Name: "ip_location", Type: TypeString},
+			{Name: "device_name", Type: TypeString},
+			{Name: "floor_number", Type: TypeInt},
+			{Name: "is_tuijian", Type: TypeBool},
+			{Name: "is_author", Type: TypeBool},
+			{Name: "is_best", Type: TypeBool},
+			{Name: "user_authentication", Type: TypeString},
+			{Name: "user_group_id", Type: TypeInt},
+			{Name: "third_platform_bound", Type: TypeString},
+			{Name: "create_time", Type: TypeTime},
+		},
+		TimeColumn:  "comment_time",
+		Location:    cst,
+		OrderBy:     "article_id, comment_time",
+		DefaultPath: "./data/gamersky.db",
+		Schema:      "gamersky-comment",
+	},
+}
+
+// SourceNames 获取所有来源名称
+func SourceNames() []string {
+	names := make([]string, 0, len(Sources))
+	for name := range Sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Filter 导出过滤条件，与 query 系列命令的参数对应
+type Filter struct {
+	BV        string    // B站评论：视频BV号
+	User      string    // B站评论：用户名
+	Keyword   string    // B站视频：搜索关键词
+	ArticleID string    // Gamersky评论：文章ID
+	Since     time.Time // 时间下限 (零值表示不限制)
+	Until     time.Time // 时间上限，不含 (零值表示不限制)
+	Limit     int       // 导出数量，<=0 表示不限制
+}
+
+// buildQuery 根据来源和过滤条件构造查询语句
+func buildQuery(source Source, filter Filter) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(value, column, flag string, supported bool) error {
+		if value == "" {
+			return nil
+		}
+		if !supported {
+			return fmt.Errorf("%s 不支持 --%s 过滤", source.Name, flag)
+		}
+		conditions = append(conditions, column+" = ?")
+		args = append(args, value)
+		return nil
+	}
+
+	isBilibiliComments := source.Table == "bilibili_comments"
+	if err := addCondition(filter.BV, "bv", "bv", isBilibiliComments); err != nil {
+		return "", nil, err
+	}
+	if err := addCondition(filter.User, "username", "user", isBilibiliComments); err != nil {
+		return "", nil, err
+	}
+	if err := addCondition(filter.Keyword, "keyword", "keyword", source.Table == "bilibili_videos"); err != nil {
+		return "", nil, err
+	}
+	if err := addCondition(filter.ArticleID, "article_id", "article-id", source.Table == "gamersky_comments"); err != nil {
+		return "", nil, err
+	}
+
+	// 时间以文本保存，转换到来源的时区后按字符串比较
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, source.TimeColumn+" >= ?")
+		args = append(args, filter.Since.In(source.Location).Format(TimeLayout))
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, source.TimeColumn+" < ?")
+		args = append(args, filter.Until.In(source.Location).Format(TimeLayout))
+	}
+
+	selects := make([]string, len(source.Columns))
+	for i, column := range source.Columns {
+		if column.Expr != "" {
+			selects[i] = column.Expr
+		} else {
+			selects[i] = column.Name
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(selects, ", "), source.Table)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY " + source.OrderBy
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	return query, args, nil
+}
+
+// Export 按过滤条件逐行读取数据并写入 w，返回导出的行数
+// 数据逐行从数据库读取并交给写入器，不会一次性加载整张表
+func Export(db *sql.DB, source Source, filter Filter, w Writer) (int64, error) {
+	query, args, err := buildQuery(source, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("查询数据失败: %v", err)
+	}
+	defer rows.Close()
+
+	if err := w.WriteHeader(source.Columns); err != nil {
+		return 0, err
+	}
+
+	// 按列类型扫描，NULL 转换为 nil
+	scans := make([]interface{}, len(source.Columns))
+	for i, column := range source.Columns {
+		switch column.Type {
+		case TypeInt:
+			scans[i] = new(sql.NullInt64)
+		case TypeBool:
+			scans[i] = new(sql.NullBool)
+		default:
+			scans[i] = new(sql.NullString)
+		}
+	}
+
+	var count int64
+	values := make([]interface{}, len(source.Columns))
+	for rows.Next() {
+		if err := rows.Scan(scans...); err != nil {
+			return count, err
+		}
+
+		for i, scan := range scans {
+			values[i] = nil
+			switch v := scan.(type) {
+			case *sql.NullInt64:
+				if v.Valid {
+					values[i] = v.Int64
+				}
+			case *sql.NullBool:
+				if v.Valid {
+					values[i] = v.Bool
+				}
+			case *sql.NullString:
+				if v.Valid {
+					values[i] = v.String
+				}
+			}
+		}
+
+		if err := w.WriteRow(values); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, rows.Err()
+}
+
+// Writer 导出文件写入器，按表头、逐行写入、关闭的顺序调用
+// 行中的值为 nil、string、int64 或 bool，TypeTime 列的值为时间文本
+type Writer interface {
+	WriteHeader(columns []Column) error
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// 导出格式
+const (
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+	FormatXLSX    = "xlsx"
+	FormatParquet = "parquet"
+)
+
+// Formats 所有支持的导出格式
+var Formats = []string{FormatCSV, FormatJSONL, FormatXLSX, FormatParquet}
+
+// NewWriter 创建导出 source 数据的写入器
+func NewWriter(format string, out io.Writer, source Source) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(out), nil
+	case FormatJSONL:
+		return newJSONLWriter(out, schema.MustItem(source.Schema)), nil
+	case FormatXLSX:
+		return newXLSXWriter(out, source.Table)
+	case FormatParquet:
+		return newParquetWriter(out, source.Table, source.Location), nil
+	default:
+		return nil, fmt.Errorf("不支持的导出格式: %s (可选 %s)", format, strings.Join(Formats, "、"))
+	}
+}
+
+// parseTime 解析时间文本，无法解析时 ok 为 false
+func parseTime(value interface{}, loc *time.Location) (time.Time, bool) {
+	text, isString := value.(string)
+	if !isString || text == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(TimeLayout, text, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
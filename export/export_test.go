@@ -0,0 +1,189 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"bili-comment/store"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/xuri/excelize/v2"
+)
+
+// newMemoryDB 创建内存中的SQLite数据库并写入两条Gamersky评论
+// 第二条评论的用户ID、评论时间为 NULL，用于检查空值的导出
+func newMemoryDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每个连接是一个独立的内存数据库
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := store.Migrate(db); err != nil {
+		t.Fatalf("创建数据表失败: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO gamersky_comments (id, article_id, user_id, username, content, comment_time, support_count, is_best, create_time)
+		VALUES (1, '100', 7, '玩家', '逗号,与"引号"', '2025-01-02 09:30:00', 5, 1, '2025-01-02 10:00:00'),
+		       (2, '200', NULL, '路人', '第二条', NULL, 0, 0, '2025-01-02 10:00:00')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// exportBytes 将 source 的全部数据按 format 导出到内存
+func exportBytes(t *testing.T, db *sql.DB, source Source, format string) []byte {
+	t.Helper()
+
+	var out bytes.Buffer
+	w, err := NewWriter(format, &out, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := Export(db, source, Filter{}, w)
+	if err != nil {
+		t.Fatalf("导出 %s 失败: %v", format, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("关闭 %s 写入器失败: %v", format, err)
+	}
+	if count != 2 {
+		t.Errorf("导出 %s: %d 行, 期望 2", format, count)
+	}
+	return out.Bytes()
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	db := newMemoryDB(t)
+	source := Sources["gamersky-comments"]
+
+	t.Run("csv", func(t *testing.T) {
+		data := exportBytes(t, db, source, FormatCSV)
+		if !bytes.HasPrefix(data, utf8BOM) {
+			t.Fatalf("CSV 没有以UTF-8 BOM开头")
+		}
+		records, err := csv.NewReader(bytes.NewReader(data[len(utf8BOM):])).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 3 {
+			t.Fatalf("CSV 行数 = %d, 期望 3", len(records))
+		}
+
+		rows := csvRows(records)
+		// 时间列按数据库中的原样文本输出，NULL 输出为空字符串
+		checkValues(t, "csv 第1行", rows[0], map[string]string{
+			"id": "1", "user_id": "7", "content": `逗号,与"引号"`, "comment_time": "2025-01-02 09:30:00", "is_best": "true",
+		})
+		checkValues(t, "csv 第2行", rows[1], map[string]string{
+			"id": "2", "user_id": "", "comment_time": "", "is_best": "false",
+		})
+	})
+
+	t.Run("xlsx", func(t *testing.T) {
+		data := exportBytes(t, db, source, FormatXLSX)
+		file, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		records, err := file.GetRows(source.Table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 3 {
+			t.Fatalf("XLSX 行数 = %d, 期望 3", len(records))
+		}
+
+		rows := csvRows(records)
+		checkValues(t, "xlsx 第1行", rows[0], map[string]string{
+			"id": "1", "user_id": "7", "content": `逗号,与"引号"`, "comment_time": "2025-01-02 09:30:00", "is_best": "TRUE",
+		})
+		checkValues(t, "xlsx 第2行", rows[1], map[string]string{"id": "2", "user_id": "", "comment_time": ""})
+
+		// 时间列保存为日期单元格，而不是文本
+		cell, _ := excelize.CoordinatesToCellName(columnIndex(records[0], "comment_time")+1, 2)
+		if cellType, err := file.GetCellType(source.Table, cell); err != nil || cellType == excelize.CellTypeSharedString || cellType == excelize.CellTypeInlineString {
+			t.Errorf("时间单元格 %s 的类型 = %v, %v", cell, cellType, err)
+		}
+	})
+
+	t.Run("parquet", func(t *testing.T) {
+		data := exportBytes(t, db, source, FormatParquet)
+
+		type comment struct {
+			ID          *int64    `parquet:"id,optional"`
+			UserID      *int64    `parquet:"user_id,optional"`
+			Content     *string   `parquet:"content,optional"`
+			CommentTime time.Time `parquet:"comment_time,optional,timestamp(millisecond)"` // NULL 读取为零值
+			IsBest      *bool     `parquet:"is_best,optional"`
+		}
+		rows, err := parquet.Read[comment](bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 2 {
+			t.Fatalf("Parquet 行数 = %d, 期望 2", len(rows))
+		}
+
+		first := rows[0]
+		if first.ID == nil || *first.ID != 1 || first.UserID == nil || *first.UserID != 7 ||
+			first.Content == nil || *first.Content != `逗号,与"引号"` || first.IsBest == nil || !*first.IsBest {
+			t.Errorf("Parquet 第1行 = %+v", first)
+		}
+		// Gamersky时间按北京时间解析为时间戳
+		want := time.Date(2025, 1, 2, 9, 30, 0, 0, cst)
+		if !first.CommentTime.Equal(want) {
+			t.Errorf("Parquet 第1行 comment_time = %v, 期望 %v", first.CommentTime, want)
+		}
+
+		second := rows[1]
+		if second.ID == nil || *second.ID != 2 || second.UserID != nil || !second.CommentTime.IsZero() {
+			t.Errorf("Parquet 第2行 = %+v", second)
+		}
+	})
+}
+
+// csvRows 将表头和数据行转换为按列名索引的行
+func csvRows(records [][]string) []map[string]string {
+	header := records[0]
+	var rows []map[string]string
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(record) {
+				row[name] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// checkValues 检查行中指定列的值
+func checkValues(t *testing.T, name string, row map[string]string, want map[string]string) {
+	t.Helper()
+	for column, value := range want {
+		if row[column] != value {
+			t.Errorf("%s %s = %q, 期望 %q", name, column, row[column], value)
+		}
+	}
+}
+
+// columnIndex 列名在表头中的位置
+func columnIndex(header []string, name string) int {
+	for i, column := range header {
+		if column == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquet 写入参数
+const (
+	parquetRowGroupSize = 50000 // 每个行组的行数，决定写入时缓存在内存中的数据量
+	parquetBatchSize    = 1000  // 每次提交给写入器的行数
+)
+
+// parquetWriter Parquet写入器
+// 整数列为 INT64，布尔列为 BOOLEAN，时间列为毫秒精度的 TIMESTAMP，文本列为 UTF-8 字符串，所有列均可为空
+type parquetWriter struct {
+	out     io.Writer
+	name    string
+	loc     *time.Location
+	writer  *parquet.Writer
+	columns []Column
+	indexes []int // 列在 Parquet 模式中的序号（模式按列名排序）
+	rows    []parquet.Row
+}
+
+func newParquetWriter(out io.Writer, name string, loc *time.Location) *parquetWriter {
+	return &parquetWriter{out: out, name: name, loc: loc}
+}
+
+func (w *parquetWriter) WriteHeader(columns []Column) error {
+	group := parquet.Group{}
+	for _, column := range columns {
+		var node parquet.Node
+		switch column.Type {
+		case TypeInt:
+			node = parquet.Int(64)
+		case TypeBool:
+			node = parquet.Leaf(parquet.BooleanType)
+		case TypeTime:
+			node = parquet.Timestamp(parquet.Millisecond)
+		default:
+			node = parquet.String()
+		}
+		group[column.Name] = parquet.Optional(node)
+	}
+	schema := parquet.NewSchema(w.name, group)
+
+	position := make(map[string]int, len(columns))
+	for i, field := range schema.Fields() {
+		position[field.Name()] = i
+	}
+	w.indexes = make([]int, len(columns))
+	for i, column := range columns {
+		w.indexes[i] = position[column.Name]
+	}
+
+	w.columns = columns
+	w.writer = parquet.NewWriter(w.out, schema,
+		parquet.Compression(&parquet.Snappy),
+		parquet.MaxRowsPerRowGroup(parquetRowGroupSize))
+	return nil
+}
+
+func (w *parquetWriter) WriteRow(values []interface{}) error {
+	row := make(parquet.Row, len(values))
+	for i, value := range values {
+		index := w.indexes[i]
+
+		var v parquet.Value
+		switch typed := value.(type) {
+		case int64:
+			v = parquet.Int64Value(typed)
+		case bool:
+			v = parquet.BooleanValue(typed)
+		case string:
+			if w.columns[i].Type == TypeTime {
+				t, ok := parseTime(typed, w.loc)
+				if !ok {
+					row[index] = parquet.NullValue().Level(0, 0, index)
+					continue
+				}
+				v = parquet.Int64Value(t.UnixMilli())
+			} else {
+				v = parquet.ByteArrayValue([]byte(typed))
+			}
+		default:
+			row[index] = parquet.NullValue().Level(0, 0, index)
+			continue
+		}
+		row[index] = v.Level(0, 1, index)
+	}
+
+	w.rows = append(w.rows, row)
+	if len(w.rows) >= parquetBatchSize {
+		return w.flushRows()
+	}
+	return nil
+}
+
+// flushRows 将缓存的行提交给写入器
+func (w *parquetWriter) flushRows() error {
+	if len(w.rows) == 0 {
+		return nil
+	}
+	_, err := w.writer.WriteRows(w.rows)
+	w.rows = w.rows[:0]
+	return err
+}
+
+func (w *parquetWriter) Close() error {
+	if w.writer == nil {
+		return nil
+	}
+	if err := w.flushRows(); err != nil {
+		return err
+	}
+	return w.writer.Close()
+}
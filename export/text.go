@@ -0,0 +1,140 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"bili-comment/schema"
+)
+
+// utf8BOM 让 Excel 以UTF-8打开CSV，避免中文乱码
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// csvWriter CSV写入器，文件以UTF-8 BOM开头
+type csvWriter struct {
+	out    *bufio.Writer
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVWriter(out io.Writer) *csvWriter {
+	buffered := bufio.NewWriter(out)
+	return &csvWriter{out: buffered, writer: csv.NewWriter(buffered)}
+}
+
+func (w *csvWriter) WriteHeader(columns []Column) error {
+	if _, err := w.out.Write(utf8BOM); err != nil {
+		return err
+	}
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	w.record = make([]string, len(columns))
+	return w.writer.Write(header)
+}
+
+func (w *csvWriter) WriteRow(values []interface{}) error {
+	for i, value := range values {
+		switch v := value.(type) {
+		case nil:
+			w.record[i] = ""
+		case string:
+			w.record[i] = v
+		case int64:
+			w.record[i] = strconv.FormatInt(v, 10)
+		case bool:
+			w.record[i] = strconv.FormatBool(v)
+		}
+	}
+	return w.writer.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return err
+	}
+	return w.out.Flush()
+}
+
+// jsonlWriter JSON Lines写入器，每行一个对象，字段顺序与列顺序一致
+// 每行写出前按数据结构生成的JSON Schema校验；NULL 输出为列类型的零值，与结构体序列化结果一致
+type jsonlWriter struct {
+	out     *bufio.Writer
+	schema  *schema.Schema
+	columns []Column
+	keys    [][]byte
+	line    bytes.Buffer
+	count   int64
+}
+
+func newJSONLWriter(out io.Writer, itemSchema *schema.Schema) *jsonlWriter {
+	return &jsonlWriter{out: bufio.NewWriter(out), schema: itemSchema}
+}
+
+func (w *jsonlWriter) WriteHeader(columns []Column) error {
+	w.columns = columns
+	w.keys = make([][]byte, len(columns))
+	for i, column := range columns {
+		key, err := json.Marshal(column.Name)
+		if err != nil {
+			return err
+		}
+		w.keys[i] = key
+	}
+	return nil
+}
+
+func (w *jsonlWriter) WriteRow(values []interface{}) error {
+	w.line.Reset()
+	w.line.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			w.line.WriteByte(',')
+		}
+		w.line.Write(w.keys[i])
+		w.line.WriteByte(':')
+
+		if value == nil {
+			value = zeroValue(w.columns[i].Type)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		w.line.Write(encoded)
+	}
+	w.line.WriteByte('}')
+
+	w.count++
+	if err := w.schema.ValidateJSON(w.line.Bytes()); err != nil {
+		return fmt.Errorf("第 %d 条记录不符合JSON Schema: %v", w.count, err)
+	}
+
+	w.line.WriteByte('\n')
+	_, err := w.out.Write(w.line.Bytes())
+	return err
+}
+
+// zeroValue 列类型的零值
+func zeroValue(columnType ColumnType) interface{} {
+	switch columnType {
+	case TypeInt:
+		return int64(0)
+	case TypeBool:
+		return false
+	default:
+		return ""
+	}
+}
+
+func (w *jsonlWriter) Close() error {
+	return w.out.Flush()
+}
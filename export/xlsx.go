@@ -0,0 +1,113 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxDateFormat 时间列的单元格格式
+const xlsxDateFormat = "yyyy-mm-dd hh:mm:ss"
+
+// xlsxWriter Excel写入器，使用流式写入，首行表头冻结
+// 超过内存阈值的行由 excelize 暂存到临时文件，Close 时写出完整文件
+type xlsxWriter struct {
+	out       io.Writer
+	file      *excelize.File
+	stream    *excelize.StreamWriter
+	columns   []Column
+	dateStyle int
+	row       int
+	cells     []interface{}
+}
+
+func newXLSXWriter(out io.Writer, sheetName string) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+
+	// 工作表名最长31个字符
+	if len(sheetName) > 31 {
+		sheetName = sheetName[:31]
+	}
+	if err := file.SetSheetName("Sheet1", sheetName); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	stream, err := file.NewStreamWriter(sheetName)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	dateFormat := xlsxDateFormat
+	dateStyle, err := file.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &xlsxWriter{out: out, file: file, stream: stream, dateStyle: dateStyle}, nil
+}
+
+func (w *xlsxWriter) WriteHeader(columns []Column) error {
+	w.columns = columns
+	w.cells = make([]interface{}, len(columns))
+
+	// 冻结首行，必须在写入任何行之前设置
+	err := w.stream.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+	if err != nil {
+		return err
+	}
+
+	headerStyle, err := w.file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: column.Name}
+	}
+	w.row = 1
+	return w.stream.SetRow("A1", header)
+}
+
+func (w *xlsxWriter) WriteRow(values []interface{}) error {
+	if w.row >= excelize.TotalRows {
+		return fmt.Errorf("超过Excel最大行数 %d，请使用 --limit 或时间范围分批导出，或改用其他格式", excelize.TotalRows)
+	}
+	w.row++
+
+	for i, value := range values {
+		w.cells[i] = value
+		if w.columns[i].Type != TypeTime {
+			continue
+		}
+		// Excel时间不含时区，按数据库中的原样时间写入
+		if t, ok := parseTime(value, time.UTC); ok {
+			w.cells[i] = excelize.Cell{StyleID: w.dateStyle, Value: t}
+		}
+	}
+
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.stream.SetRow(cell, w.cells)
+}
+
+func (w *xlsxWriter) Close() error {
+	defer w.file.Close()
+
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+	return w.file.Write(w.out)
+}
@@ -0,0 +1,303 @@
+package gamersky
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"bili-comment/httpclient"
+	"bili-comment/store"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxArticlePages 一篇文章最多获取的页数，防止翻页链接循环
+const maxArticlePages = 50
+
+// ArticleURL 获取文章的手机版链接
+func ArticleURL(sid string) string {
+	return "https://wap.gamersky.com/news/Content-" + sid + ".html"
+}
+
+// ErrArticleLayout 文章页面中找不到标题和正文，页面结构可能已经变化
+var ErrArticleLayout = errors.New("无法识别文章页面结构")
+
+// 文章页面各部分的选择器，依次尝试手机版和网页版的结构
+const (
+	articleTitleSelector   = "h1.ymw-contxt-title, .Mid2L_tit h1"
+	articleAsideSelector   = ".ymw-contxt-aside, .Mid2L_tit .detail"
+	articleContentSelector = "#gsTemplateContent_AutoTest, .ymw-contxt, .Mid2L_con"
+	articleTagSelector     = ".ymw-tag a, .Mid2L_tag a"
+	articlePagerSelector   = ".ymw-page a, .page_css a"
+)
+
+// 文章信息栏中的发布时间和署名
+var (
+	articleTimeRegex   = regexp.MustCompile(`\d{4}-\d{2}-\d{2} \d{2}:\d{2}(:\d{2})?`)
+	articleSignRegex   = regexp.MustCompile(`(来源|作者|编辑|责编)[:：]\s*([^\s]+)`)
+	articleSpacesRegex = regexp.MustCompile(`\s+`)
+)
+
+// ArticlePage 文章一页的解析结果
+type ArticlePage struct {
+	Title       string
+	Author      string
+	Editor      string
+	Source      string
+	PublishTime string   // 格式为 2006-01-02 15:04:05，页面没有秒时补0
+	Tags        []string // 标签
+	Paragraphs  []string // 正文段落，去掉了空段落
+	Images      []string // 正文图片的绝对链接，按出现顺序去重
+	NextURL     string   // 下一页的绝对链接，最后一页为空
+}
+
+// ParseArticlePage 解析文章的一页，pageURL 用于将相对链接转换为绝对链接
+func ParseArticlePage(pageURL string, html []byte) (*ArticlePage, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("解析页面链接失败: %v", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("解析HTML失败: %v", err)
+	}
+
+	page := &ArticlePage{
+		Title: cleanText(doc.Find(articleTitleSelector).First().Text()),
+	}
+
+	// 信息栏：发布时间、来源、作者、编辑
+	aside := cleanText(doc.Find(articleAsideSelector).First().Text())
+	if match := articleTimeRegex.FindString(aside); match != "" {
+		if len(match) == len("2006-01-02 15:04") {
+			match += ":00"
+		}
+		page.PublishTime = match
+	}
+	for _, match := range articleSignRegex.FindAllStringSubmatch(aside, -1) {
+		switch match[1] {
+		case "来源":
+			page.Source = match[2]
+		case "作者":
+			page.Author = match[2]
+		case "编辑", "责编":
+			page.Editor = match[2]
+		}
+	}
+
+	doc.Find(articleTagSelector).Each(func(_ int, s *goquery.Selection) {
+		if tag := cleanText(s.Text()); tag != "" {
+			page.Tags = append(page.Tags, tag)
+		}
+	})
+
+	// 正文：段落文字和图片，广告、脚本不计入正文
+	content := doc.Find(articleContentSelector).First()
+	content.Find("script, style, .gs_ccs_solve").Remove()
+
+	seenImages := make(map[string]bool)
+	content.Find("p").Each(func(_ int, p *goquery.Selection) {
+		if text := cleanText(p.Text()); text != "" {
+			page.Paragraphs = append(page.Paragraphs, text)
+		}
+		p.Find("img").Each(func(_ int, img *goquery.Selection) {
+			if src := imageSource(base, img); src != "" && !seenImages[src] {
+				seenImages[src] = true
+				page.Images = append(page.Images, src)
+			}
+		})
+	})
+
+	if page.Title == "" && len(page.Paragraphs) == 0 {
+		return nil, ErrArticleLayout
+	}
+
+	// 下一页链接
+	doc.Find(articlePagerSelector).EachWithBreak(func(_ int, a *goquery.Selection) bool {
+		if !strings.Contains(a.Text(), "下一页") {
+			return true
+		}
+		if next := resolveURL(base, a.AttrOr("href", "")); next != "" {
+			page.NextURL = next
+		}
+		return false
+	})
+
+	return page, nil
+}
+
+// cleanText 合并连续空白（含全角空格和 &nbsp;）并去掉首尾空白
+func cleanText(text string) string {
+	text = strings.NewReplacer("\u3000", " ", "\u00a0", " ").Replace(text)
+	return strings.TrimSpace(articleSpacesRegex.ReplaceAllString(text, " "))
+}
+
+// imageSource 获取图片的绝对链接，懒加载图片使用 data-src 中的原图
+func imageSource(base *url.URL, img *goquery.Selection) string {
+	for _, attr := range []string{"data-src", "data-original", "src"} {
+		src, ok := img.Attr(attr)
+		if !ok || src == "" || strings.HasPrefix(src, "data:") || strings.Contains(src, "/loading.") {
+			continue
+		}
+		return resolveURL(base, src)
+	}
+	return ""
+}
+
+// resolveURL 将相对链接转换为绝对链接
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "javascript:") {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
+
+// ArticleCrawler Gamersky文章正文爬虫
+type ArticleCrawler struct {
+	store  store.ArticleStore
+	config *Config
+}
+
+// NewArticleCrawler 创建新的Gamersky文章正文爬虫实例，存储由配置中的DSN决定
+func NewArticleCrawler(config *Config) (*ArticleCrawler, error) {
+	// 初始化存储
+	st, err := config.openStore()
+	if err != nil {
+		return nil, fmt.Errorf("数据库连接失败: %v", err)
+	}
+
+	return NewArticleCrawlerWithStore(config, st), nil
+}
+
+// NewArticleCrawlerWithStore 使用指定存储创建Gamersky文章正文爬虫实例
+func NewArticleCrawlerWithStore(config *Config, st store.ArticleStore) *ArticleCrawler {
+	return &ArticleCrawler{
+		store:  st,
+		config: config,
+	}
+}
+
+// HasArticle 判断是否已保存文章正文
+func (gac *ArticleCrawler) HasArticle(sid string) (bool, error) {
+	return gac.store.HasArticle(sid)
+}
+
+// CrawlArticle 获取新闻对应的文章正文（含后续分页）并保存，返回保存的文章
+func (gac *ArticleCrawler) CrawlArticle(ctx context.Context, news NewsInfo) (*Article, error) {
+	if news.URL == "" {
+		return nil, fmt.Errorf("新闻 %s 没有文章链接", news.SID)
+	}
+
+	article, err := gac.FetchArticle(ctx, news.URL)
+	if err != nil {
+		return nil, err
+	}
+	article.SID = news.SID
+	if article.Title == "" {
+		article.Title = news.Title
+	}
+
+	inserted, err := gac.store.SaveArticle(*article, gac.config.Run.ID())
+	gac.config.Run.RecordSave(inserted, err)
+	if err != nil {
+		return nil, fmt.Errorf("保存文章失败: %v", err)
+	}
+	return article, nil
+}
+
+// FetchArticle 获取文章的全部分页并合并为一篇文章，不保存
+// 标题、署名等信息取自第一页，后续分页只合并正文和图片
+func (gac *ArticleCrawler) FetchArticle(ctx context.Context, articleURL string) (*Article, error) {
+	article := &Article{
+		URL:        articleURL,
+		CreateTime: time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	seenPages := make(map[string]bool)
+	seenImages := make(map[string]bool)
+	for pageURL := articleURL; pageURL != "" && !seenPages[pageURL]; {
+		if len(seenPages) >= maxArticlePages {
+			log.Printf("文章 %s 超过 %d 页，停止翻页", articleURL, maxArticlePages)
+			break
+		}
+		if len(seenPages) > 0 {
+			if err := httpclient.Sleep(ctx, gac.config.RequestDelay); err != nil {
+				return nil, err
+			}
+		}
+		seenPages[pageURL] = true
+
+		html, err := fetchHTML(ctx, pageURL)
+		if err != nil {
+			return nil, fmt.Errorf("获取文章第 %d 页失败: %v", len(seenPages), err)
+		}
+		page, err := ParseArticlePage(pageURL, html)
+		if err != nil {
+			return nil, fmt.Errorf("解析文章第 %d 页失败 (%s): %v", len(seenPages), pageURL, err)
+		}
+
+		if article.Pages == 0 {
+			article.Title = page.Title
+			article.Author = page.Author
+			article.Editor = page.Editor
+			article.Source = page.Source
+			article.PublishTime = page.PublishTime
+			article.Tags = page.Tags
+		}
+		article.Pages++
+		article.Paragraphs = append(article.Paragraphs, page.Paragraphs...)
+		for _, image := range page.Images {
+			if !seenImages[image] {
+				seenImages[image] = true
+				article.Images = append(article.Images, image)
+			}
+		}
+
+		pageURL = page.NextURL
+	}
+
+	return article, nil
+}
+
+// fetchHTML 请求页面并返回HTML内容
+func fetchHTML(ctx context.Context, pageURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36")
+	req.Header.Set("Referer", "https://wap.gamersky.com/")
+
+	resp, err := httpclient.Default().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Close 关闭数据库连接
+func (gac *ArticleCrawler) Close() error {
+	if gac.store != nil {
+		return gac.store.Close()
+	}
+	return nil
+}
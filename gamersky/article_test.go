@@ -0,0 +1,138 @@
+package gamersky
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bili-comment/store"
+)
+
+// readSnapshot 读取 html-snapshot 目录下保存的页面
+func readSnapshot(t *testing.T, name string) []byte {
+	t.Helper()
+	html, err := os.ReadFile("../html-snapshot/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return html
+}
+
+func TestParseArticlePage(t *testing.T) {
+	pageURL := "https://wap.gamersky.com/news/Content-1905789.html"
+	page, err := ParseArticlePage(pageURL, readSnapshot(t, "wap-article-1905789.html"))
+	if err != nil {
+		t.Fatalf("解析文章失败: %v", err)
+	}
+
+	if page.Title != "《怪猎：荒野》中配要来了 预计五月底上线！" {
+		t.Errorf("标题 = %q", page.Title)
+	}
+	if page.PublishTime != "2025-04-01 17:05:00" {
+		t.Errorf("发布时间 = %q", page.PublishTime)
+	}
+	if page.Source != "游民星空" || page.Author != "星空君" || page.Editor != "鸡腿" {
+		t.Errorf("署名解析错误: 来源=%q 作者=%q 编辑=%q", page.Source, page.Author, page.Editor)
+	}
+	if want := []string{"怪物猎人：荒野", "卡普空"}; !reflect.DeepEqual(page.Tags, want) {
+		t.Errorf("标签 = %v, 期望 %v", page.Tags, want)
+	}
+
+	// 空段落、图片段落和广告脚本不计入正文，全角缩进被去掉
+	if len(page.Paragraphs) != 3 {
+		t.Fatalf("段落数 = %d, 期望 3: %q", len(page.Paragraphs), page.Paragraphs)
+	}
+	if !strings.HasPrefix(page.Paragraphs[1], "官方表示") || !strings.Contains(page.Paragraphs[1], "随从艾露猫") {
+		t.Errorf("段落内的行内标签未保留文字: %q", page.Paragraphs[1])
+	}
+	for _, p := range page.Paragraphs {
+		if strings.Contains(p, "gsAd") {
+			t.Errorf("正文包含广告脚本: %q", p)
+		}
+	}
+
+	// 懒加载图片使用 data-src，不使用占位图
+	wantImages := []string{
+		"https://imgs.gamersky.com/upimg/new_preview/2025/04/01/origin_202504011703261599.jpg",
+		"https://imgs.gamersky.com/upimg/new_preview/2025/04/01/origin_202504011703268833.jpg",
+	}
+	if !reflect.DeepEqual(page.Images, wantImages) {
+		t.Errorf("图片 = %v, 期望 %v", page.Images, wantImages)
+	}
+
+	if page.NextURL != "https://wap.gamersky.com/news/Content-1905789_2.html" {
+		t.Errorf("下一页 = %q", page.NextURL)
+	}
+}
+
+func TestParseArticleLastPage(t *testing.T) {
+	pageURL := "https://wap.gamersky.com/news/Content-1905789_2.html"
+	page, err := ParseArticlePage(pageURL, readSnapshot(t, "wap-article-1905789_2.html"))
+	if err != nil {
+		t.Fatalf("解析文章失败: %v", err)
+	}
+
+	if page.NextURL != "" {
+		t.Errorf("最后一页不应有下一页: %q", page.NextURL)
+	}
+	if len(page.Paragraphs) != 2 || len(page.Images) != 2 {
+		t.Errorf("段落 %d 个、图片 %d 张, 期望 2 和 2", len(page.Paragraphs), len(page.Images))
+	}
+	// 协议相对链接转换为页面的协议
+	if len(page.Images) == 2 && !strings.HasPrefix(page.Images[1], "https://imgs.gamersky.com/") {
+		t.Errorf("图片链接未转换为绝对地址: %s", page.Images[1])
+	}
+}
+
+func TestParseArticleLayoutDrift(t *testing.T) {
+	// 首页没有文章标题和正文
+	_, err := ParseArticlePage("https://wap.gamersky.com/", readSnapshot(t, "wap-gs.html"))
+	if !errors.Is(err, ErrArticleLayout) {
+		t.Errorf("期望 ErrArticleLayout, 得到 %v", err)
+	}
+}
+
+func TestCrawlArticleFollowsPages(t *testing.T) {
+	pages := map[string][]byte{
+		"/news/Content-1905789.html":   readSnapshot(t, "wap-article-1905789.html"),
+		"/news/Content-1905789_2.html": readSnapshot(t, "wap-article-1905789_2.html"),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		html, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(html)
+	}))
+	defer server.Close()
+
+	st := store.NewMemory()
+	crawler := NewArticleCrawlerWithStore(&Config{}, st)
+	news := NewsInfo{SID: "1905789", URL: server.URL + "/news/Content-1905789.html"}
+
+	article, err := crawler.CrawlArticle(context.Background(), news)
+	if err != nil {
+		t.Fatalf("获取文章失败: %v", err)
+	}
+
+	if article.SID != "1905789" || article.Pages != 2 {
+		t.Errorf("SID=%s 页数=%d, 期望 1905789 和 2", article.SID, article.Pages)
+	}
+	if len(article.Paragraphs) != 5 {
+		t.Errorf("段落数 = %d, 期望 5", len(article.Paragraphs))
+	}
+	// 第二页重复的图片只保留一次
+	if len(article.Images) != 3 {
+		t.Errorf("图片数 = %d, 期望 3: %v", len(article.Images), article.Images)
+	}
+
+	if saved, _ := st.HasArticle("1905789"); !saved {
+		t.Error("文章未保存")
+	}
+}
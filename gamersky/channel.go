@@ -0,0 +1,363 @@
+package gamersky
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bili-comment/httpclient"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ChannelIndex 手机版首页信息流，不指定频道时爬取
+const ChannelIndex = "index"
+
+// Channel Gamersky新闻频道
+// 第一页为频道列表页的HTML，后续页面通过列表接口按节点ID翻页，节点ID从列表页中获取
+type Channel struct {
+	Name    string // --channel 使用的名称，保存到 gamersky_news.channel
+	Label   string // 频道中文名称
+	ListURL string // 频道列表页链接
+}
+
+// channels 支持的频道，站点调整频道链接时只需修改这里
+var channels = []Channel{
+	{Name: "news", Label: "新闻", ListURL: "https://www.gamersky.com/news/"},
+	{Name: "review", Label: "评测", ListURL: "https://www.gamersky.com/review/"},
+	{Name: "handheld", Label: "掌机", ListURL: "https://www.gamersky.com/handheld/"},
+	{Name: "pc", Label: "单机", ListURL: "https://www.gamersky.com/news/pc/zx/"},
+	{Name: "hardware", Label: "硬件", ListURL: "https://hard.gamersky.com/news/"},
+	{Name: "esports", Label: "电竞", ListURL: "https://www.gamersky.com/esports/"},
+}
+
+// ChannelNames 获取所有可用的频道名称，用于命令行帮助
+func ChannelNames() string {
+	names := []string{ChannelIndex}
+	for _, ch := range channels {
+		names = append(names, ch.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// LookupChannel 按名称查找频道，名称为空或 index 时返回 false 表示爬取首页信息流
+func LookupChannel(name string) (Channel, bool, error) {
+	if name == "" || name == ChannelIndex {
+		return Channel{}, false, nil
+	}
+	for _, ch := range channels {
+		if ch.Name == name {
+			return ch, true, nil
+		}
+	}
+	return Channel{}, false, fmt.Errorf("未知的Gamersky频道 %s (可选 %s)", name, ChannelNames())
+}
+
+// ChannelLabel 获取频道的中文名称，用于日志
+func ChannelLabel(name string) string {
+	if ch, ok, err := LookupChannel(name); err == nil && ok {
+		return ch.Label
+	}
+	if name == "" || name == ChannelIndex {
+		return "首页信息流"
+	}
+	return name
+}
+
+// channelListAPI 频道列表翻页接口，jsondata 中的 nodeId 为列表页的节点ID
+var channelListAPI = "https://db2.gamersky.com/LabelJsonpAjax.aspx"
+
+// 列表页中的文章链接和节点ID
+var (
+	channelArticleRegex = regexp.MustCompile(`/(?:\d{6}/(\d+)\.shtml|Content-(\d+)(?:_\d+)?\.html)`)
+	channelNodeIDRegex  = regexp.MustCompile(`(?i)nodeId["']?\s*[:=]\s*["']?(\d+)`)
+	channelTimeRegex    = regexp.MustCompile(`\d{4}-\d{2}-\d{2}( \d{2}:\d{2}(:\d{2})?)?`)
+)
+
+// channelPaging 各频道的翻页状态，以频道名称为key，同一进程内的多次爬取共享
+var channelPaging = struct {
+	sync.Mutex
+	states map[string]*channelState
+}{states: make(map[string]*channelState)}
+
+// channelState 一个频道的翻页状态
+type channelState struct {
+	NodeID     string // 列表页的节点ID，翻页接口使用
+	TotalPages int    // 接口返回的总页数，0表示未知
+}
+
+// loadChannelState 获取频道的翻页状态
+func loadChannelState(name string) channelState {
+	channelPaging.Lock()
+	defer channelPaging.Unlock()
+
+	if state, ok := channelPaging.states[name]; ok {
+		return *state
+	}
+	return channelState{}
+}
+
+// updateChannelState 更新频道的翻页状态，空值不覆盖已有状态
+func updateChannelState(name, nodeID string, totalPages int) {
+	channelPaging.Lock()
+	defer channelPaging.Unlock()
+
+	state, ok := channelPaging.states[name]
+	if !ok {
+		state = &channelState{}
+		channelPaging.states[name] = state
+	}
+	if nodeID != "" {
+		state.NodeID = nodeID
+	}
+	if totalPages > 0 {
+		state.TotalPages = totalPages
+	}
+}
+
+// ChannelPage 频道列表一页的解析结果
+type ChannelPage struct {
+	News   []NewsInfo // 列表中的新闻，按出现顺序去重
+	NodeID string     // 列表的节点ID，翻页时使用；翻页接口返回的页面为空
+}
+
+// ParseChannelList 解析频道列表页或翻页接口返回的列表HTML，pageURL 用于将相对链接转换为绝对链接
+// 页面中有带 data-nodeid 的列表时只解析该列表，避免把侧栏推荐当作频道新闻
+func ParseChannelList(pageURL string, html []byte) (*ChannelPage, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("解析页面链接失败: %v", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("解析HTML失败: %v", err)
+	}
+
+	page := &ChannelPage{}
+	scope := doc.Selection
+	if list := doc.Find("[data-nodeid]").First(); list.Length() > 0 {
+		page.NodeID = list.AttrOr("data-nodeid", "")
+		scope = list
+	} else if match := channelNodeIDRegex.FindSubmatch(html); match != nil {
+		page.NodeID = string(match[1])
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	seen := make(map[string]bool)
+	scope.Find("li").Each(func(_ int, li *goquery.Selection) {
+		news := parseChannelItem(base, li)
+		if news == nil || seen[news.SID] {
+			return
+		}
+		seen[news.SID] = true
+		news.CreateTime = now
+		page.News = append(page.News, *news)
+	})
+
+	return page, nil
+}
+
+// parseChannelItem 解析列表中的一条新闻，不是文章的列表项返回nil
+func parseChannelItem(base *url.URL, li *goquery.Selection) *NewsInfo {
+	var link *goquery.Selection
+	var sid string
+	li.Find("a[href]").EachWithBreak(func(_ int, a *goquery.Selection) bool {
+		match := channelArticleRegex.FindStringSubmatch(a.AttrOr("href", ""))
+		if match == nil {
+			return true
+		}
+		link = a
+		sid = match[1] + match[2]
+		return false
+	})
+	if link == nil {
+		return nil
+	}
+
+	news := &NewsInfo{
+		SID: sid,
+		URL: resolveURL(base, link.AttrOr("href", "")),
+	}
+
+	// 标题：优先使用标题链接，其次是链接的 title 属性和文字
+	for _, title := range []string{
+		li.Find("a.tt, .tit a, h5, h3").First().Text(),
+		link.AttrOr("title", ""),
+		link.Text(),
+	} {
+		if title = cleanText(title); title != "" {
+			news.Title = title
+			break
+		}
+	}
+	if news.Title == "" {
+		return nil
+	}
+
+	for _, selector := range []string{".time", "time", ".txt"} {
+		if t := channelTimeRegex.FindString(cleanText(li.Find(selector).First().Text())); t != "" {
+			news.Time = t
+			break
+		}
+	}
+
+	// 评论数由页面脚本填充，翻页接口和部分列表页会直接输出
+	if text := cleanText(li.Find(".cy_comment, .pls, .commentNum").First().Text()); text != "" {
+		if commentNum, err := strconv.Atoi(text); err == nil {
+			news.CommentNum = commentNum
+		}
+	}
+
+	if img := li.Find("img").First(); img.Length() > 0 {
+		news.ImageURL = imageSource(base, img)
+	}
+	return news
+}
+
+// channelListResponse 频道列表翻页接口的响应
+type channelListResponse struct {
+	Status     string `json:"status"`
+	TotalPages int    `json:"totalPages"`
+	Body       string `json:"body"`
+}
+
+// crawlChannelPage 爬取频道列表的指定页面，返回保存的新闻数；超过总页数时返回0
+func (gnc *NewsCrawler) crawlChannelPage(ctx context.Context, ch Channel, page int) (int, error) {
+	var newsList []NewsInfo
+
+	state := loadChannelState(ch.Name)
+	if page > 1 && state.TotalPages > 0 && page > state.TotalPages {
+		log.Printf("频道 %s 共 %d 页，没有第 %d 页", ch.Label, state.TotalPages, page)
+		return 0, nil
+	}
+
+	if page == 1 || state.NodeID == "" {
+		// 第一页为列表页HTML，同时获取翻页使用的节点ID
+		first, err := fetchChannelList(ctx, ch)
+		if err != nil {
+			return 0, err
+		}
+		updateChannelState(ch.Name, first.NodeID, 0)
+		state = loadChannelState(ch.Name)
+		newsList = first.News
+	}
+
+	if page > 1 {
+		if state.NodeID == "" {
+			return 0, fmt.Errorf("频道 %s 的列表页中找不到节点ID，无法翻页", ch.Label)
+		}
+		result, totalPages, err := fetchChannelAPIPage(ctx, ch, state.NodeID, page)
+		if err != nil {
+			return 0, err
+		}
+		updateChannelState(ch.Name, "", totalPages)
+		newsList = result.News
+	}
+
+	count := 0
+	for i := range newsList {
+		news := &newsList[i]
+		news.Channel = ch.Name
+
+		// 保存新闻到数据库（已有新闻更新评论数等信息）
+		if err := gnc.saveNewsToDB(news); err != nil {
+			log.Printf("保存新闻失败 (SID: %s): %v", news.SID, err)
+			continue
+		}
+
+		count++
+		log.Printf("爬取%s频道新闻: %s - %s", ch.Label, news.SID, news.Title)
+	}
+
+	log.Printf("%s频道第 %d 页完成，共 %d 条新闻", ch.Label, page, len(newsList))
+	return count, nil
+}
+
+// fetchChannelList 获取并解析频道列表页
+func fetchChannelList(ctx context.Context, ch Channel) (*ChannelPage, error) {
+	html, err := fetchHTML(ctx, ch.ListURL)
+	if err != nil {
+		return nil, fmt.Errorf("获取%s频道列表失败: %v", ch.Label, err)
+	}
+	page, err := ParseChannelList(ch.ListURL, html)
+	if err != nil {
+		return nil, fmt.Errorf("解析%s频道列表失败: %v", ch.Label, err)
+	}
+	return page, nil
+}
+
+// fetchChannelAPIPage 通过列表接口获取频道的指定页面，返回解析结果和总页数
+func fetchChannelAPIPage(ctx context.Context, ch Channel, nodeID string, page int) (*ChannelPage, int, error) {
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"type":      "updatenodelabel",
+		"isCache":   true,
+		"cacheTime": 60,
+		"nodeId":    nodeID,
+		"isNodeId":  "true",
+		"page":      page,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("序列化请求数据失败: %v", err)
+	}
+
+	apiURL := channelListAPI + "?jsondata=" + url.QueryEscape(string(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36")
+	req.Header.Set("Referer", ch.ListURL)
+
+	resp, err := httpclient.Default().Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("%s频道列表接口返回 HTTP %d", ch.Label, resp.StatusCode)
+	}
+
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(resp.Body); err != nil {
+		return nil, 0, fmt.Errorf("读取响应失败: %v", err)
+	}
+
+	var response channelListResponse
+	if err := json.Unmarshal(unwrapJSONP(body.Bytes()), &response); err != nil {
+		return nil, 0, fmt.Errorf("解析JSON失败: %v", err)
+	}
+	if response.Status != "" && response.Status != "ok" {
+		return nil, 0, fmt.Errorf("%s频道列表接口错误: %s", ch.Label, response.Status)
+	}
+
+	result, err := ParseChannelList(ch.ListURL, []byte("<ul>"+response.Body+"</ul>"))
+	if err != nil {
+		return nil, 0, err
+	}
+	return result, response.TotalPages, nil
+}
+
+// unwrapJSONP 去掉JSONP响应的回调函数包裹，普通JSON原样返回
+func unwrapJSONP(body []byte) []byte {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] == '{' {
+		return body
+	}
+	start := bytes.IndexByte(body, '(')
+	end := bytes.LastIndexByte(body, ')')
+	if start < 0 || end <= start {
+		return body
+	}
+	return body[start+1 : end]
+}
@@ -0,0 +1,98 @@
+package gamersky
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bili-comment/store"
+)
+
+func TestParseChannelList(t *testing.T) {
+	page, err := ParseChannelList("https://www.gamersky.com/news/", readSnapshot(t, "channel-news.html"))
+	if err != nil {
+		t.Fatalf("解析频道列表失败: %v", err)
+	}
+
+	if page.NodeID != "11007" {
+		t.Errorf("节点ID = %q, 期望 11007", page.NodeID)
+	}
+	// 重复的新闻、专题链接和侧栏推荐不计入列表
+	if len(page.News) != 2 {
+		t.Fatalf("新闻数 = %d, 期望 2: %+v", len(page.News), page.News)
+	}
+
+	first := page.News[0]
+	if first.SID != "1905789" || first.Title != "《怪猎：荒野》中配要来了 预计五月底上线！" {
+		t.Errorf("第一条新闻 = %s %q", first.SID, first.Title)
+	}
+	if first.Time != "2025-04-01 17:05" || first.CommentNum != 128 {
+		t.Errorf("时间 %q、评论数 %d, 期望 2025-04-01 17:05 和 128", first.Time, first.CommentNum)
+	}
+
+	// 协议相对链接转换为绝对链接，懒加载图片使用 data-src
+	second := page.News[1]
+	if second.URL != "https://www.gamersky.com/news/202504/1905790.shtml" {
+		t.Errorf("链接 = %s", second.URL)
+	}
+	if second.ImageURL != "https://imgs.gamersky.com/upimg/new_preview/2025/04/01/1905790.jpg" {
+		t.Errorf("图片 = %s", second.ImageURL)
+	}
+}
+
+func TestCrawlChannelPages(t *testing.T) {
+	listHTML := readSnapshot(t, "channel-news.html")
+	var nodeIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/news/":
+			w.Write(listHTML)
+		case "/LabelJsonpAjax.aspx":
+			jsondata := r.URL.Query().Get("jsondata")
+			nodeIDs = append(nodeIDs, jsondata)
+			body := `<li><a class=\"tt\" href=\"https://www.gamersky.com/news/202503/1900001.shtml\">第二页新闻</a><div class=\"time\">2025-03-31 10:00</div></li>`
+			fmt.Fprintf(w, `jQuery123({"status":"ok","totalPages":2,"body":"%s"})`, body)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(api string) { channelListAPI = api }(channelListAPI)
+	channelListAPI = server.URL + "/LabelJsonpAjax.aspx"
+
+	ch := Channel{Name: "test-news", Label: "测试", ListURL: server.URL + "/news/"}
+	st := store.NewMemory()
+	crawler := NewNewsCrawlerWithStore(&Config{}, st)
+
+	// 直接爬取第2页时先从列表页获取节点ID
+	// 第3页超过接口返回的总页数，不再请求
+	for _, tc := range []struct{ page, want int }{{2, 1}, {3, 0}} {
+		count, err := crawler.crawlChannelPage(context.Background(), ch, tc.page)
+		if err != nil {
+			t.Fatalf("爬取第 %d 页失败: %v", tc.page, err)
+		}
+		if count != tc.want {
+			t.Errorf("第 %d 页保存 %d 条, 期望 %d", tc.page, count, tc.want)
+		}
+	}
+	if len(nodeIDs) != 1 || !strings.Contains(nodeIDs[0], `"nodeId":"11007"`) || !strings.Contains(nodeIDs[0], `"page":2`) {
+		t.Errorf("翻页请求 = %v, 期望只请求节点 11007 的第2页", nodeIDs)
+	}
+
+	if _, err := crawler.crawlChannelPage(context.Background(), ch, 1); err != nil {
+		t.Fatalf("爬取第1页失败: %v", err)
+	}
+	news, _ := st.QueryNews(0, 0)
+	if len(news) != 3 {
+		t.Fatalf("保存的新闻数 = %d, 期望 3", len(news))
+	}
+	for _, item := range news {
+		if item.Channel != "test-news" {
+			t.Errorf("新闻 %s 的频道 = %q", item.SID, item.Channel)
+		}
+	}
+}
@@ -1,14 +1,19 @@
 package gamersky
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
+	"sort"
 	"time"
+
+	"bili-comment/httpclient"
+	"bili-comment/store"
 )
 
 // CommentAPIRequest 评论API请求结构体
@@ -29,349 +34,542 @@ type CommentAPIResponse struct {
 		CommentsCount int `json:"commentsCount"`
 		IsUpdateImage int `json:"isUpdateImage"`
 		Comments      []struct {
-			CommentID          int64         `json:"comment_id"`
-			CreateTime         int64         `json:"create_time"`
-			LastJoinTime       int64         `json:"last_join_time"`
-			IsTuijian          bool          `json:"is_tuijian"`
-			IsAuthor           bool          `json:"is_author"`
-			IsBest             bool          `json:"is_best"`
-			BeAuthorPraise     bool          `json:"beAuthorPraise"`
-			From               int           `json:"from"`
-			Content            string        `json:"content"`
-			SupportCount       int           `json:"support_count"`
-			IPLocation         string        `json:"ip_location"`
-			UserID             int           `json:"user_id"`
-			Nickname           string        `json:"nickname"`
-			ImgURL             string        `json:"img_url"`
-			DeviceName         string        `json:"deviceName"`
-			UserLevel          int           `json:"userLevel"`
-			UserAuthentication string        `json:"userAuthentication"`
-			UserGroupID        int           `json:"userGroupId"`
-			FloorNumber        int           `json:"floorNumber"`
-			ImageInfes         []interface{} `json:"imageInfes"`
-			ThirdPlatformBound string        `json:"thirdPlatformBound"`
-			Comments           interface{}   `json:"comments"`
-			Replies            []struct {
-				RootID                   int64  `json:"rootId"`
-				ReplyID                  int64  `json:"replyId"`
-				CreateTime               int64  `json:"createTime"`
-				ReplyContent             string `json:"replyContent"`
-				PraisesCount             int    `json:"praisesCount"`
-				UserID                   int    `json:"userId"`
-				UserName                 string `json:"userName"`
-				UserHeadImageURL         string `json:"userHeadImageURL"`
-				DeviceName               string `json:"deviceName"`
-				UserLevel                int    `json:"userLevel"`
-				UserAuthentication       string `json:"userAuthentication"`
-				UserGroupID              int    `json:"userGroupId"`
-				ThirdPlatformBound       string `json:"thirdPlatformBound"`
-				ObjectCommentID          int64  `json:"objectCommentId"`
-				ObjectUserID             int    `json:"objectUserId"`
-				ObjectUserName           string `json:"objectUserName"`
-				ObjectUserHeadImageURL   string `json:"objectUserHeadImageURL"`
-				ObjectUserGroupID        int    `json:"objectUserGroupId"`
-				ObjectUserAuthentication string `json:"objectUserAuthentication"`
-				ObjectUserLevel          int    `json:"objectUserLevel"`
-				IsAuthor                 bool   `json:"is_author"`
-				IPLocation               string `json:"ip_location"`
-				BeAuthorPraise           bool   `json:"beAuthorPraise"`
-			} `json:"replies"`
-			RepliesCount int `json:"repliesCount"`
+			CommentID          int64       `json:"comment_id"`
+			CreateTime         int64       `json:"create_time"`
+			LastJoinTime       int64       `json:"last_join_time"`
+			IsTuijian          bool        `json:"is_tuijian"`
+			IsAuthor           bool        `json:"is_author"`
+			IsBest             bool        `json:"is_best"`
+			BeAuthorPraise     bool        `json:"beAuthorPraise"`
+			From               int         `json:"from"`
+			Content            string      `json:"content"`
+			SupportCount       int         `json:"support_count"`
+			IPLocation         string      `json:"ip_location"`
+			UserID             int         `json:"user_id"`
+			Nickname           string      `json:"nickname"`
+			ImgURL             string      `json:"img_url"`
+			DeviceName         string      `json:"deviceName"`
+			UserLevel          int         `json:"userLevel"`
+			UserAuthentication string      `json:"userAuthentication"`
+			UserGroupID        int         `json:"userGroupId"`
+			FloorNumber        int         `json:"floorNumber"`
+			ImageInfes         []APIImage  `json:"imageInfes"`
+			ThirdPlatformBound string      `json:"thirdPlatformBound"`
+			Comments           interface{} `json:"comments"`
+			Replies            []APIReply  `json:"replies"`
+			RepliesCount       int         `json:"repliesCount"`
 		} `json:"comments"`
 	} `json:"result"`
 }
 
+// APIReply 评论接口和回复接口返回的回复
+type APIReply struct {
+	RootID                   int64  `json:"rootId"`
+	ReplyID                  int64  `json:"replyId"`
+	CreateTime               int64  `json:"createTime"`
+	ReplyContent             string `json:"replyContent"`
+	PraisesCount             int    `json:"praisesCount"`
+	UserID                   int    `json:"userId"`
+	UserName                 string `json:"userName"`
+	UserHeadImageURL         string `json:"userHeadImageURL"`
+	DeviceName               string `json:"deviceName"`
+	UserLevel                int    `json:"userLevel"`
+	UserAuthentication       string `json:"userAuthentication"`
+	UserGroupID              int    `json:"userGroupId"`
+	ThirdPlatformBound       string `json:"thirdPlatformBound"`
+	ObjectCommentID          int64  `json:"objectCommentId"`
+	ObjectUserID             int    `json:"objectUserId"`
+	ObjectUserName           string `json:"objectUserName"`
+	ObjectUserHeadImageURL   string `json:"objectUserHeadImageURL"`
+	ObjectUserGroupID        int    `json:"objectUserGroupId"`
+	ObjectUserAuthentication string `json:"objectUserAuthentication"`
+	ObjectUserLevel          int    `json:"objectUserLevel"`
+	IsAuthor                 bool   `json:"is_author"`
+	IPLocation               string `json:"ip_location"`
+	BeAuthorPraise           bool   `json:"beAuthorPraise"`
+}
+
 // CommentCrawler Gamersky评论爬虫结构体
 type CommentCrawler struct {
-	db     *sql.DB
+	store  store.CommentStore
 	config *Config
+	images *ImageCache // 评论图片缓存，为空时不下载图片
+
+	requests int // 已请求评论接口和回复接口的次数
 }
 
-// NewCommentCrawler 创建新的Gamersky评论爬虫实例
+// NewCommentCrawler 创建新的Gamersky评论爬虫实例，存储由配置中的DSN决定
 func NewCommentCrawler(config *Config) (*CommentCrawler, error) {
-	// 初始化数据库
-	db, err := GetDBConnection(config.OutputPath)
+	// 初始化存储
+	st, err := config.openStore()
 	if err != nil {
 		return nil, fmt.Errorf("数据库连接失败: %v", err)
 	}
 
-	return &CommentCrawler{
-		db:     db,
+	return NewCommentCrawlerWithStore(config, st), nil
+}
+
+// NewCommentCrawlerWithStore 使用指定存储创建Gamersky评论爬虫实例
+func NewCommentCrawlerWithStore(config *Config, st store.CommentStore) *CommentCrawler {
+	crawler := &CommentCrawler{
+		store:  st,
 		config: config,
-	}, nil
+	}
+	if config.ImageDir != "" {
+		crawler.images = NewImageCache(config.ImageDir)
+	}
+	return crawler
+}
+
+// commentAPIURL 文章评论接口地址
+var commentAPIURL = "https://cm.gamersky.com/appapi/GetArticleCommentWithClubStyle"
+
+// commentPageSize 评论接口每页的一级评论数
+const commentPageSize = 20
+
+// repliesMaxCount 评论接口中每条评论最多附带的回复数
+const repliesMaxCount = 10
+
+// pageRetryRounds 失败页面最多重试的轮数
+const pageRetryRounds = 3
+
+// CrawlReport 一篇文章评论爬取的完整性报告
+type CrawlReport struct {
+	ArticleID   string // 文章ID
+	Reported    int    // 接口报告的一级评论数 (commentsCount)
+	TotalPages  int    // 按评论数计算的总页数
+	Crawled     int    // 成功爬取的页数
+	Fetched     int    // 本次获取到的一级评论数（去重）
+	Stored      int    // 存储中该文章的一级评论数，由调用方用 CountStored 统计
+	Saved       int    // 本次保存的评论和回复数
+	Images      int    // 本次保存的评论图片数
+	Requests    int    // 本次请求评论接口和回复接口的次数，包括重试和回复翻页
+	FailedPages []int  // 重试后仍然失败的页码
+	Order       string // 评论排序方式
+	MinPraises  int    // 最低点赞数，大于0时接口只返回部分评论
+
+	StoppedEarly bool // 按最新排序翻到时间范围之前的评论后提前停止翻页
+
+	IncompleteThreads []int64 // 未能获取全部回复的一级评论ID
 }
 
-// CrawlComments 爬取指定文章的评论
-func (gcc *CommentCrawler) CrawlComments(articleID string, maxPages int) (int, error) {
-	totalCount := 0
+// Complete 判断是否已获取接口报告的全部一级评论
+// 设置了最低点赞数时接口只返回部分评论，不与评论总数比较
+func (r *CrawlReport) Complete() bool {
+	if len(r.FailedPages) > 0 || len(r.IncompleteThreads) > 0 {
+		return false
+	}
+	return r.MinPraises > 0 || r.Fetched >= r.Reported
+}
+
+// CrawlComments 爬取指定文章的评论，maxPages<=0 时爬取全部页面，返回保存的评论数
+func (gcc *CommentCrawler) CrawlComments(ctx context.Context, articleID string, maxPages int) (int, error) {
+	report, err := gcc.CrawlCommentsWithReport(ctx, articleID, maxPages)
+	return report.Saved, err
+}
+
+// CrawlCommentsWithReport 爬取指定文章的评论并返回完整性报告
+// 总页数由第一页返回的 commentsCount 计算，maxPages>0 时最多爬取 maxPages 页。
+// 失败的页面加入重试队列，在其余页面完成后按递增的间隔重试；第一页决定总页数，失败时直接重试。
+// ctx取消时在当前页完成后停止（不再获取其余回复，但保存本页剩余的评论），并返回已爬取的结果和ctx的错误
+// 设置了时间范围时只保存范围内的评论，回复按各自的时间过滤；推荐和最热排序不按时间排列，不会提前停止翻页，
+// 最新排序下一页中最早的评论已早于 Since 时停止翻页
+func (gcc *CommentCrawler) CrawlCommentsWithReport(ctx context.Context, articleID string, maxPages int) (*CrawlReport, error) {
+	report := &CrawlReport{ArticleID: articleID, MinPraises: gcc.config.MinPraises}
+	fetched := make(map[int64]bool)
+	defer func(start int) { report.Requests = gcc.requests - start }(gcc.requests)
+
+	order, err := LookupCommentOrder(gcc.config.CommentOrder)
+	if err != nil {
+		return report, err
+	}
+	report.Order = order.Name
+
+	// 记录一页的结果
+	record := func(page int, result *commentsPage) {
+		report.Crawled++
+		report.Saved += result.saved
+		report.Images += result.images
+		report.IncompleteThreads = append(report.IncompleteThreads, result.incompleteThreads...)
+		for _, id := range result.ids {
+			fetched[id] = true
+		}
+		log.Printf("第 %d 页爬取完成，新增 %d 条评论", page, result.saved)
+	}
+
+	// 第一页：获取评论总数
+	first, err := gcc.crawlFirstCommentsPage(ctx, articleID, order)
+	if first != nil {
+		record(1, first)
+	}
+	if err != nil {
+		return gcc.finishReport(report, fetched, nil), err
+	}
+
+	report.Reported = first.commentsCount
+	report.TotalPages = (first.commentsCount + commentPageSize - 1) / commentPageSize
+	lastPage := report.TotalPages
+
+	// 接口没有返回评论总数时，翻页到不足一页为止
+	countKnown := report.Reported >= len(first.ids)
+	switch {
+	case countKnown:
+		log.Printf("文章 %s 共 %d 条评论，%d 页", articleID, report.Reported, report.TotalPages)
+	case len(first.ids) < commentPageSize:
+		lastPage = 1
+	default:
+		log.Printf("接口报告的评论数 %d 少于第一页的评论数，改为翻页到最后一页", report.Reported)
+		lastPage = math.MaxInt32
+	}
+	if maxPages > 0 && maxPages < lastPage {
+		lastPage = maxPages
+	}
+	if lastPage > 1 && gcc.beforeTimeRange(order, first) {
+		log.Printf("第 1 页的评论已早于时间范围，停止爬取")
+		report.StoppedEarly = true
+		lastPage = 1
+	}
+
+	var retryQueue []int
+	for page := 2; page <= lastPage; page++ {
+		if err := httpclient.Sleep(ctx, gcc.config.RequestDelay); err != nil {
+			return gcc.finishReport(report, fetched, retryQueue), err
+		}
 
-	for page := 1; page <= maxPages; page++ {
 		log.Printf("正在爬取文章 %s 第 %d 页评论...", articleID, page)
 
-		count, hasMore, err := gcc.crawlCommentsPage(articleID, page)
+		result, err := gcc.crawlCommentsPage(ctx, articleID, page, order)
+		if ctx.Err() != nil {
+			// 已获取的页面完成保存后再退出，请求未完成的页面记为失败
+			if result != nil {
+				record(page, result)
+				return gcc.finishReport(report, fetched, retryQueue), ctx.Err()
+			}
+			return gcc.finishReport(report, fetched, append(retryQueue, page)), ctx.Err()
+		}
 		if err != nil {
-			log.Printf("爬取第 %d 页评论失败: %v", page, err)
+			gcc.config.Run.RecordError(err)
+			log.Printf("爬取第 %d 页评论失败，加入重试队列: %v", page, err)
+			retryQueue = append(retryQueue, page)
 			continue
 		}
+		record(page, result)
 
-		totalCount += count
-		log.Printf("第 %d 页爬取完成，新增 %d 条评论", page, count)
-
-		// 如果没有更多评论了，停止爬取
-		if !hasMore || count == 0 {
+		// 评论在爬取期间被删除时实际页数会少于计算的页数
+		if len(result.ids) == 0 || (!countKnown && len(result.ids) < commentPageSize) {
 			log.Printf("第 %d 页没有更多评论，停止爬取", page)
 			break
 		}
-
-		// 延迟
-		if page < maxPages {
-			time.Sleep(gcc.config.RequestDelay)
+		if gcc.beforeTimeRange(order, result) {
+			log.Printf("第 %d 页的评论已早于时间范围，停止爬取", page)
+			report.StoppedEarly = true
+			break
 		}
 	}
 
-	return totalCount, nil
-}
+	// 重试失败的页面，每轮间隔递增
+	for round := 1; round <= pageRetryRounds && len(retryQueue) > 0; round++ {
+		var failed []int
+		for i, page := range retryQueue {
+			// 取消时本轮已失败的页面和尚未重试完成的页面都记为失败
+			if err := httpclient.Sleep(ctx, gcc.config.RequestDelay*time.Duration(1<<round)); err != nil {
+				return gcc.finishReport(report, fetched, append(failed, retryQueue[i:]...)), err
+			}
 
-// crawlCommentsPage 爬取指定页面的评论
-func (gcc *CommentCrawler) crawlCommentsPage(articleID string, pageIndex int) (int, bool, error) {
-	// 构造API请求
-	requestData := CommentAPIRequest{
-		ArticleID:       articleID,
-		MinPraisesCount: 0,
-		RepliesMaxCount: 10,
-		PageIndex:       pageIndex,
-		PageSize:        20,
-		Order:           "tuiJian",
-	}
+			log.Printf("第 %d 轮重试文章 %s 第 %d 页评论...", round, articleID, page)
 
-	// 序列化请求数据为JSON
-	jsonData, err := json.Marshal(requestData)
-	if err != nil {
-		return 0, false, fmt.Errorf("序列化请求数据失败: %v", err)
+			result, err := gcc.crawlCommentsPage(ctx, articleID, page, order)
+			if ctx.Err() != nil {
+				remaining := retryQueue[i:]
+				if result != nil {
+					record(page, result)
+					remaining = retryQueue[i+1:]
+				}
+				return gcc.finishReport(report, fetched, append(failed, remaining...)), ctx.Err()
+			}
+			if err != nil {
+				gcc.config.Run.RecordError(err)
+				log.Printf("重试第 %d 页评论失败: %v", page, err)
+				failed = append(failed, page)
+				continue
+			}
+			record(page, result)
+		}
+		retryQueue = failed
 	}
 
-	// URL编码JSON数据
-	encodedRequest := url.QueryEscape(string(jsonData))
-
-	// 构造完整的API URL
-	apiURL := fmt.Sprintf("https://cm.gamersky.com/appapi/GetArticleCommentWithClubStyle?request=%s", encodedRequest)
+	return gcc.finishReport(report, fetched, retryQueue), nil
+}
 
-	// 创建HTTP客户端
-	client := &http.Client{Timeout: 30 * time.Second}
+// crawlFirstCommentsPage 爬取第一页评论，失败时按递增的间隔重试
+func (gcc *CommentCrawler) crawlFirstCommentsPage(ctx context.Context, articleID string, order CommentOrder) (*commentsPage, error) {
+	log.Printf("正在爬取文章 %s 第 1 页评论（%s排序）...", articleID, order.Label)
 
-	// 创建请求
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return 0, false, fmt.Errorf("创建请求失败: %v", err)
-	}
+	for round := 0; ; round++ {
+		result, err := gcc.crawlCommentsPage(ctx, articleID, 1, order)
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		if err == nil {
+			return result, nil
+		}
 
-	// 设置请求头
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36")
-	req.Header.Set("Referer", "https://www.gamersky.com/")
+		gcc.config.Run.RecordError(err)
+		if round == pageRetryRounds {
+			return nil, fmt.Errorf("爬取第 1 页评论失败: %v", err)
+		}
+		log.Printf("爬取第 1 页评论失败，稍后重试: %v", err)
 
-	// 发送请求
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0, false, fmt.Errorf("发送请求失败: %v", err)
+		if err := httpclient.Sleep(ctx, gcc.config.RequestDelay*time.Duration(2<<round)); err != nil {
+			return nil, err
+		}
 	}
-	defer resp.Body.Close()
+}
 
-	// 读取响应
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, false, fmt.Errorf("读取响应失败: %v", err)
+// beforeTimeRange 判断最新排序下该页最早的评论是否已早于 Since，之后的页面不会再有范围内的评论
+func (gcc *CommentCrawler) beforeTimeRange(order CommentOrder, result *commentsPage) bool {
+	if order.Name != OrderLatest || gcc.config.Since.IsZero() || result.oldest.IsZero() {
+		return false
 	}
+	return result.oldest.Before(gcc.config.Since)
+}
 
-	log.Printf("评论API响应状态: %d, 大小: %d bytes", resp.StatusCode, len(body))
+// finishReport 填写报告中的汇总数据
+func (gcc *CommentCrawler) finishReport(report *CrawlReport, fetched map[int64]bool, failedPages []int) *CrawlReport {
+	report.Fetched = len(fetched)
+	report.FailedPages = failedPages
+	sort.Ints(report.FailedPages)
+	return report
+}
+
+// commentsPage 一页评论的爬取结果
+type commentsPage struct {
+	saved             int       // 保存的评论和回复数
+	images            int       // 保存的评论图片数
+	ids               []int64   // 该页的一级评论ID（含时间范围外的评论）
+	commentsCount     int       // 接口报告的一级评论总数
+	oldest            time.Time // 该页最早的一级评论时间
+	incompleteThreads []int64   // 未能获取全部回复的一级评论ID
+}
+
+// crawlCommentsPage 按指定排序方式爬取指定页面的评论，并记录一级评论在该排序下的名次
+// 获取回复期间ctx被取消时跳过其余回复，仍保存本页的评论，同时返回结果和ctx的错误
+func (gcc *CommentCrawler) crawlCommentsPage(ctx context.Context, articleID string, pageIndex int, order CommentOrder) (*commentsPage, error) {
+	// 构造API请求
+	requestData := CommentAPIRequest{
+		ArticleID:       articleID,
+		MinPraisesCount: gcc.config.MinPraises,
+		RepliesMaxCount: repliesMaxCount,
+		PageIndex:       pageIndex,
+		PageSize:        commentPageSize,
+		Order:           order.API,
+	}
 
-	// 解析JSON响应
 	var apiResponse CommentAPIResponse
-	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		return 0, false, fmt.Errorf("解析JSON失败: %v, 响应内容: %s", err, string(body[:min(200, len(body))]))
+	if err := gcc.callAPI(ctx, commentAPIURL, requestData, &apiResponse); err != nil {
+		return nil, err
 	}
 
 	// 检查API错误
 	if apiResponse.ErrorCode != 0 {
-		return 0, false, fmt.Errorf("API错误: %s (代码: %d)", apiResponse.ErrorMessage, apiResponse.ErrorCode)
+		return nil, fmt.Errorf("API错误: %s (代码: %d)", apiResponse.ErrorMessage, apiResponse.ErrorCode)
 	}
 
 	// 处理评论数据
+	result := &commentsPage{commentsCount: apiResponse.Result.CommentsCount}
 	count := 0
-	for _, comment := range apiResponse.Result.Comments {
-		// 保存一级评论
-		gamerskyComment := &Comment{
-			ID:                 comment.CommentID,
-			ArticleID:          articleID,
-			UserID:             comment.UserID,
-			Username:           comment.Nickname,
-			Content:            comment.Content,
-			CommentTime:        time.Unix(comment.CreateTime/1000, 0).In(time.FixedZone("CST", 8*3600)).Format("2006-01-02 15:04:05"),
-			SupportCount:       comment.SupportCount,
-			ReplyCount:         comment.RepliesCount,
-			ParentID:           0,  // 一级评论父ID为0
-			AnswerToID:         0,  // 一级评论不回复任何人
-			AnswerToName:       "", // 一级评论不回复任何人
-			UserAvatar:         comment.ImgURL,
-			UserLevel:          comment.UserLevel,
-			IPLocation:         comment.IPLocation,
-			DeviceName:         comment.DeviceName,
-			FloorNumber:        comment.FloorNumber,
-			IsTuijian:          comment.IsTuijian,
-			IsAuthor:           comment.IsAuthor,
-			IsBest:             comment.IsBest,
-			UserAuthentication: comment.UserAuthentication,
-			UserGroupID:        comment.UserGroupID,
-			ThirdPlatformBound: comment.ThirdPlatformBound,
-			CreateTime:         time.Unix(comment.CreateTime/1000, 0).In(time.FixedZone("CST", 8*3600)).Format("2006-01-02 15:04:05"),
+	seenAt := time.Now().Format("2006-01-02 15:04:05")
+	for i, comment := range apiResponse.Result.Comments {
+		result.ids = append(result.ids, comment.CommentID)
+		createTime := time.UnixMilli(comment.CreateTime)
+		if result.oldest.IsZero() || createTime.Before(result.oldest) {
+			result.oldest = createTime
 		}
 
-		if err := gcc.saveCommentToDB(gamerskyComment); err != nil {
-			log.Printf("保存评论失败 (ID: %d): %v", comment.CommentID, err)
-		} else {
-			count++
-			log.Printf("保存评论: %d - %s", comment.CommentID, comment.Nickname)
+		// 回复总是晚于一级评论，一级评论晚于结束时间时其回复也都在范围外
+		if !gcc.config.Until.IsZero() && !createTime.Before(gcc.config.Until) {
+			continue
 		}
 
-		// 处理回复（二级评论）
-		for _, reply := range comment.Replies {
-			// 跳过无效的回复数据
-			if reply.ReplyID == 0 || reply.UserName == "" {
-				log.Printf("跳过无效回复数据: ID=%d, UserName=%s", reply.ReplyID, reply.UserName)
-				continue
-			}
-
-			replyComment := &Comment{
-				ID:                 reply.ReplyID,
+		// 一级评论在时间范围内时才保存，其回复按各自的时间单独过滤
+		if gcc.config.inTimeRange(createTime) {
+			// 保存一级评论
+			gamerskyComment := &Comment{
+				ID:                 comment.CommentID,
 				ArticleID:          articleID,
-				UserID:             reply.UserID,
-				Username:           reply.UserName,
-				Content:            reply.ReplyContent,
-				CommentTime:        time.Unix(reply.CreateTime/1000, 0).In(time.FixedZone("CST", 8*3600)).Format("2006-01-02 15:04:05"),
-				SupportCount:       reply.PraisesCount,
-				ReplyCount:         0,                     // 二级评论通常没有回复数
-				ParentID:           comment.CommentID,     // 父评论ID
-				AnswerToID:         reply.ObjectCommentID, // 被回复的评论ID
-				AnswerToName:       reply.ObjectUserName,  // 被回复用户名
-				UserAvatar:         reply.UserHeadImageURL,
-				UserLevel:          reply.UserLevel,
-				IPLocation:         reply.IPLocation,
-				DeviceName:         reply.DeviceName,
-				FloorNumber:        0,     // 回复通常没有楼层号
-				IsTuijian:          false, // 回复结构中没有此字段
-				IsAuthor:           reply.IsAuthor,
-				IsBest:             false, // 回复结构中没有此字段
-				UserAuthentication: reply.UserAuthentication,
-				UserGroupID:        reply.UserGroupID,
-				ThirdPlatformBound: reply.ThirdPlatformBound,
-				CreateTime:         time.Unix(reply.CreateTime/1000, 0).In(time.FixedZone("CST", 8*3600)).Format("2006-01-02 15:04:05"),
+				UserID:             comment.UserID,
+				Username:           comment.Nickname,
+				Content:            comment.Content,
+				CommentTime:        time.Unix(comment.CreateTime/1000, 0).In(time.FixedZone("CST", 8*3600)).Format("2006-01-02 15:04:05"),
+				SupportCount:       comment.SupportCount,
+				ReplyCount:         comment.RepliesCount,
+				ParentID:           0,  // 一级评论父ID为0
+				AnswerToID:         0,  // 一级评论不回复任何人
+				AnswerToName:       "", // 一级评论不回复任何人
+				UserAvatar:         comment.ImgURL,
+				UserLevel:          comment.UserLevel,
+				IPLocation:         comment.IPLocation,
+				DeviceName:         comment.DeviceName,
+				FloorNumber:        comment.FloorNumber,
+				IsTuijian:          comment.IsTuijian,
+				IsAuthor:           comment.IsAuthor,
+				IsBest:             comment.IsBest,
+				UserAuthentication: comment.UserAuthentication,
+				UserGroupID:        comment.UserGroupID,
+				ThirdPlatformBound: comment.ThirdPlatformBound,
+				CreateTime:         time.Unix(comment.CreateTime/1000, 0).In(time.FixedZone("CST", 8*3600)).Format("2006-01-02 15:04:05"),
 			}
 
-			if err := gcc.saveCommentToDB(replyComment); err != nil {
-				log.Printf("保存回复失败 (ID: %d): %v", reply.ReplyID, err)
+			if err := gcc.saveCommentToDB(gamerskyComment); err != nil {
+				log.Printf("保存评论失败 (ID: %d): %v", comment.CommentID, err)
 			} else {
 				count++
-				log.Printf("保存回复: %d - %s", reply.ReplyID, reply.UserName)
+				log.Printf("保存评论: %d - %s", comment.CommentID, comment.Nickname)
 			}
+			result.images += gcc.saveImages(ctx, articleID, comment.CommentID, comment.ImageInfes)
+			gcc.saveCommentRank(CommentRank{
+				CommentID: comment.CommentID,
+				ArticleID: articleID,
+				OrderMode: order.Name,
+				Rank:      (pageIndex-1)*commentPageSize + i + 1,
+				SeenAt:    seenAt,
+			})
 		}
-	}
 
-	// 判断是否还有更多页面（简单判断：如果返回的评论数量等于请求的pageSize，则可能还有更多）
-	hasMore := len(apiResponse.Result.Comments) >= 20 // pageSize为20
+		// 处理回复（二级评论）
+		seen := make(map[int64]bool, len(comment.Replies))
+		for _, reply := range comment.Replies {
+			seen[reply.ReplyID] = true
+			if gcc.saveReply(articleID, comment.CommentID, reply) {
+				count++
+			}
+		}
+
+		// 评论内只返回前 repliesMaxCount 条回复，其余的通过回复接口翻页获取
+		// 已取消时不再获取其余回复，但继续保存本页剩余的评论
+		if comment.RepliesCount > len(comment.Replies) {
+			if ctx.Err() != nil {
+				result.incompleteThreads = append(result.incompleteThreads, comment.CommentID)
+				continue
+			}
+			saved, err := gcc.crawlReplies(ctx, articleID, comment.CommentID, comment.RepliesCount, seen)
+			count += saved
+			if err != nil {
+				if ctx.Err() == nil {
+					gcc.config.Run.RecordError(err)
+					log.Printf("获取评论 %d 的全部回复失败: %v", comment.CommentID, err)
+				}
+				result.incompleteThreads = append(result.incompleteThreads, comment.CommentID)
+			}
+		}
+	}
 
 	log.Printf("文章 %s 第 %d 页完成，共 %d 条评论",
 		articleID, pageIndex, len(apiResponse.Result.Comments))
 
-	return count, hasMore, nil
+	result.saved = count
+	return result, ctx.Err()
+}
+
+// callAPI 请求评论接口或回复接口并计数
+func (gcc *CommentCrawler) callAPI(ctx context.Context, apiURL string, request, response interface{}) error {
+	gcc.requests++
+	return callCommentAPI(ctx, apiURL, request, response)
+}
+
+// callCommentAPI 以URL编码的JSON作为 request 参数请求评论接口，并解析响应
+func callCommentAPI(ctx context.Context, apiURL string, request, response interface{}) error {
+	// 序列化请求数据为JSON
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("序列化请求数据失败: %v", err)
+	}
+
+	// 构造完整的API URL
+	fullURL := fmt.Sprintf("%s?request=%s", apiURL, url.QueryEscape(string(jsonData)))
+
+	// 创建请求
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+
+	// 设置请求头
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36")
+	req.Header.Set("Referer", "https://www.gamersky.com/")
+
+	// 发送请求
+	resp, err := httpclient.Default().Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 读取响应
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %v", err)
+	}
+
+	log.Printf("评论API响应状态: %d, 大小: %d bytes", resp.StatusCode, len(body))
+
+	// 解析JSON响应
+	if err := json.Unmarshal(body, response); err != nil {
+		return fmt.Errorf("解析JSON失败: %v, 响应内容: %s", err, string(body[:min(200, len(body))]))
+	}
+	return nil
 }
 
 // saveCommentToDB 保存评论到数据库
 func (gcc *CommentCrawler) saveCommentToDB(comment *Comment) error {
-	// 使用 INSERT OR IGNORE 来实现去重
-	sql := `
-	INSERT OR IGNORE INTO gamersky_comments 
-	(id, article_id, user_id, username, content, comment_time, support_count, reply_count, parent_id, answer_to_id, answer_to_name, user_avatar, user_level, ip_location, device_name, floor_number, is_tuijian, is_author, is_best, user_authentication, user_group_id, third_platform_bound, create_time)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-
-	_, err := gcc.db.Exec(sql,
-		comment.ID, comment.ArticleID, comment.UserID, comment.Username,
-		comment.Content, comment.CommentTime, comment.SupportCount, comment.ReplyCount,
-		comment.ParentID, comment.AnswerToID, comment.AnswerToName, comment.UserAvatar, comment.UserLevel, comment.IPLocation,
-		comment.DeviceName, comment.FloorNumber, comment.IsTuijian, comment.IsAuthor,
-		comment.IsBest, comment.UserAuthentication, comment.UserGroupID, comment.ThirdPlatformBound,
-		comment.CreateTime)
+	inserted, err := gcc.store.SaveGamerskyComment(*comment, gcc.config.Run.ID())
+	gcc.config.Run.RecordSave(inserted, err)
 
 	return err
 }
 
-// QueryComments 查询数据库中的评论
-func (gcc *CommentCrawler) QueryComments(articleID string, limit int) ([]Comment, error) {
-	var query string
-	var args []interface{}
-
-	if articleID != "" {
-		if limit > 0 {
-			query = `
-			SELECT id, article_id, user_id, username, content, comment_time, support_count, reply_count, parent_id, answer_to_id, answer_to_name, user_avatar, user_level, ip_location, device_name, floor_number, is_tuijian, is_author, is_best, user_authentication, user_group_id, third_platform_bound, create_time 
-			FROM gamersky_comments 
-			WHERE article_id = ? 
-			ORDER BY comment_time DESC 
-			LIMIT ?`
-			args = append(args, articleID, limit)
-		} else {
-			query = `
-			SELECT id, article_id, user_id, username, content, comment_time, support_count, reply_count, parent_id, answer_to_id, answer_to_name, user_avatar, user_level, ip_location, device_name, floor_number, is_tuijian, is_author, is_best, user_authentication, user_group_id, third_platform_bound, create_time 
-			FROM gamersky_comments 
-			WHERE article_id = ? 
-			ORDER BY comment_time DESC`
-			args = append(args, articleID)
-		}
-	} else {
-		if limit > 0 {
-			query = `
-			SELECT id, article_id, user_id, username, content, comment_time, support_count, reply_count, parent_id, answer_to_id, answer_to_name, user_avatar, user_level, ip_location, device_name, floor_number, is_tuijian, is_author, is_best, user_authentication, user_group_id, third_platform_bound, create_time 
-			FROM gamersky_comments 
-			ORDER BY create_time DESC 
-			LIMIT ?`
-			args = append(args, limit)
-		} else {
-			query = `
-			SELECT id, article_id, user_id, username, content, comment_time, support_count, reply_count, parent_id, answer_to_id, answer_to_name, user_avatar, user_level, ip_location, device_name, floor_number, is_tuijian, is_author, is_best, user_authentication, user_group_id, third_platform_bound, create_time 
-			FROM gamersky_comments 
-			ORDER BY create_time DESC`
-		}
+// saveCommentRank 记录评论在排序中的名次，失败时只记录日志
+func (gcc *CommentCrawler) saveCommentRank(rank CommentRank) {
+	if err := gcc.store.SaveGamerskyCommentOrder(rank, gcc.config.Run.ID()); err != nil {
+		log.Printf("记录评论排序失败 (ID: %d): %v", rank.CommentID, err)
 	}
+}
 
-	rows, err := gcc.db.Query(query, args...)
+// CountStored 统计数据库中文章的一级评论数
+func (gcc *CommentCrawler) CountStored(articleID string) (int, error) {
+	comments, err := gcc.QueryComments(articleID, 0)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
-
-	var comments []Comment
-	for rows.Next() {
-		var comment Comment
-		err := rows.Scan(
-			&comment.ID, &comment.ArticleID, &comment.UserID, &comment.Username,
-			&comment.Content, &comment.CommentTime, &comment.SupportCount, &comment.ReplyCount,
-			&comment.ParentID, &comment.AnswerToID, &comment.AnswerToName, &comment.UserAvatar, &comment.UserLevel, &comment.IPLocation,
-			&comment.DeviceName, &comment.FloorNumber, &comment.IsTuijian, &comment.IsAuthor,
-			&comment.IsBest, &comment.UserAuthentication, &comment.UserGroupID, &comment.ThirdPlatformBound,
-			&comment.CreateTime,
-		)
-		if err != nil {
-			return nil, err
+
+	count := 0
+	for _, comment := range comments {
+		if comment.ParentID == 0 {
+			count++
 		}
-		comments = append(comments, comment)
 	}
+	return count, nil
+}
+
+// QueryComments 查询数据库中的评论
+func (gcc *CommentCrawler) QueryComments(articleID string, limit int) ([]Comment, error) {
+	return gcc.QueryCommentsInRange(articleID, time.Time{}, time.Time{}, limit)
+}
 
-	return comments, nil
+// QueryCommentsInRange 查询评论时间在 [since, until) 范围内的评论，零值时间表示不限制
+func (gcc *CommentCrawler) QueryCommentsInRange(articleID string, since, until time.Time, limit int) ([]Comment, error) {
+	return gcc.store.QueryGamerskyComments(store.CommentFilter{
+		ArticleID: articleID,
+		Since:     since,
+		Until:     until,
+		Limit:     limit,
+	})
 }
 
 // Close 关闭数据库连接
 func (gcc *CommentCrawler) Close() error {
-	if gcc.db != nil {
-		return gcc.db.Close()
+	if gcc.store != nil {
+		return gcc.store.Close()
 	}
 	return nil
 }
@@ -0,0 +1,246 @@
+package gamersky
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bili-comment/store"
+)
+
+// fakeCommentAPI 模拟评论接口，按请求的页码调用 page 生成响应，返回收到的页码请求记录
+func fakeCommentAPI(t *testing.T, page func(request CommentAPIRequest) string) *[]int {
+	t.Helper()
+
+	var mu sync.Mutex
+	var pages []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request CommentAPIRequest
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("request")), &request); err != nil {
+			t.Errorf("解析请求参数失败: %v", err)
+		}
+		mu.Lock()
+		pages = append(pages, request.PageIndex)
+		mu.Unlock()
+
+		body := page(request)
+		if body == "" {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	api := commentAPIURL
+	commentAPIURL = server.URL
+	t.Cleanup(func() { commentAPIURL = api })
+	return &pages
+}
+
+func TestCrawlCommentsFiltersRepliesByOwnTime(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ms := func(t time.Time) int64 { return t.UnixMilli() }
+
+	// 一级评论 1 在范围内、回复在 Until 之后；一级评论 2 早于 Since、回复在范围内
+	body := fmt.Sprintf(`{"errorCode":0,"result":{"commentsCount":40,"comments":[
+		{"comment_id":1,"create_time":%d,"nickname":"a","content":"范围内","repliesCount":1,
+		 "replies":[{"replyId":11,"createTime":%d,"userName":"b","replyContent":"太晚"}]},
+		{"comment_id":2,"create_time":%d,"nickname":"c","content":"太早","repliesCount":1,
+		 "replies":[{"replyId":21,"createTime":%d,"userName":"d","replyContent":"范围内的回复"}]}
+	]}}`,
+		ms(since.Add(time.Hour)), ms(since.Add(48*time.Hour)),
+		ms(since.Add(-time.Hour)), ms(since.Add(2*time.Hour)))
+	pages := fakeCommentAPI(t, func(CommentAPIRequest) string { return body })
+
+	st := store.NewMemory()
+	config := &Config{CommentOrder: OrderLatest, Since: since, Until: since.Add(24 * time.Hour)}
+	report, err := NewCommentCrawlerWithStore(config, st).CrawlCommentsWithReport(context.Background(), "100", 0)
+	if err != nil {
+		t.Fatalf("爬取评论失败: %v", err)
+	}
+
+	comments, _ := st.QueryGamerskyComments(store.CommentFilter{ArticleID: "100"})
+	saved := make(map[int64]bool)
+	for _, comment := range comments {
+		saved[comment.ID] = true
+	}
+	if len(saved) != 2 || !saved[1] || !saved[21] {
+		t.Errorf("保存的评论 = %v, 期望一级评论 1 和回复 21", saved)
+	}
+
+	// 最新排序下第一页已早于 Since，不再请求第二页
+	if !report.StoppedEarly || len(*pages) != 1 {
+		t.Errorf("StoppedEarly = %t, 请求的页码 = %v, 期望只请求第 1 页", report.StoppedEarly, *pages)
+	}
+}
+
+func TestCrawlCommentsCancelDuringRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 共3页，第2、3页一直失败；第2页重试失败后在等待重试第3页期间取消
+	var mu sync.Mutex
+	requests := make(map[int]int)
+	pages := fakeCommentAPI(t, func(request CommentAPIRequest) string {
+		mu.Lock()
+		defer mu.Unlock()
+		requests[request.PageIndex]++
+
+		if request.PageIndex == 1 {
+			return `{"errorCode":0,"result":{"commentsCount":60,"comments":[{"comment_id":1,"create_time":1735689600000,"content":"第一页"}]}}`
+		}
+		if request.PageIndex == 2 && requests[2] == 2 {
+			time.AfterFunc(20*time.Millisecond, cancel)
+		}
+		return ""
+	})
+
+	config := &Config{CommentOrder: OrderLatest, RequestDelay: 100 * time.Millisecond}
+	report, err := NewCommentCrawlerWithStore(config, store.NewMemory()).CrawlCommentsWithReport(ctx, "100", 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, 期望 context.Canceled", err)
+	}
+
+	// 第2页重试失败，第3页尚未重试，每页只报告一次
+	if fmt.Sprint(report.FailedPages) != "[2 3]" {
+		t.Errorf("FailedPages = %v, 期望 [2 3]", report.FailedPages)
+	}
+	if fmt.Sprint(*pages) != "[1 2 3 2]" {
+		t.Errorf("请求的页码 = %v, 期望 [1 2 3 2]", *pages)
+	}
+}
+
+func TestCrawlCommentsCancelDuringReplies(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 评论 1 的回复需要通过回复接口获取，获取期间取消；评论 2 仍应保存
+	fakeCommentAPI(t, func(CommentAPIRequest) string {
+		return `{"errorCode":0,"result":{"commentsCount":2,"comments":[
+			{"comment_id":1,"create_time":1735689600000,"nickname":"a","content":"有很多回复","repliesCount":15},
+			{"comment_id":2,"create_time":1735689600000,"nickname":"b","content":"同一页的评论","repliesCount":0}
+		]}}`
+	})
+	replies := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer replies.Close()
+	defer func(api string) { replyAPIURL = api }(replyAPIURL)
+	replyAPIURL = replies.URL
+
+	st := store.NewMemory()
+	report, err := NewCommentCrawlerWithStore(&Config{}, st).CrawlCommentsWithReport(ctx, "100", 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, 期望 context.Canceled", err)
+	}
+
+	comments, _ := st.QueryGamerskyComments(store.CommentFilter{ArticleID: "100"})
+	if len(comments) != 2 {
+		t.Errorf("保存了 %d 条评论, 期望本页的 2 条一级评论都保存", len(comments))
+	}
+	if report.Crawled != 1 || report.Saved != 2 || report.Fetched != 2 || len(report.FailedPages) != 0 {
+		t.Errorf("报告 = %+v, 期望第 1 页已完成", report)
+	}
+	if fmt.Sprint(report.IncompleteThreads) != "[1]" {
+		t.Errorf("IncompleteThreads = %v, 期望 [1]", report.IncompleteThreads)
+	}
+	// 回复请求也计入请求数
+	if report.Requests != 2 {
+		t.Errorf("Requests = %d, 期望评论和回复各请求 1 次", report.Requests)
+	}
+}
+
+// commentsPageBody 生成评论接口一页的响应，commentsCount 为接口报告的评论总数
+func commentsPageBody(page, count, commentsCount int) string {
+	var comments []string
+	for i := 0; i < count; i++ {
+		comments = append(comments, fmt.Sprintf(`{"comment_id":%d,"create_time":1735689600000,"nickname":"u","content":"c"}`, page*100+i))
+	}
+	return fmt.Sprintf(`{"errorCode":0,"result":{"commentsCount":%d,"comments":[%s]}}`, commentsCount, strings.Join(comments, ","))
+}
+
+func TestCrawlCommentsRetry(t *testing.T) {
+	// 45 条评论共 3 页，最后一页 5 条
+	pageSize := func(page int) int {
+		if page == 3 {
+			return 5
+		}
+		return commentPageSize
+	}
+
+	cases := []struct {
+		name     string
+		maxPages int
+		fail     func(page, attempt int) bool // 第 attempt 次请求该页时是否失败
+		requests string
+		crawled  int
+		fetched  int
+		failed   string
+		complete bool
+	}{
+		{
+			name:     "第2页失败一次后重试成功",
+			fail:     func(page, attempt int) bool { return page == 2 && attempt == 1 },
+			requests: "[1 2 3 2]", crawled: 3, fetched: 45, failed: "[]", complete: true,
+		},
+		{
+			name:     "第3页重试后仍然失败",
+			fail:     func(page, attempt int) bool { return page == 3 },
+			requests: "[1 2 3 3 3 3]", crawled: 2, fetched: 40, failed: "[3]", complete: false,
+		},
+		{
+			name:     "maxPages限制页数",
+			maxPages: 2,
+			fail:     func(page, attempt int) bool { return false },
+			requests: "[1 2]", crawled: 2, fetched: 40, failed: "[]", complete: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := make(map[int]int)
+			pages := fakeCommentAPI(t, func(request CommentAPIRequest) string {
+				page := request.PageIndex
+				attempts[page]++
+				if tc.fail(page, attempts[page]) {
+					return ""
+				}
+				return commentsPageBody(page, pageSize(page), 45)
+			})
+
+			report, err := NewCommentCrawlerWithStore(&Config{}, store.NewMemory()).CrawlCommentsWithReport(context.Background(), "100", tc.maxPages)
+			if err != nil {
+				t.Fatalf("爬取评论失败: %v", err)
+			}
+
+			// 总页数由第一页返回的 commentsCount 计算
+			if report.Reported != 45 || report.TotalPages != 3 {
+				t.Errorf("Reported = %d, TotalPages = %d, 期望 45 和 3", report.Reported, report.TotalPages)
+			}
+			if got := fmt.Sprint(*pages); got != tc.requests {
+				t.Errorf("请求的页码 = %s, 期望 %s", got, tc.requests)
+			}
+			if report.Requests != len(*pages) {
+				t.Errorf("Requests = %d, 期望包括重试在内的 %d 次", report.Requests, len(*pages))
+			}
+			if report.Crawled != tc.crawled || report.Fetched != tc.fetched {
+				t.Errorf("Crawled = %d, Fetched = %d, 期望 %d 和 %d", report.Crawled, report.Fetched, tc.crawled, tc.fetched)
+			}
+			if got := fmt.Sprint(report.FailedPages); got != tc.failed {
+				t.Errorf("FailedPages = %s, 期望 %s", got, tc.failed)
+			}
+			if report.Complete() != tc.complete {
+				t.Errorf("Complete() = %t, 期望 %t", report.Complete(), tc.complete)
+			}
+		})
+	}
+}
@@ -2,10 +2,44 @@ package gamersky
 
 import (
 	"time"
+
+	"bili-comment/runlog"
+	"bili-comment/store"
 )
 
+// DefaultOutputPath 默认的Gamersky数据库路径
+const DefaultOutputPath = "./data/gamersky.db"
+
 // Config Gamersky爬虫配置
 type Config struct {
 	OutputPath   string        // 输出数据库路径
+	StoreDSN     string        // 存储DSN (为空时使用 OutputPath 下的SQLite数据库)
 	RequestDelay time.Duration // 请求间隔
+	Run          *runlog.Run   // 本次运行记录 (可为空)
+	Since        time.Time     // 只保存该时间及之后的评论 (零值表示不限制)
+	Until        time.Time     // 只保存该时间之前的评论 (零值表示不限制)
+	ImageDir     string        // 评论图片下载目录，为空时只保存图片信息，不下载图片
+	Channel      string        // 新闻频道，为空或 index 时爬取手机版首页信息流
+	CommentOrder string        // 评论排序方式，为空时为 recommended（推荐）
+	MinPraises   int           // 只获取点赞数不少于该值的一级评论，0表示不限制
+}
+
+// inTimeRange 判断评论时间是否在 [Since, Until) 范围内
+func (c *Config) inTimeRange(t time.Time) bool {
+	if !c.Since.IsZero() && t.Before(c.Since) {
+		return false
+	}
+	if !c.Until.IsZero() && !t.Before(c.Until) {
+		return false
+	}
+	return true
+}
+
+// openStore 根据配置打开存储
+func (c *Config) openStore() (store.Store, error) {
+	outputPath := c.OutputPath
+	if outputPath == "" {
+		outputPath = DefaultOutputPath
+	}
+	return store.Open(c.StoreDSN, outputPath)
 }
@@ -0,0 +1,209 @@
+package gamersky
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"bili-comment/httpclient"
+	"bili-comment/model"
+)
+
+// DefaultImageDir 默认的评论图片缓存目录
+const DefaultImageDir = "./data/gamersky-images"
+
+// maxImageSize 单张图片的最大下载大小
+const maxImageSize = 32 << 20
+
+// APIImage 评论接口返回的图片信息
+type APIImage struct {
+	Origin string `json:"origin"` // 原图链接
+	Small  string `json:"small"`  // 缩略图链接
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	IsGif  bool   `json:"isGif"`
+}
+
+// url 获取图片链接，优先使用原图
+func (img APIImage) url() string {
+	if img.Origin != "" {
+		return img.Origin
+	}
+	return img.Small
+}
+
+// ImageCache 按内容寻址的本地图片缓存
+// 图片保存为 <目录>/<SHA-256前两位>/<SHA-256><扩展名>，相同内容的图片只保存一份；
+// 图片链接到缓存文件的对应关系保存在 <目录>/urls 下，已下载过的链接不再重复下载
+type ImageCache struct {
+	Dir string
+}
+
+// NewImageCache 创建图片缓存，目录为空时使用默认目录
+func NewImageCache(dir string) *ImageCache {
+	if dir == "" {
+		dir = DefaultImageDir
+	}
+	return &ImageCache{Dir: dir}
+}
+
+// Path 获取内容哈希对应的缓存文件路径，ext 为带点的扩展名
+func (c *ImageCache) Path(hash, ext string) string {
+	return filepath.Join(c.Dir, hash[:2], hash+ext)
+}
+
+// urlIndexPath 获取图片链接对应的索引文件路径，索引文件的内容为缓存文件名
+func (c *ImageCache) urlIndexPath(imageURL string) string {
+	sum := sha256.Sum256([]byte(imageURL))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.Dir, "urls", key[:2], key)
+}
+
+// lookup 查找已下载过的图片链接，缓存文件存在时返回内容哈希
+func (c *ImageCache) lookup(imageURL string) (string, bool) {
+	name, err := os.ReadFile(c.urlIndexPath(imageURL))
+	if err != nil || len(name) < sha256.Size*2 {
+		return "", false
+	}
+	hash, ext := string(name[:sha256.Size*2]), string(name[sha256.Size*2:])
+	if _, err := os.Stat(c.Path(hash, ext)); err != nil {
+		return "", false
+	}
+	return hash, true
+}
+
+// Download 下载图片并写入缓存，返回图片内容的SHA-256
+// 该链接已下载过且缓存文件存在时不再请求；缓存中已有相同内容时不再写入
+func (c *ImageCache) Download(ctx context.Context, imageURL string) (string, error) {
+	if hash, ok := c.lookup(imageURL); ok {
+		return hash, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36")
+	req.Header.Set("Referer", "https://www.gamersky.com/")
+
+	resp, err := httpclient.Default().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("下载图片失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("下载图片失败: HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return "", fmt.Errorf("读取图片失败: %v", err)
+	}
+	if len(body) > maxImageSize {
+		return "", fmt.Errorf("图片超过 %d MB", maxImageSize>>20)
+	}
+
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+	ext := imageExt(imageURL, resp.Header.Get("Content-Type"))
+	target := c.Path(hash, ext)
+
+	if _, err := os.Stat(target); err != nil {
+		if err := writeFileAtomic(target, body); err != nil {
+			return "", fmt.Errorf("保存图片失败: %v", err)
+		}
+	}
+
+	// 索引写入失败只影响下次是否重新下载
+	if err := writeFileAtomic(c.urlIndexPath(imageURL), []byte(hash+ext)); err != nil {
+		log.Printf("保存图片索引失败 (%s): %v", imageURL, err)
+	}
+	return hash, nil
+}
+
+// imageExt 根据链接或响应类型确定图片扩展名
+func imageExt(imageURL, contentType string) string {
+	if u, err := url.Parse(imageURL); err == nil {
+		switch ext := strings.ToLower(path.Ext(u.Path)); ext {
+		case ".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp":
+			return ext
+		}
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+			return exts[0]
+		}
+	}
+	return ""
+}
+
+// writeFileAtomic 先写入临时文件再重命名，避免中断时留下不完整的图片
+func writeFileAtomic(target string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// saveImages 保存评论中的图片，配置了图片缓存时先下载图片，返回保存的图片数
+// 下载失败的图片仍会保存图片信息，内容哈希为空
+func (gcc *CommentCrawler) saveImages(ctx context.Context, articleID string, commentID int64, images []APIImage) int {
+	saved := 0
+	for i, img := range images {
+		image := model.GamerskyCommentImage{
+			CommentID:  commentID,
+			ArticleID:  articleID,
+			ImageOrder: i,
+			URL:        img.url(),
+			Width:      img.Width,
+			Height:     img.Height,
+		}
+		if image.URL == "" {
+			continue
+		}
+
+		if gcc.images != nil {
+			hash, err := gcc.images.Download(ctx, image.URL)
+			if err != nil {
+				gcc.config.Run.RecordError(err)
+				log.Printf("下载评论 %d 的图片失败 (%s): %v", commentID, image.URL, err)
+			}
+			image.SHA256 = hash
+		}
+
+		inserted, err := gcc.store.SaveGamerskyCommentImage(image, gcc.config.Run.ID())
+		if err != nil {
+			log.Printf("保存评论 %d 的图片失败: %v", commentID, err)
+			continue
+		}
+		if inserted {
+			saved++
+		}
+	}
+	return saved
+}
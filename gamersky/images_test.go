@@ -0,0 +1,244 @@
+package gamersky
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"bili-comment/model"
+	"bili-comment/source"
+)
+
+func TestAPIImageJSON(t *testing.T) {
+	var response CommentAPIResponse
+	if err := json.Unmarshal(readSnapshot(t, "gamersky-comments-images.json"), &response); err != nil {
+		t.Fatalf("解析评论接口响应失败: %v", err)
+	}
+	if len(response.Result.Comments) != 2 {
+		t.Fatalf("评论数 = %d, 期望 2", len(response.Result.Comments))
+	}
+
+	images := response.Result.Comments[0].ImageInfes
+	if len(images) != 2 {
+		t.Fatalf("第一条评论的图片数 = %d, 期望 2", len(images))
+	}
+	first := images[0]
+	if first.Origin != "https://imgs.gamersky.com/upimg/comment/2025/01/02/a.jpg" || first.Width != 800 || first.Height != 600 || first.IsGif {
+		t.Errorf("第一张图片 = %+v", first)
+	}
+	if first.url() != first.Origin {
+		t.Errorf("有原图时应使用原图链接: %s", first.url())
+	}
+	// 没有原图时使用缩略图
+	second := images[1]
+	if !second.IsGif || second.url() != "https://imgs.gamersky.com/upimg/comment/2025/01/02/b_small" {
+		t.Errorf("第二张图片 = %+v, 链接 %s", second, second.url())
+	}
+}
+
+func TestImageExt(t *testing.T) {
+	cases := []struct {
+		url, contentType, want string
+	}{
+		{"https://imgs.gamersky.com/a.JPG", "", ".jpg"},
+		{"https://imgs.gamersky.com/a.webp?x=1", "image/png", ".webp"},
+		{"https://imgs.gamersky.com/b_small", "image/gif", ".gif"},
+		{"https://imgs.gamersky.com/b.php", "image/png; charset=binary", ".png"},
+		{"https://imgs.gamersky.com/b", "", ""},
+		{"https://imgs.gamersky.com/b", "application/x-unknown-type", ""},
+	}
+	for _, c := range cases {
+		if got := imageExt(c.url, c.contentType); got != c.want {
+			t.Errorf("imageExt(%q, %q) = %q, 期望 %q", c.url, c.contentType, got, c.want)
+		}
+	}
+}
+
+// fakeImageServer 模拟图片服务器，返回按路径统计的请求次数
+func fakeImageServer(t *testing.T, files map[string][]byte) (*httptest.Server, func() map[string]int) {
+	t.Helper()
+
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "_small") {
+			w.Header().Set("Content-Type", "image/gif")
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() map[string]int {
+		mu.Lock()
+		defer mu.Unlock()
+		counts := make(map[string]int, len(requests))
+		for path, n := range requests {
+			counts[path] = n
+		}
+		return counts
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestImageCacheDownload(t *testing.T) {
+	jpg, gif := []byte("jpg-content"), []byte("gif-content")
+	server, requests := fakeImageServer(t, map[string][]byte{
+		"/a.jpg":    jpg,
+		"/copy.jpg": jpg, // 与 a.jpg 内容相同
+		"/b_small":  gif,
+	})
+	cache := NewImageCache(t.TempDir())
+	ctx := context.Background()
+
+	for _, path := range []string{"/a.jpg", "/copy.jpg", "/b_small", "/a.jpg"} {
+		if _, err := cache.Download(ctx, server.URL+path); err != nil {
+			t.Fatalf("下载 %s 失败: %v", path, err)
+		}
+	}
+
+	// 按内容寻址：相同内容只保存一份，扩展名取自链接或响应类型
+	jpgPath, gifPath := cache.Path(sha256Hex(jpg), ".jpg"), cache.Path(sha256Hex(gif), ".gif")
+	for path, want := range map[string][]byte{jpgPath: jpg, gifPath: gif} {
+		if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, want) {
+			t.Errorf("缓存文件 %s = %q, %v", path, data, err)
+		}
+	}
+	if files := cachedImages(t, cache.Dir); len(files) != 2 {
+		t.Errorf("缓存的图片 = %v, 期望 2 个文件", files)
+	}
+
+	// 已下载过的链接不再请求
+	if got := requests(); got["/a.jpg"] != 1 || got["/copy.jpg"] != 1 || got["/b_small"] != 1 {
+		t.Errorf("请求次数 = %v, 期望每个链接 1 次", got)
+	}
+
+	// 缓存文件被删除后重新下载
+	if err := os.Remove(jpgPath); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := cache.Download(ctx, server.URL+"/a.jpg")
+	if err != nil || hash != sha256Hex(jpg) {
+		t.Fatalf("重新下载 = %s, %v", hash, err)
+	}
+	if got := requests(); got["/a.jpg"] != 2 {
+		t.Errorf("删除缓存后 /a.jpg 请求 %d 次, 期望 2", got["/a.jpg"])
+	}
+	if _, err := os.Stat(jpgPath); err != nil {
+		t.Errorf("重新下载后缓存文件不存在: %v", err)
+	}
+
+	// 下载失败返回错误，不写入索引
+	if _, err := cache.Download(ctx, server.URL+"/missing.jpg"); err == nil {
+		t.Error("图片不存在时应返回错误")
+	}
+}
+
+// cachedImages 列出缓存目录中的图片文件（不含链接索引）
+func cachedImages(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == "urls" {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() {
+			files = append(files, filepath.Base(path))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestCrawlCommentsDownloadImages(t *testing.T) {
+	jpg, gif := []byte("jpg-content"), []byte("gif-content")
+	prefix := "/upimg/comment/2025/01/02"
+	server, requests := fakeImageServer(t, map[string][]byte{
+		prefix + "/a.jpg":    jpg,
+		prefix + "/copy.jpg": jpg,
+		prefix + "/b_small":  gif,
+	})
+	body := strings.ReplaceAll(string(readSnapshot(t, "gamersky-comments-images.json")), "https://imgs.gamersky.com", server.URL)
+	fakeCommentAPI(t, func(CommentAPIRequest) string { return body })
+
+	// 与 gamersky-comments --download-images 相同，设置 ImageDir 时下载图片
+	config := &Config{ImageDir: t.TempDir()}
+	crawl := func() []model.GamerskyCommentImage {
+		emit, collected := source.Collect()
+		report, err := NewCommentCrawlerWithStore(config, source.NewEmitStore(emit)).CrawlCommentsWithReport(context.Background(), "100", 0)
+		if err != nil {
+			t.Fatalf("爬取评论失败: %v", err)
+		}
+		if report.Images != 3 {
+			t.Errorf("保存图片 %d 张, 期望 3", report.Images)
+		}
+
+		var images []model.GamerskyCommentImage
+		for _, record := range collected() {
+			if image, ok := record.(model.GamerskyCommentImage); ok {
+				images = append(images, image)
+			}
+		}
+		return images
+	}
+
+	for run := 1; run <= 2; run++ {
+		images := crawl()
+		if len(images) != 3 {
+			t.Fatalf("第 %d 次爬取: 图片 = %+v, 期望 3 张", run, images)
+		}
+		want := []struct {
+			commentID int64
+			order     int
+			path      string
+			hash      string
+		}{
+			{801, 0, prefix + "/a.jpg", sha256Hex(jpg)},
+			{801, 1, prefix + "/b_small", sha256Hex(gif)},
+			{802, 0, prefix + "/copy.jpg", sha256Hex(jpg)},
+		}
+		for i, w := range want {
+			got := images[i]
+			if got.CommentID != w.commentID || got.ImageOrder != w.order || got.URL != server.URL+w.path || got.SHA256 != w.hash {
+				t.Errorf("第 %d 次爬取: 图片 %d = %+v", run, i, got)
+			}
+		}
+	}
+
+	// 第二次爬取时图片已在缓存中，不再下载
+	if got := requests(); got[prefix+"/a.jpg"] != 1 || got[prefix+"/copy.jpg"] != 1 || got[prefix+"/b_small"] != 1 {
+		t.Errorf("图片请求次数 = %v, 期望每张 1 次", got)
+	}
+	if files := cachedImages(t, config.ImageDir); len(files) != 2 {
+		t.Errorf("缓存的图片 = %v, 期望 2 个文件", files)
+	}
+}
@@ -0,0 +1,105 @@
+package gamersky
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ErrIndexLayout 首页信息流中没有已知布局的新闻，页面结构可能已经变化
+var ErrIndexLayout = errors.New("无法识别首页信息流结构")
+
+// wapIndexLayout 首页信息流中一种新闻列表项的布局
+type wapIndexLayout struct {
+	Name  string // 布局名称，与页面中的class相同
+	Title string // 标题选择器
+	Link  string // 链接选择器
+}
+
+// wapIndexLayouts 已知的首页信息流布局，按顺序尝试，页面调整时修改该表即可
+var wapIndexLayouts = []wapIndexLayout{
+	// 三图布局：class 在 li 上，标题和图片列表平级
+	{Name: "sanTu", Title: ".sanTu h5", Link: ".sanTu a"},
+	// 标题加时间布局：标题和时间在 .titleAndTime 中，右侧一张图
+	{Name: "titleAndTime", Title: ".titleAndTime h5", Link: "a"},
+}
+
+// ParseWapIndex 解析手机版首页信息流中的新闻
+// 页面中没有新闻列表项，或所有列表项都不是已知布局时返回 ErrIndexLayout
+func ParseWapIndex(html []byte) ([]NewsInfo, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("解析HTML失败: %v", err)
+	}
+
+	items := doc.Find("li[data-id]")
+	if items.Length() == 0 {
+		return nil, fmt.Errorf("%w: 没有找到新闻列表项 li[data-id]", ErrIndexLayout)
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	seen := make(map[string]bool)
+	var news []NewsInfo
+	unknown := 0
+	items.Each(func(_ int, li *goquery.Selection) {
+		item := parseWapIndexItem(li)
+		if item == nil {
+			unknown++
+			return
+		}
+		if seen[item.SID] {
+			return
+		}
+		seen[item.SID] = true
+		item.CreateTime = now
+		news = append(news, *item)
+	})
+
+	if len(news) == 0 {
+		return nil, fmt.Errorf("%w: %d 个新闻列表项都不是已知布局 (%s)", ErrIndexLayout, unknown, wapIndexLayoutNames())
+	}
+	if unknown > 0 {
+		log.Printf("首页信息流中有 %d 个列表项不是已知布局，已跳过", unknown)
+	}
+	return news, nil
+}
+
+// parseWapIndexItem 按已知布局解析一条新闻，不匹配任何布局或没有标题时返回nil
+func parseWapIndexItem(li *goquery.Selection) *NewsInfo {
+	for _, layout := range wapIndexLayouts {
+		title := strings.TrimSpace(li.Find(layout.Title).First().Text())
+		if title == "" {
+			continue
+		}
+
+		news := &NewsInfo{
+			SID:         li.AttrOr("data-id", ""),
+			Title:       title,
+			Time:        strings.TrimSpace(li.Find("time").First().Text()),
+			URL:         li.Find(layout.Link).First().AttrOr("href", ""),
+			ImageURL:    li.Find("img").First().AttrOr("src", ""),
+			TopLineTime: li.AttrOr("data-toplinetime", ""),
+		}
+		// 评论数由页面脚本加载，未加载时为空
+		if commentNum, err := strconv.Atoi(strings.TrimSpace(li.Find(".commentNum").First().Text())); err == nil {
+			news.CommentNum = commentNum
+		}
+		return news
+	}
+	return nil
+}
+
+// wapIndexLayoutNames 获取所有已知布局的名称，以逗号分隔
+func wapIndexLayoutNames() string {
+	names := make([]string, 0, len(wapIndexLayouts))
+	for _, layout := range wapIndexLayouts {
+		names = append(names, layout.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,110 @@
+package gamersky
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestParseWapIndex(t *testing.T) {
+	debugOutput, err := os.ReadFile("../debug/debug_output.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures := []struct {
+		name    string
+		html    []byte
+		first   NewsInfo
+		comment int // 第一条新闻的评论数，页面脚本未加载时为0
+	}{
+		{
+			name: "html-snapshot/wap-gs.html",
+			html: readSnapshot(t, "wap-gs.html"),
+			first: NewsInfo{
+				SID:         "2013305",
+				Title:       "《丝之歌》通关率来到12.4% 最难成就达成率仍为0%!",
+				Time:        "10:10",
+				URL:         "https://wap.gamersky.com/news/Content-2013305.html",
+				ImageURL:    "https://imgs.gamersky.com/upimg/new_preview/2025/09/13/origin_b_202509130925409265.jpg",
+				TopLineTime: "2025-09-13 10:10:52",
+			},
+			comment: 3,
+		},
+		{
+			name: "debug/debug_output.html",
+			html: debugOutput,
+			first: NewsInfo{
+				SID:         "2013306",
+				Title:       "筋疲力尽！《小丑牌》开发者道歉：无法准时更新游戏",
+				Time:        "10:34",
+				URL:         "https://wap.gamersky.com/news/Content-2013306.html",
+				ImageURL:    "https://imgs.gamersky.com/upimg/new_preview/2025/09/13/origin_b_202509130925146681.jpg",
+				TopLineTime: "2025-09-13 10:34:52",
+			},
+		},
+	}
+
+	for _, fixture := range fixtures {
+		news, err := ParseWapIndex(fixture.html)
+		if err != nil {
+			t.Fatalf("%s: 解析失败: %v", fixture.name, err)
+		}
+		// 页面脚本中的列表项模板不是HTML元素，不会被解析
+		if len(news) != 15 {
+			t.Errorf("%s: 新闻数 = %d, 期望 15", fixture.name, len(news))
+		}
+
+		got := news[0]
+		want := fixture.first
+		want.CommentNum = fixture.comment
+		want.CreateTime = got.CreateTime
+		if got != want {
+			t.Errorf("%s: 第一条新闻 = %+v, 期望 %+v", fixture.name, got, want)
+		}
+
+		// 三图布局的新闻
+		var sanTu *NewsInfo
+		for i := range news {
+			if news[i].SID == "2013009" {
+				sanTu = &news[i]
+			}
+			if news[i].Title == "" || news[i].URL == "" || news[i].TopLineTime == "" {
+				t.Errorf("%s: 新闻 %s 缺少字段: %+v", fixture.name, news[i].SID, news[i])
+			}
+		}
+		if sanTu == nil {
+			t.Fatalf("%s: 没有解析出三图布局的新闻 2013009", fixture.name)
+		}
+		if sanTu.Title != "《宇宙机器人》总监力推25款PS游戏：你玩过哪些？" ||
+			sanTu.URL != "https://wap.gamersky.com/news/Content-2013009.html" ||
+			sanTu.ImageURL != "https://imgs.gamersky.com/upimg/new_preview/2025/09/12/origin_b_202509121604513081.jpg" {
+			t.Errorf("%s: 三图布局新闻 = %+v", fixture.name, *sanTu)
+		}
+	}
+}
+
+func TestParseWapIndexLayoutDrift(t *testing.T) {
+	cases := map[string]string{
+		"没有列表项": `<html><body><ul><li>广告</li></ul></body></html>`,
+		"未知布局": `<html><body><ul>
+			<li data-id="1"><div class="newLayout"><h3>新布局的标题</h3></div><a href="/news/Content-1.html"></a></li>
+			<li data-id="2"><div class="newLayout"><h3>另一条新闻</h3></div><a href="/news/Content-2.html"></a></li>
+		</ul></body></html>`,
+	}
+	for name, html := range cases {
+		if _, err := ParseWapIndex([]byte(html)); !errors.Is(err, ErrIndexLayout) {
+			t.Errorf("%s: err = %v, 期望 ErrIndexLayout", name, err)
+		}
+	}
+
+	// 部分列表项是未知布局时跳过这些项
+	mixed := `<html><body><ul>
+		<li data-id="1"><div class="titleAndTime"><h5>已知布局</h5><p><time>刚刚</time></p></div><a href="/news/Content-1.html"></a></li>
+		<li data-id="2"><div class="newLayout"><h3>未知布局</h3></div></li>
+	</ul></body></html>`
+	news, err := ParseWapIndex([]byte(mixed))
+	if err != nil || len(news) != 1 || news[0].SID != "1" || news[0].Time != "刚刚" {
+		t.Errorf("部分未知布局: news = %+v, err = %v", news, err)
+	}
+}
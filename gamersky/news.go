@@ -1,17 +1,19 @@
 package gamersky
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
+	"bili-comment/httpclient"
+	"bili-comment/store"
+
 	"github.com/gocolly/colly/v2"
 )
 
@@ -45,41 +47,58 @@ type APINewsItem struct {
 	WapArticleUrl            string `json:"WapArticleUrl"`
 	WapSanTuArticlePic       string `json:"WapSanTuArticlePic"`
 	TopLineTime              string `json:"TopLineTime"`
+	CommentsCount            int    `json:"CommentsCount"`
 }
 
+// imgSrcRegex 从HTML图片标签中提取图片URL
+var imgSrcRegex = regexp.MustCompile(`src=['"]([^'"]*?)['"]`)
+
 // NewsCrawler Gamersky新闻爬虫结构体
 type NewsCrawler struct {
-	db     *sql.DB
+	store  store.NewsStore
 	config *Config
 }
 
-// NewNewsCrawler 创建新的Gamersky新闻爬虫实例
+// NewNewsCrawler 创建新的Gamersky新闻爬虫实例，存储由配置中的DSN决定
 func NewNewsCrawler(config *Config) (*NewsCrawler, error) {
-	// 初始化数据库
-	db, err := GetDBConnection(config.OutputPath)
+	// 初始化存储
+	st, err := config.openStore()
 	if err != nil {
 		return nil, fmt.Errorf("数据库连接失败: %v", err)
 	}
 
+	return NewNewsCrawlerWithStore(config, st), nil
+}
+
+// NewNewsCrawlerWithStore 使用指定存储创建Gamersky新闻爬虫实例
+func NewNewsCrawlerWithStore(config *Config, st store.NewsStore) *NewsCrawler {
 	return &NewsCrawler{
-		db:     db,
+		store:  st,
 		config: config,
-	}, nil
+	}
 }
 
-// CrawlNews 爬取Gamersky新闻
-func (gnc *NewsCrawler) CrawlNews(page int) (int, error) {
+// CrawlNews 爬取Gamersky新闻，配置了频道时爬取频道列表，否则爬取手机版首页信息流
+func (gnc *NewsCrawler) CrawlNews(ctx context.Context, page int) (int, error) {
+	ch, ok, err := LookupChannel(gnc.config.Channel)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return gnc.crawlChannelPage(ctx, ch, page)
+	}
+
 	if page == 1 {
 		// 第一页使用Colly爬取（保持原有逻辑）
-		return gnc.crawlFirstPage()
+		return gnc.crawlFirstPage(ctx)
 	} else {
 		// 后续页面使用API
-		return gnc.crawlAPIPage(page)
+		return gnc.crawlAPIPage(ctx, page)
 	}
 }
 
 // crawlAPIPage 使用API爬取指定页面
-func (gnc *NewsCrawler) crawlAPIPage(page int) (int, error) {
+func (gnc *NewsCrawler) crawlAPIPage(ctx context.Context, page int) (int, error) {
 	// 构造API请求
 	requestData := APIRequest{
 		Request: APIRequestData{
@@ -96,10 +115,10 @@ func (gnc *NewsCrawler) crawlAPIPage(page int) (int, error) {
 	}
 
 	// 创建HTTP客户端
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := httpclient.Default()
 
 	// 创建请求
-	req, err := http.NewRequest("POST", "https://appapi2.gamersky.com/v6/GetWapIndex", strings.NewReader(string(jsonData)))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://appapi2.gamersky.com/v6/GetWapIndex", strings.NewReader(string(jsonData)))
 	if err != nil {
 		return 0, fmt.Errorf("创建请求失败: %v", err)
 	}
@@ -142,6 +161,7 @@ func (gnc *NewsCrawler) crawlAPIPage(page int) (int, error) {
 			SID:         fmt.Sprintf("%d", item.ArticleID),
 			Title:       item.Title,
 			Time:        item.WapTopLineTimeTodayLabel,
+			CommentNum:  item.CommentsCount,
 			URL:         item.WapArticleUrl,
 			TopLineTime: item.TopLineTime,
 			CreateTime:  time.Now().Format("2006-01-02 15:04:05"),
@@ -149,13 +169,12 @@ func (gnc *NewsCrawler) crawlAPIPage(page int) (int, error) {
 
 		// 从HTML图片标签中提取图片URL
 		if item.WapSanTuArticlePic != "" {
-			imgRegex := regexp.MustCompile(`src=['"]([^'"]*?)['"]`)
-			if matches := imgRegex.FindStringSubmatch(item.WapSanTuArticlePic); len(matches) > 1 {
+			if matches := imgSrcRegex.FindStringSubmatch(item.WapSanTuArticlePic); len(matches) > 1 {
 				news.ImageURL = matches[1]
 			}
 		}
 
-		// 保存新闻到数据库（去重）
+		// 保存新闻到数据库（已有新闻更新评论数等信息）
 		if err := gnc.saveNewsToDB(news); err != nil {
 			log.Printf("保存新闻失败 (SID: %s): %v", news.SID, err)
 			continue
@@ -170,16 +189,21 @@ func (gnc *NewsCrawler) crawlAPIPage(page int) (int, error) {
 }
 
 // crawlFirstPage 爬取第一页
-func (gnc *NewsCrawler) crawlFirstPage() (int, error) {
+func (gnc *NewsCrawler) crawlFirstPage(ctx context.Context) (int, error) {
 	// 创建 Colly 收集器
 	c := colly.NewCollector(
 		// 设置允许的域名
 		colly.AllowedDomains("wap.gamersky.com"),
+		// 请求随ctx取消
+		colly.StdlibContext(ctx),
 	)
 
 	// 设置用户代理和请求头
 	c.UserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36"
 
+	// 使用共享客户端的传输层（请求计数、代理池）
+	c.WithTransport(httpclient.Default().Transport)
+
 	// 设置请求延迟
 	c.Limit(&colly.LimitRule{
 		DomainGlob:  "*",
@@ -188,79 +212,26 @@ func (gnc *NewsCrawler) crawlFirstPage() (int, error) {
 	})
 
 	count := 0
+	var parseErr error
 
-	// 查找新闻列表项
-	c.OnHTML("li[data-id]", func(e *colly.HTMLElement) {
-		// 提取新闻信息
-		news := &NewsInfo{
-			SID:         e.Attr("data-id"),
-			TopLineTime: e.Attr("data-toplinetime"),
-			CreateTime:  time.Now().Format("2006-01-02 15:04:05"),
-		}
-
-		// 提取标题 - 根据当前li元素的布局类型来决定
-		var newsType string
-
-		// 检查是否为 titleAndTime 布局
-		titleElement := e.DOM.Find(".titleAndTime h5")
-		if titleElement.Length() > 0 {
-			news.Title = strings.TrimSpace(titleElement.Text())
-			newsType = "titleAndTime"
-		}
-
-		// 检查是否为 sanTu 布局
-		sanTuElement := e.DOM.Find(".sanTu h5")
-		if sanTuElement.Length() > 0 {
-			news.Title = strings.TrimSpace(sanTuElement.Text())
-			newsType = "sanTu"
-		}
-
-		// 提取时间 - 从 time 标签
-		timeElement := e.DOM.Find("time")
-		if timeElement.Length() > 0 {
-			news.Time = strings.TrimSpace(timeElement.Text())
-		}
-
-		// 提取评论数 - 从 commentNum 类的 span
-		commentElement := e.DOM.Find(".commentNum")
-		if commentElement.Length() > 0 {
-			if commentText := strings.TrimSpace(commentElement.Text()); commentText != "" {
-				if commentNum, err := strconv.Atoi(commentText); err == nil {
-					news.CommentNum = commentNum
-				}
-			}
-		}
-
-		// 提取链接 - 根据布局类型选择合适的选择器
-		if newsType == "sanTu" {
-			// sanTu 布局：优先使用 .sanTu 内的链接
-			sanTuLinkElement := e.DOM.Find(".sanTu a")
-			if sanTuLinkElement.Length() > 0 {
-				news.URL = sanTuLinkElement.AttrOr("href", "")
-			}
-		} else {
-			// titleAndTime 布局或其他：使用通用的 a 标签
-			linkElement := e.DOM.Find("a")
-			if linkElement.Length() > 0 {
-				news.URL = linkElement.AttrOr("href", "")
-			}
-		}
+	// 解析首页信息流中的新闻
+	c.OnResponse(func(r *colly.Response) {
+		log.Printf("收到响应: %s, 状态码: %d, 大小: %d bytes",
+			r.Request.URL, r.StatusCode, len(r.Body))
 
-		// 提取图片链接 - 从 img 标签的 src
-		imgElement := e.DOM.Find("img")
-		if imgElement.Length() > 0 {
-			news.ImageURL = imgElement.AttrOr("src", "")
+		news, err := ParseWapIndex(r.Body)
+		if err != nil {
+			parseErr = err
+			return
 		}
-
-		// 只处理有标题的新闻
-		if news.Title != "" {
-			// 保存新闻到数据库（去重）
-			if err := gnc.saveNewsToDB(news); err != nil {
-				log.Printf("保存新闻失败 (SID: %s): %v", news.SID, err)
-			} else {
-				count++
-				log.Printf("爬取新闻 [%s]: %s - %s", newsType, news.SID, news.Title)
+		for i := range news {
+			// 保存新闻到数据库（已有新闻更新评论数等信息）
+			if err := gnc.saveNewsToDB(&news[i]); err != nil {
+				log.Printf("保存新闻失败 (SID: %s): %v", news[i].SID, err)
+				continue
 			}
+			count++
+			log.Printf("爬取新闻: %s - %s", news[i].SID, news[i].Title)
 		}
 	})
 
@@ -277,12 +248,6 @@ func (gnc *NewsCrawler) crawlFirstPage() (int, error) {
 		log.Printf("请求失败: %s, 错误: %v", r.Request.URL, err)
 	})
 
-	// 请求完成处理
-	c.OnResponse(func(r *colly.Response) {
-		log.Printf("收到响应: %s, 状态码: %d, 大小: %d bytes",
-			r.Request.URL, r.StatusCode, len(r.Body))
-	})
-
 	// 开始爬取
 	err := c.Visit("https://wap.gamersky.com/")
 	if err != nil {
@@ -292,22 +257,18 @@ func (gnc *NewsCrawler) crawlFirstPage() (int, error) {
 	// 等待所有请求完成
 	c.Wait()
 
+	if parseErr != nil {
+		return 0, parseErr
+	}
+
 	log.Printf("页面是否有加载更多按钮: %t", nextPageFound)
 	return count, nil
 }
 
 // saveNewsToDB 保存新闻到数据库
 func (gnc *NewsCrawler) saveNewsToDB(news *NewsInfo) error {
-	// 使用 INSERT OR IGNORE 来实现去重
-	sql := `
-	INSERT OR IGNORE INTO gamersky_news 
-	(sid, title, time, comment_num, url, image_url, topline_time, create_time)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`
-
-	_, err := gnc.db.Exec(sql,
-		news.SID, news.Title, news.Time, news.CommentNum,
-		news.URL, news.ImageURL, news.TopLineTime, news.CreateTime)
+	inserted, err := gnc.store.SaveNews(*news, gnc.config.Run.ID())
+	gnc.config.Run.RecordSave(inserted, err)
 
 	return err
 }
@@ -319,49 +280,13 @@ func (gnc *NewsCrawler) QueryNews(limit int) ([]NewsInfo, error) {
 
 // QueryNewsWithOffset 查询数据库中的新闻（支持偏移量）
 func (gnc *NewsCrawler) QueryNewsWithOffset(offset, limit int) ([]NewsInfo, error) {
-	var query string
-	var args []interface{}
-
-	if limit > 0 {
-		query = `
-		SELECT sid, title, time, comment_num, url, image_url, topline_time, create_time 
-		FROM gamersky_news 
-		ORDER BY create_time DESC 
-		LIMIT ? OFFSET ?`
-		args = append(args, limit, offset)
-	} else {
-		query = `
-		SELECT sid, title, time, comment_num, url, image_url, topline_time, create_time 
-		FROM gamersky_news 
-		ORDER BY create_time DESC`
-	}
-
-	rows, err := gnc.db.Query(query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var news []NewsInfo
-	for rows.Next() {
-		var item NewsInfo
-		err := rows.Scan(
-			&item.SID, &item.Title, &item.Time, &item.CommentNum,
-			&item.URL, &item.ImageURL, &item.TopLineTime, &item.CreateTime,
-		)
-		if err != nil {
-			return nil, err
-		}
-		news = append(news, item)
-	}
-
-	return news, nil
+	return gnc.store.QueryNews(offset, limit)
 }
 
 // Close 关闭数据库连接
 func (gnc *NewsCrawler) Close() error {
-	if gnc.db != nil {
-		return gnc.db.Close()
+	if gnc.store != nil {
+		return gnc.store.Close()
 	}
 	return nil
 }
@@ -0,0 +1,58 @@
+package gamersky
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 评论排序方式
+const (
+	OrderRecommended = "recommended" // 推荐排序，评论接口的默认排序
+	OrderLatest      = "latest"      // 按发表时间从新到旧
+	OrderHottest     = "hottest"     // 按点赞数从多到少
+)
+
+// CommentOrder 评论排序方式与评论接口 order 参数的对应关系
+type CommentOrder struct {
+	Name  string // 命令行和数据库中使用的名称
+	Label string // 中文名称
+	API   string // 评论接口的 order 参数
+}
+
+// commentOrders 支持的评论排序方式，接口参数调整时修改该表即可
+var commentOrders = []CommentOrder{
+	{Name: OrderRecommended, Label: "推荐", API: "tuiJian"},
+	{Name: OrderLatest, Label: "最新", API: "zuiXin"},
+	{Name: OrderHottest, Label: "最热", API: "zuiRe"},
+}
+
+// CommentOrderNames 获取所有评论排序方式的名称，以逗号分隔
+func CommentOrderNames() string {
+	names := make([]string, 0, len(commentOrders))
+	for _, order := range commentOrders {
+		names = append(names, order.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// LookupCommentOrder 按名称查找评论排序方式，名称为空时为推荐排序
+func LookupCommentOrder(name string) (CommentOrder, error) {
+	if name == "" {
+		name = OrderRecommended
+	}
+	for _, order := range commentOrders {
+		if order.Name == name {
+			return order, nil
+		}
+	}
+	return CommentOrder{}, fmt.Errorf("未知的评论排序方式: %s (可选: %s)", name, CommentOrderNames())
+}
+
+// CommentOrderLabel 获取评论排序方式的中文名称，未知的排序方式返回名称本身
+func CommentOrderLabel(name string) string {
+	order, err := LookupCommentOrder(name)
+	if err != nil {
+		return name
+	}
+	return order.Label
+}
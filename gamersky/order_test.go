@@ -0,0 +1,133 @@
+package gamersky
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bili-comment/source"
+	"bili-comment/store"
+)
+
+func TestLookupCommentOrder(t *testing.T) {
+	order, err := LookupCommentOrder("")
+	if err != nil || order.Name != OrderRecommended || order.API != "tuiJian" {
+		t.Errorf("默认排序 = %+v, %v, 期望推荐排序", order, err)
+	}
+	for _, name := range []string{OrderRecommended, OrderLatest, OrderHottest} {
+		if order, err := LookupCommentOrder(name); err != nil || order.Name != name || order.API == "" {
+			t.Errorf("LookupCommentOrder(%q) = %+v, %v", name, order, err)
+		}
+	}
+	if _, err := LookupCommentOrder("random"); err == nil {
+		t.Error("未知的排序方式应返回错误")
+	}
+}
+
+func TestSourceCommentOrder(t *testing.T) {
+	var requests []CommentAPIRequest
+	fakeCommentAPI(t, func(request CommentAPIRequest) string {
+		requests = append(requests, request)
+		return `{"errorCode":0,"result":{"commentsCount":0,"comments":[]}}`
+	})
+
+	src, err := source.Get(source.Gamersky)
+	if err != nil {
+		t.Fatal(err)
+	}
+	emit := func(interface{}) (bool, error) { return true, nil }
+
+	// 来源插件按名称查找排序方式，并传递最低点赞数
+	for _, tc := range []struct {
+		order      string
+		minPraises int
+		api        string
+	}{
+		{"", 0, "tuiJian"},
+		{OrderLatest, 0, "zuiXin"},
+		{OrderHottest, 10, "zuiRe"},
+	} {
+		requests = nil
+		opts := source.Options{Order: tc.order, MinPraises: tc.minPraises}
+		if _, err := src.FetchComments(context.Background(), "100", opts, emit); err != nil {
+			t.Errorf("排序 %q: 爬取评论失败: %v", tc.order, err)
+			continue
+		}
+		if len(requests) != 1 || requests[0].Order != tc.api || requests[0].MinPraisesCount != tc.minPraises {
+			t.Errorf("排序 %q: 请求 = %+v, 期望 order=%s、minPraisesCount=%d", tc.order, requests, tc.api, tc.minPraises)
+		}
+	}
+
+	if _, err := src.FetchComments(context.Background(), "100", source.Options{Order: "hot"}, emit); err == nil {
+		t.Error("未知的排序方式应返回错误")
+	}
+}
+
+func TestSourceSavesCommentOrder(t *testing.T) {
+	fakeCommentAPI(t, func(CommentAPIRequest) string { return commentsPageBody(1, 3, 3) })
+
+	st, err := store.OpenSQLite(filepath.Join(t.TempDir(), "crawl.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	// 与 crawl 命令相同，插件输出的记录逐条经 SaveRecord 写入存储
+	src, err := source.Get(source.Gamersky)
+	if err != nil {
+		t.Fatal(err)
+	}
+	emit := func(record interface{}) (bool, error) {
+		return store.SaveRecord(st, record, 1)
+	}
+	if _, err := src.FetchComments(context.Background(), "100", source.Options{Order: OrderLatest}, emit); err != nil {
+		t.Fatalf("爬取评论失败: %v", err)
+	}
+
+	rows, err := st.DB().Query("SELECT comment_id, order_mode, rank FROM gamersky_comment_orders WHERE article_id = '100' ORDER BY rank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var orders []string
+	for rows.Next() {
+		var commentID int64
+		var mode string
+		var rank int
+		if err := rows.Scan(&commentID, &mode, &rank); err != nil {
+			t.Fatal(err)
+		}
+		orders = append(orders, fmt.Sprintf("%d:%s:%d", commentID, mode, rank))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(orders); got != "[100:latest:1 101:latest:2 102:latest:3]" {
+		t.Errorf("排序记录 = %s, 期望 3 条一级评论按最新排序的名次", got)
+	}
+}
+
+func TestBeforeTimeRange(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	gcc := &CommentCrawler{config: &Config{Since: since}}
+	latest, _ := LookupCommentOrder(OrderLatest)
+	recommended, _ := LookupCommentOrder(OrderRecommended)
+
+	older := &commentsPage{oldest: since.Add(-time.Minute)}
+	newer := &commentsPage{oldest: since.Add(time.Minute)}
+
+	if !gcc.beforeTimeRange(latest, older) {
+		t.Error("最新排序下评论早于 Since 时应停止翻页")
+	}
+	if gcc.beforeTimeRange(latest, newer) {
+		t.Error("最新排序下评论仍在范围内时不应停止翻页")
+	}
+	if gcc.beforeTimeRange(recommended, older) {
+		t.Error("推荐排序不按时间排列，不应提前停止翻页")
+	}
+	if (&CommentCrawler{config: &Config{}}).beforeTimeRange(latest, older) {
+		t.Error("没有设置 Since 时不应提前停止翻页")
+	}
+}
@@ -0,0 +1,140 @@
+package gamersky
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"bili-comment/httpclient"
+)
+
+// replyAPIURL 评论回复接口地址，请求格式与评论接口相同
+var replyAPIURL = "https://cm.gamersky.com/appapi/GetCommentRepliesWithClubStyle"
+
+// repliesPageSize 回复接口每页的回复数
+const repliesPageSize = 20
+
+// ReplyAPIRequest 回复接口请求结构体
+type ReplyAPIRequest struct {
+	CommentID int64 `json:"commentId"`
+	PageIndex int   `json:"pageIndex"`
+	PageSize  int   `json:"pageSize"`
+}
+
+// ReplyAPIResponse 回复接口响应结构体
+type ReplyAPIResponse struct {
+	ErrorCode    int    `json:"errorCode"`
+	ErrorMessage string `json:"errorMessage"`
+	Result       struct {
+		RepliesCount int        `json:"repliesCount"`
+		Replies      []APIReply `json:"replies"`
+	} `json:"result"`
+}
+
+// crawlReplies 通过回复接口翻页获取一级评论的全部回复，返回保存的回复数
+// seen 为评论接口中已附带的回复ID，翻页时跳过这些回复；获取到 total 条或没有新回复时停止
+func (gcc *CommentCrawler) crawlReplies(ctx context.Context, articleID string, rootID int64, total int, seen map[int64]bool) (int, error) {
+	log.Printf("评论 %d 共 %d 条回复，已附带 %d 条，获取其余回复...", rootID, total, len(seen))
+
+	saved := 0
+	for page := 1; len(seen) < total; page++ {
+		if err := httpclient.Sleep(ctx, gcc.config.RequestDelay); err != nil {
+			return saved, err
+		}
+
+		requestData := ReplyAPIRequest{
+			CommentID: rootID,
+			PageIndex: page,
+			PageSize:  repliesPageSize,
+		}
+
+		var apiResponse ReplyAPIResponse
+		if err := gcc.callAPI(ctx, replyAPIURL, requestData, &apiResponse); err != nil {
+			return saved, fmt.Errorf("回复第 %d 页: %v", page, err)
+		}
+		if apiResponse.ErrorCode != 0 {
+			return saved, fmt.Errorf("回复第 %d 页: API错误: %s (代码: %d)", page, apiResponse.ErrorMessage, apiResponse.ErrorCode)
+		}
+
+		replies := apiResponse.Result.Replies
+		newReplies := 0
+		for _, reply := range replies {
+			if seen[reply.ReplyID] {
+				continue
+			}
+			seen[reply.ReplyID] = true
+			newReplies++
+
+			if gcc.saveReply(articleID, rootID, reply) {
+				saved++
+			}
+		}
+
+		// 回复在爬取期间被删除时总数会少于 total
+		if newReplies == 0 || len(replies) < repliesPageSize {
+			break
+		}
+	}
+
+	if len(seen) < total {
+		log.Printf("评论 %d 获取到 %d/%d 条回复，其余回复可能已被删除", rootID, len(seen), total)
+	}
+	return saved, nil
+}
+
+// saveReply 保存一条回复，返回是否保存成功；无效或时间范围外的回复不保存
+func (gcc *CommentCrawler) saveReply(articleID string, rootID int64, reply APIReply) bool {
+	// 跳过无效的回复数据
+	if reply.ReplyID == 0 || reply.UserName == "" {
+		log.Printf("跳过无效回复数据: ID=%d, UserName=%s", reply.ReplyID, reply.UserName)
+		return false
+	}
+	if !gcc.config.inTimeRange(time.UnixMilli(reply.CreateTime)) {
+		return false
+	}
+
+	if err := gcc.saveCommentToDB(newReplyComment(articleID, rootID, reply)); err != nil {
+		log.Printf("保存回复失败 (ID: %d): %v", reply.ReplyID, err)
+		return false
+	}
+	log.Printf("保存回复: %d - %s", reply.ReplyID, reply.UserName)
+	return true
+}
+
+// newReplyComment 将回复转换为评论结构
+// ParentID 为所属的一级评论，AnswerToID 为被回复的评论或回复，接口未返回时为一级评论，
+// 沿 AnswerToID 可还原楼中楼的完整对话
+func newReplyComment(articleID string, rootID int64, reply APIReply) *Comment {
+	answerToID := reply.ObjectCommentID
+	if answerToID == 0 {
+		answerToID = rootID
+	}
+	commentTime := time.UnixMilli(reply.CreateTime).In(time.FixedZone("CST", 8*3600)).Format("2006-01-02 15:04:05")
+
+	return &Comment{
+		ID:                 reply.ReplyID,
+		ArticleID:          articleID,
+		UserID:             reply.UserID,
+		Username:           reply.UserName,
+		Content:            reply.ReplyContent,
+		CommentTime:        commentTime,
+		SupportCount:       reply.PraisesCount,
+		ReplyCount:         0,                    // 二级评论通常没有回复数
+		ParentID:           rootID,               // 所属的一级评论ID
+		AnswerToID:         answerToID,           // 被回复的评论ID
+		AnswerToName:       reply.ObjectUserName, // 被回复用户名
+		UserAvatar:         reply.UserHeadImageURL,
+		UserLevel:          reply.UserLevel,
+		IPLocation:         reply.IPLocation,
+		DeviceName:         reply.DeviceName,
+		FloorNumber:        0,     // 回复通常没有楼层号
+		IsTuijian:          false, // 回复结构中没有此字段
+		IsAuthor:           reply.IsAuthor,
+		IsBest:             false, // 回复结构中没有此字段
+		UserAuthentication: reply.UserAuthentication,
+		UserGroupID:        reply.UserGroupID,
+		ThirdPlatformBound: reply.ThirdPlatformBound,
+		CreateTime:         commentTime,
+	}
+}
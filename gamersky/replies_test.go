@@ -0,0 +1,95 @@
+package gamersky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bili-comment/store"
+)
+
+func TestCrawlReplies(t *testing.T) {
+	body := readSnapshot(t, "gamersky-replies.json")
+	var requests []ReplyAPIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request ReplyAPIRequest
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("request")), &request); err != nil {
+			t.Errorf("解析请求参数失败: %v", err)
+		}
+		requests = append(requests, request)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	defer func(api string) { replyAPIURL = api }(replyAPIURL)
+	replyAPIURL = server.URL
+
+	st := store.NewMemory()
+	crawler := NewCommentCrawlerWithStore(&Config{}, st)
+
+	// 评论接口已附带回复 501，其余回复通过回复接口获取
+	seen := map[int64]bool{501: true}
+	saved, err := crawler.crawlReplies(context.Background(), "100", 500, 4, seen)
+	if err != nil {
+		t.Fatalf("获取回复失败: %v", err)
+	}
+	if saved != 3 {
+		t.Errorf("保存 %d 条回复, 期望 3", saved)
+	}
+
+	// 回复不足一页，只请求第 1 页
+	if len(requests) != 1 {
+		t.Fatalf("请求 %d 次, 期望 1: %+v", len(requests), requests)
+	}
+	if r := requests[0]; r.CommentID != 500 || r.PageIndex != 1 || r.PageSize != repliesPageSize {
+		t.Errorf("请求参数 = %+v", r)
+	}
+
+	comments, err := st.QueryGamerskyComments(store.CommentFilter{ArticleID: "100"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replies := make(map[int64]int)
+	for i, comment := range comments {
+		replies[comment.ID] = i
+	}
+	if _, ok := replies[501]; ok || len(comments) != 3 {
+		t.Fatalf("保存的回复 = %v, 期望 502、503、504", replies)
+	}
+
+	// 沿 AnswerToID 还原对话：502 回复楼主，503 回复 502，504 回复 503
+	for _, want := range []struct {
+		id, answerToID int64
+		answerToName   string
+		username       string
+		commentTime    string
+	}{
+		{502, 500, "", "玩家乙", "2025-01-02 09:30:00"},
+		{503, 502, "玩家乙", "玩家丙", "2025-01-02 09:35:00"},
+		{504, 503, "玩家丙", "玩家乙", "2025-01-02 09:40:00"},
+	} {
+		i, ok := replies[want.id]
+		if !ok {
+			t.Errorf("回复 %d 没有保存", want.id)
+			continue
+		}
+		got := comments[i]
+		if got.ParentID != 500 || got.AnswerToID != want.answerToID || got.AnswerToName != want.answerToName {
+			t.Errorf("回复 %d: ParentID %d, AnswerToID %d, AnswerToName %q, 期望 500、%d、%q",
+				want.id, got.ParentID, got.AnswerToID, got.AnswerToName, want.answerToID, want.answerToName)
+		}
+		if got.Username != want.username || got.CommentTime != want.commentTime {
+			t.Errorf("回复 %d: 用户 %q, 时间 %q, 期望 %q、%q", want.id, got.Username, got.CommentTime, want.username, want.commentTime)
+		}
+	}
+
+	// 回复的作者、设备等字段原样保存
+	if got := comments[replies[502]]; got.UserLevel != 8 || got.DeviceName != "iPhone" || got.IPLocation != "上海" || got.SupportCount != 2 {
+		t.Errorf("回复 502 = %+v", got)
+	}
+	if got := comments[replies[503]]; !got.IsAuthor {
+		t.Errorf("回复 503 的 IsAuthor = false, 期望 true")
+	}
+}
@@ -0,0 +1,273 @@
+package gamersky
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"bili-comment/model"
+	"bili-comment/store"
+)
+
+// 默认的评论重爬策略
+const (
+	DefaultRecrawlMaxAge      = 7 * 24 * time.Hour
+	DefaultRecrawlMinInterval = 30 * time.Minute
+	DefaultRecrawlMaxInterval = 24 * time.Hour
+	DefaultRecrawlBudget      = 200
+)
+
+// 重爬间隔的计算参数
+const (
+	recrawlAgeDivisor = 4  // 基础重爬间隔为新闻年龄的 1/4
+	recrawlGrowthUnit = 10 // 上次爬取后每新增这么多条评论，重爬间隔缩短一倍
+)
+
+// CrawlState 新闻评论的爬取状态
+type CrawlState = model.GamerskyCrawlState
+
+// RecrawlPolicy 评论重爬策略
+// 新闻越新、上次爬取后评论数增长越多，重爬间隔越短，间隔限制在 [MinInterval, MaxInterval] 内
+type RecrawlPolicy struct {
+	MaxAge       time.Duration // 新闻发布超过该时间后不再重爬评论，<=0 时不限制
+	MinInterval  time.Duration // 最热文章的重爬间隔
+	MaxInterval  time.Duration // 最冷文章的重爬间隔，<=0 时每次运行都重爬
+	Budget       int           // 每次运行最多请求评论接口的次数（包括重试和回复翻页），<=0 时不限制
+	CommentPages int           // 每篇文章最多爬取的评论页数，<=0 时按评论数爬取全部页面
+}
+
+// DefaultRecrawlPolicy 获取默认的评论重爬策略
+func DefaultRecrawlPolicy() RecrawlPolicy {
+	return RecrawlPolicy{
+		MaxAge:      DefaultRecrawlMaxAge,
+		MinInterval: DefaultRecrawlMinInterval,
+		MaxInterval: DefaultRecrawlMaxInterval,
+		Budget:      DefaultRecrawlBudget,
+	}
+}
+
+// RecrawlTask 一篇需要重爬评论的文章
+type RecrawlTask struct {
+	News     NewsInfo
+	Pages    int           // 本次最多爬取的评论页数，<=0 表示爬取全部页面
+	Age      time.Duration // 新闻发布至今的时间
+	Interval time.Duration // 按热度计算的重爬间隔
+	Growth   int           // 上次爬取后新增的评论数，从未爬取时为当前评论数
+	Score    float64       // 优先级，越大越先爬取
+}
+
+// RecrawlPlan 一次运行的评论重爬计划
+type RecrawlPlan struct {
+	Tasks    []RecrawlTask // 按优先级排序的待爬取文章
+	Tracked  int           // 未超过最大年龄、仍在跟踪的新闻数
+	Due      int           // 已到重爬时间的新闻数
+	Deferred int           // 已到重爬时间但超出预算、推迟到下次运行的新闻数
+	Pages    int           // 计划请求的评论页数（按评论数估算，运行时按实际请求数扣除预算）
+}
+
+// PlanRecrawl 根据新闻年龄和评论数增长安排本次运行需要重爬评论的文章
+// 从未爬取过评论的新闻优先；已爬取的新闻在距上次爬取超过重爬间隔后到期，按超期程度和评论增长排序。
+// 按评论数估算每篇文章的页数，累计超过预算后的文章推迟到下次运行；
+// 重试和回复翻页也会消耗预算，运行时由 RecrawlScheduler.Allow 和 Charge 按实际请求数扣除
+func PlanRecrawl(news []NewsInfo, states map[string]CrawlState, policy RecrawlPolicy, now time.Time) *RecrawlPlan {
+	plan := &RecrawlPlan{}
+
+	var due []RecrawlTask
+	for _, item := range news {
+		age := newsAge(item, now)
+		if policy.MaxAge > 0 && age > policy.MaxAge {
+			continue
+		}
+		plan.Tracked++
+
+		task := RecrawlTask{News: item, Age: age}
+		state, crawled := states[item.SID]
+		lastCrawl, err := time.ParseInLocation("2006-01-02 15:04:05", state.CrawledAt, time.Local)
+		if !crawled || err != nil {
+			// 从未爬取过评论：优先级高于所有已爬取的新闻，评论多的先爬取
+			task.Growth = item.CommentNum
+			task.Score = math.Inf(1)
+			due = append(due, task)
+			continue
+		}
+
+		task.Growth = item.CommentNum - state.CommentNum
+		if task.Growth < 0 {
+			task.Growth = 0
+		}
+		task.Interval = policy.interval(age, task.Growth)
+
+		elapsed := now.Sub(lastCrawl)
+		if elapsed < task.Interval {
+			continue
+		}
+		// 超期越久、评论增长越多越先爬取
+		overdue := 1.0
+		if task.Interval > 0 {
+			overdue = float64(elapsed) / float64(task.Interval)
+		}
+		task.Score = overdue * float64(1+task.Growth)
+		due = append(due, task)
+	}
+	plan.Due = len(due)
+
+	sort.SliceStable(due, func(i, j int) bool {
+		if due[i].Score != due[j].Score {
+			return due[i].Score > due[j].Score
+		}
+		return due[i].Growth > due[j].Growth
+	})
+
+	for _, task := range due {
+		pages := policy.estimatePages(task.News)
+		if policy.Budget > 0 {
+			// 有预算时按估算的页数限制爬取，保证请求数不超过预算
+			remaining := policy.Budget - plan.Pages
+			if remaining <= 0 {
+				plan.Deferred++
+				continue
+			}
+			task.Pages = min(pages, remaining)
+			plan.Pages += task.Pages
+		} else {
+			task.Pages = policy.CommentPages
+			plan.Pages += pages
+		}
+		plan.Tasks = append(plan.Tasks, task)
+	}
+
+	return plan
+}
+
+// interval 计算重爬间隔：基础间隔为新闻年龄的 1/4，上次爬取后每新增 recrawlGrowthUnit 条评论缩短一倍
+func (p RecrawlPolicy) interval(age time.Duration, growth int) time.Duration {
+	if p.MaxInterval <= 0 {
+		return 0
+	}
+
+	interval := age / recrawlAgeDivisor
+	interval = time.Duration(float64(interval) / math.Pow(2, float64(growth)/recrawlGrowthUnit))
+
+	if interval < p.MinInterval {
+		interval = p.MinInterval
+	}
+	if interval > p.MaxInterval {
+		interval = p.MaxInterval
+	}
+	return interval
+}
+
+// estimatePages 按新闻的评论数估算需要请求的评论页数，至少1页，不超过每篇文章的页数限制
+func (p RecrawlPolicy) estimatePages(news NewsInfo) int {
+	pages := (news.CommentNum + commentPageSize - 1) / commentPageSize
+	if pages < 1 {
+		pages = 1
+	}
+	if p.CommentPages > 0 {
+		return min(pages, p.CommentPages)
+	}
+	return pages
+}
+
+// newsAge 新闻发布至今的时间，没有发布时间时按首次记录时间计算，都无法解析时视为刚记录
+func newsAge(news NewsInfo, now time.Time) time.Duration {
+	created, err := time.ParseInLocation("2006-01-02 15:04:05", news.PublishedAt, time.FixedZone("CST", 8*3600))
+	if err != nil {
+		created, err = time.ParseInLocation("2006-01-02 15:04:05", news.CreateTime, time.Local)
+	}
+	if err != nil || created.After(now) {
+		return 0
+	}
+	return now.Sub(created)
+}
+
+// recrawlStore 调度器需要的存储：读取新闻，读写评论爬取状态
+type recrawlStore interface {
+	store.NewsStore
+	store.CrawlStateStore
+}
+
+// RecrawlScheduler 评论重爬调度器，按新闻和评论爬取状态安排重爬，并记录爬取结果
+type RecrawlScheduler struct {
+	store  recrawlStore
+	config *Config
+	policy RecrawlPolicy
+	spent  int // 本次运行已请求评论接口的次数
+}
+
+// NewRecrawlScheduler 创建评论重爬调度器，存储由配置中的DSN决定
+func NewRecrawlScheduler(config *Config, policy RecrawlPolicy) (*RecrawlScheduler, error) {
+	// 初始化存储
+	st, err := config.openStore()
+	if err != nil {
+		return nil, fmt.Errorf("数据库连接失败: %v", err)
+	}
+
+	return NewRecrawlSchedulerWithStore(config, policy, st), nil
+}
+
+// NewRecrawlSchedulerWithStore 使用指定存储创建评论重爬调度器
+func NewRecrawlSchedulerWithStore(config *Config, policy RecrawlPolicy, st recrawlStore) *RecrawlScheduler {
+	return &RecrawlScheduler{
+		store:  st,
+		config: config,
+		policy: policy,
+	}
+}
+
+// Plan 读取存储中的全部新闻和评论爬取状态，生成本次运行的重爬计划
+func (rs *RecrawlScheduler) Plan(now time.Time) (*RecrawlPlan, error) {
+	news, err := rs.store.QueryNews(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("查询新闻失败: %v", err)
+	}
+	states, err := rs.store.QueryCrawlStates()
+	if err != nil {
+		return nil, fmt.Errorf("查询评论爬取状态失败: %v", err)
+	}
+	return PlanRecrawl(news, states, rs.policy, now), nil
+}
+
+// MarkCrawled 记录新闻评论的爬取时间和当时的评论数，作为下次计算评论增长的起点
+func (rs *RecrawlScheduler) MarkCrawled(news NewsInfo, crawledAt time.Time) error {
+	state := CrawlState{
+		SID:        news.SID,
+		CommentNum: news.CommentNum,
+		CrawledAt:  crawledAt.Format("2006-01-02 15:04:05"),
+	}
+	return rs.store.SaveCrawlState(state, rs.config.Run.ID())
+}
+
+// Allow 按剩余预算返回文章本次最多爬取的页数，预算用完时返回false
+func (rs *RecrawlScheduler) Allow(task RecrawlTask) (int, bool) {
+	if rs.policy.Budget <= 0 {
+		return task.Pages, true
+	}
+	remaining := rs.policy.Budget - rs.spent
+	if remaining <= 0 {
+		return 0, false
+	}
+	if task.Pages <= 0 {
+		return remaining, true
+	}
+	return min(task.Pages, remaining), true
+}
+
+// Charge 从预算中扣除爬取一篇文章实际发出的请求数
+func (rs *RecrawlScheduler) Charge(requests int) {
+	rs.spent += requests
+}
+
+// Spent 获取本次运行已请求评论接口的次数
+func (rs *RecrawlScheduler) Spent() int {
+	return rs.spent
+}
+
+// Close 关闭数据库连接
+func (rs *RecrawlScheduler) Close() error {
+	if rs.store != nil {
+		return rs.store.Close()
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package gamersky
+
+import (
+	"testing"
+	"time"
+
+	"bili-comment/store"
+)
+
+// scheduleNow 调度测试使用的当前时间
+var scheduleNow = time.Date(2025, 4, 10, 12, 0, 0, 0, time.Local)
+
+// recordedAgo 构造在 ago 之前首次记录的新闻
+func recordedAgo(sid string, ago time.Duration, commentNum int) NewsInfo {
+	return NewsInfo{
+		SID:        sid,
+		CommentNum: commentNum,
+		CreateTime: scheduleNow.Add(-ago).Format("2006-01-02 15:04:05"),
+	}
+}
+
+// crawledAgo 构造在 ago 之前爬取过评论的状态
+func crawledAgo(sid string, ago time.Duration, commentNum int) CrawlState {
+	return CrawlState{
+		SID:        sid,
+		CommentNum: commentNum,
+		CrawledAt:  scheduleNow.Add(-ago).Format("2006-01-02 15:04:05"),
+	}
+}
+
+func taskSIDs(plan *RecrawlPlan) []string {
+	sids := make([]string, 0, len(plan.Tasks))
+	for _, task := range plan.Tasks {
+		sids = append(sids, task.News.SID)
+	}
+	return sids
+}
+
+func TestPlanRecrawlPriority(t *testing.T) {
+	news := []NewsInfo{
+		recordedAgo("cold", 5*24*time.Hour, 100),   // 5天前，评论没有增长
+		recordedAgo("hot", 6*time.Hour, 300),       // 6小时前，评论增长200
+		recordedAgo("new", 10*time.Minute, 5),      // 从未爬取
+		recordedAgo("expired", 10*24*time.Hour, 9), // 超过最大年龄
+		recordedAgo("fresh", 3*time.Hour, 40),      // 刚爬取过，未到期
+	}
+	states := map[string]CrawlState{
+		"cold":    crawledAgo("cold", 2*time.Hour, 100),
+		"hot":     crawledAgo("hot", time.Hour, 100),
+		"expired": crawledAgo("expired", 48*time.Hour, 0),
+		"fresh":   crawledAgo("fresh", 10*time.Minute, 40),
+	}
+
+	plan := PlanRecrawl(news, states, DefaultRecrawlPolicy(), scheduleNow)
+
+	if plan.Tracked != 4 {
+		t.Errorf("跟踪的新闻数 = %d, 期望 4", plan.Tracked)
+	}
+	// 冷门文章的间隔为24小时，2小时前爬取过，未到期
+	got := taskSIDs(plan)
+	if len(got) != 2 || got[0] != "new" || got[1] != "hot" {
+		t.Fatalf("计划 = %v, 期望 [new hot]", got)
+	}
+	if plan.Tasks[1].Growth != 200 || plan.Tasks[1].Interval != DefaultRecrawlMinInterval {
+		t.Errorf("热门文章增长 %d、间隔 %v, 期望 200 和 %v",
+			plan.Tasks[1].Growth, plan.Tasks[1].Interval, DefaultRecrawlMinInterval)
+	}
+
+	// 一天后冷门文章到期
+	plan = PlanRecrawl(news, states, DefaultRecrawlPolicy(), scheduleNow.Add(23*time.Hour))
+	found := false
+	for _, sid := range taskSIDs(plan) {
+		if sid == "cold" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("冷门文章超过最大间隔后应重爬: %v", taskSIDs(plan))
+	}
+}
+
+func TestPlanRecrawlBudget(t *testing.T) {
+	var news []NewsInfo
+	for _, sid := range []string{"a", "b", "c", "d"} {
+		// 每篇文章75条评论，估算4页
+		news = append(news, recordedAgo(sid, time.Hour, 75))
+	}
+
+	policy := DefaultRecrawlPolicy()
+	policy.Budget = 10
+	plan := PlanRecrawl(news, nil, policy, scheduleNow)
+
+	if plan.Pages != 10 {
+		t.Errorf("计划页数 = %d, 期望不超过预算 10", plan.Pages)
+	}
+	if len(plan.Tasks) != 3 || plan.Deferred != 1 {
+		t.Fatalf("计划 %d 条、推迟 %d 条, 期望 3 和 1", len(plan.Tasks), plan.Deferred)
+	}
+	if plan.Tasks[2].Pages != 2 {
+		t.Errorf("最后一篇文章的页数 = %d, 期望用完剩余预算 2", plan.Tasks[2].Pages)
+	}
+
+	// 每篇文章的页数限制优先于评论数估算
+	policy.CommentPages = 1
+	plan = PlanRecrawl(news, nil, policy, scheduleNow)
+	if len(plan.Tasks) != 4 || plan.Pages != 4 {
+		t.Errorf("计划 %d 条、%d 页, 期望 4 和 4", len(plan.Tasks), plan.Pages)
+	}
+}
+
+func TestRecrawlSchedulerBudget(t *testing.T) {
+	policy := DefaultRecrawlPolicy()
+	policy.Budget = 10
+	scheduler := NewRecrawlSchedulerWithStore(&Config{}, policy, store.NewMemory())
+	task := RecrawlTask{Pages: 4}
+
+	if pages, ok := scheduler.Allow(task); !ok || pages != 4 {
+		t.Errorf("Allow = %d, %t, 期望 4, true", pages, ok)
+	}
+
+	// 重试和回复翻页使实际请求数超过计划页数，剩余预算按实际请求数计算
+	scheduler.Charge(7)
+	if pages, ok := scheduler.Allow(task); !ok || pages != 3 {
+		t.Errorf("Allow = %d, %t, 期望用完剩余预算 3, true", pages, ok)
+	}
+
+	scheduler.Charge(3)
+	if _, ok := scheduler.Allow(task); ok {
+		t.Error("预算用完后 Allow 应返回 false")
+	}
+	if scheduler.Spent() != 10 {
+		t.Errorf("Spent = %d, 期望 10", scheduler.Spent())
+	}
+
+	// 不限制预算时按计划的页数爬取
+	policy.Budget = 0
+	scheduler = NewRecrawlSchedulerWithStore(&Config{}, policy, store.NewMemory())
+	scheduler.Charge(100)
+	if pages, ok := scheduler.Allow(task); !ok || pages != 4 {
+		t.Errorf("不限制预算时 Allow = %d, %t, 期望 4, true", pages, ok)
+	}
+}
@@ -0,0 +1,113 @@
+package gamersky
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"bili-comment/source"
+)
+
+// gamerskySource Gamersky评论来源插件：条目为新闻列表，评论按文章ID爬取
+type gamerskySource struct{}
+
+func init() {
+	source.Register(gamerskySource{})
+}
+
+func (gamerskySource) Name() string {
+	return source.Gamersky
+}
+
+func (gamerskySource) Description() string {
+	return "Gamersky游戏天空文章评论，条目为首页新闻列表"
+}
+
+func (gamerskySource) DefaultOutputPath() string {
+	return DefaultOutputPath
+}
+
+// config 根据通用参数创建爬虫配置
+func (gamerskySource) config(opts source.Options) (*Config, error) {
+	order, err := LookupCommentOrder(opts.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err := LookupChannel(opts.Channel); err != nil {
+		return nil, err
+	}
+
+	return &Config{
+		Channel:      opts.Channel,
+		RequestDelay: opts.RequestDelay,
+		Run:          opts.Run,
+		Since:        opts.Since,
+		Until:        opts.Until,
+		CommentOrder: order.Name,
+		MinPraises:   opts.MinPraises,
+	}, nil
+}
+
+func (s gamerskySource) ListItems(ctx context.Context, query string, page int, opts source.Options) ([]interface{}, error) {
+	if query != "" {
+		return nil, fmt.Errorf("Gamersky新闻列表不支持关键词搜索")
+	}
+
+	config, err := s.config(opts)
+	if err != nil {
+		return nil, err
+	}
+	// 条目由调用方保存并登记运行记录
+	config.Run = nil
+
+	emit, collected := source.Collect()
+	crawlerInstance := NewNewsCrawlerWithStore(config, source.NewEmitStore(emit))
+	if _, err := crawlerInstance.CrawlNews(ctx, page); err != nil {
+		return nil, err
+	}
+	return collected(), nil
+}
+
+func (s gamerskySource) FetchComments(ctx context.Context, itemID string, opts source.Options, emit source.EmitFunc) (int, error) {
+	config, err := s.config(opts)
+	if err != nil {
+		return 0, err
+	}
+
+	crawlerInstance := NewCommentCrawlerWithStore(config, source.NewEmitStore(emit))
+	return crawlerInstance.CrawlComments(ctx, itemID, opts.Pages)
+}
+
+func (gamerskySource) Normalize(record interface{}) (source.Entry, error) {
+	switch r := record.(type) {
+	case Comment:
+		entry := source.Entry{
+			Source:  source.Gamersky,
+			Kind:    source.KindComment,
+			ID:      strconv.FormatInt(r.ID, 10),
+			ItemID:  r.ArticleID,
+			Author:  r.Username,
+			Content: r.Content,
+			Time:    r.CommentTime,
+			Likes:   int64(r.SupportCount),
+			Replies: int64(r.ReplyCount),
+		}
+		if r.ParentID != 0 {
+			entry.ParentID = strconv.FormatInt(r.ParentID, 10)
+		}
+		return entry, nil
+	case NewsInfo:
+		return source.Entry{
+			Source:  source.Gamersky,
+			Kind:    source.KindItem,
+			ID:      r.SID,
+			Title:   r.Title,
+			Time:    r.Time,
+			Replies: int64(r.CommentNum),
+			URL:     r.URL,
+		}, nil
+	default:
+		return source.Entry{}, fmt.Errorf("Gamersky来源不支持的记录类型: %T", record)
+	}
+}
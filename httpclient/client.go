@@ -0,0 +1,78 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout 默认请求超时时间
+const DefaultTimeout = 30 * time.Second
+
+var (
+	mu            sync.RWMutex
+	defaultClient = newClient(http.DefaultTransport)
+	requestCount  int64
+)
+
+// countingTransport 统计经过共享客户端发出的请求数
+type countingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip 计数后交给底层传输层发送请求
+func (ct *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&requestCount, 1)
+	return ct.base.RoundTrip(req)
+}
+
+// newClient 创建带请求计数的HTTP客户端
+func newClient(transport http.RoundTripper) *http.Client {
+	return &http.Client{
+		Timeout:   DefaultTimeout,
+		Transport: &countingTransport{base: transport},
+	}
+}
+
+// Default 获取所有爬虫共享的HTTP客户端
+func Default() *http.Client {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultClient
+}
+
+// SetDefault 替换共享的HTTP客户端（用于配置代理或在测试中指向本地服务）
+func SetDefault(client *http.Client) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultClient = client
+}
+
+// UseProxyPool 让共享客户端通过代理池发送请求
+func UseProxyPool(pool *ProxyPool) {
+	SetDefault(newClient(pool))
+}
+
+// RequestCount 获取进程启动以来通过共享客户端发出的请求总数
+func RequestCount() int64 {
+	return atomic.LoadInt64(&requestCount)
+}
+
+// Sleep 等待指定时间后再发下一个请求，context取消时提前返回其错误
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,275 @@
+package httpclient
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxFailures 代理连续失败多少次后被剔除
+const DefaultMaxFailures = 3
+
+// DefaultHealthCheckURL 代理健康检查使用的地址
+const DefaultHealthCheckURL = "https://www.bilibili.com/robots.txt"
+
+// proxyEntry 代理池中的单个代理
+type proxyEntry struct {
+	url       *url.URL
+	transport *http.Transport
+	failures  int  // 连续失败次数
+	total     int  // 累计失败次数
+	evicted   bool // 是否已被剔除
+}
+
+// ProxyStat 代理状态统计
+type ProxyStat struct {
+	URL      string // 代理地址
+	Failures int    // 连续失败次数
+	Total    int    // 累计失败次数
+	Evicted  bool   // 是否已被剔除
+}
+
+// ProxyPool 代理池，按轮询方式分配代理并统计失败次数
+// 同时实现 http.RoundTripper，可直接用于 net/http 客户端和 colly 收集器
+type ProxyPool struct {
+	mu          sync.Mutex
+	entries     []*proxyEntry
+	next        int
+	maxFailures int
+}
+
+// ParseProxy 解析代理地址，支持 http、https、socks5 协议，未指定协议时默认为 http
+func ParseProxy(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("代理地址无效 %s: %v", raw, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("不支持的代理协议: %s", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("代理地址缺少主机: %s", raw)
+	}
+
+	return u, nil
+}
+
+// LoadProxyFile 从文件读取代理列表，每行一个，忽略空行和 # 开头的注释
+func LoadProxyFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var proxies []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		proxies = append(proxies, line)
+	}
+
+	return proxies, scanner.Err()
+}
+
+// NewProxyPool 创建代理池，maxFailures<=0 时使用默认值
+func NewProxyPool(proxies []string, maxFailures int) (*ProxyPool, error) {
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("代理列表为空")
+	}
+
+	if maxFailures <= 0 {
+		maxFailures = DefaultMaxFailures
+	}
+
+	pool := &ProxyPool{maxFailures: maxFailures}
+	for _, raw := range proxies {
+		u, err := ParseProxy(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		pool.entries = append(pool.entries, &proxyEntry{
+			url: u,
+			transport: &http.Transport{
+				Proxy:                 http.ProxyURL(u),
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: DefaultTimeout,
+				IdleConnTimeout:       90 * time.Second,
+				MaxIdleConnsPerHost:   4,
+			},
+		})
+	}
+
+	return pool, nil
+}
+
+// pick 轮询选取一个未被剔除的代理
+func (pp *ProxyPool) pick() (*proxyEntry, error) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	for i := 0; i < len(pp.entries); i++ {
+		entry := pp.entries[(pp.next+i)%len(pp.entries)]
+		if !entry.evicted {
+			pp.next = (pp.next + i + 1) % len(pp.entries)
+			return entry, nil
+		}
+	}
+
+	return nil, fmt.Errorf("没有可用的代理（共 %d 个，均已被剔除）", len(pp.entries))
+}
+
+// markFailure 记录代理失败，连续失败达到上限或 evict 为 true 时剔除
+func (pp *ProxyPool) markFailure(entry *proxyEntry, reason string, evict bool) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	entry.failures++
+	entry.total++
+	if !entry.evicted && (evict || entry.failures >= pp.maxFailures) {
+		entry.evicted = true
+		entry.transport.CloseIdleConnections()
+		log.Printf("代理 %s 失败 %d 次，已剔除: %s", entry.url.Redacted(), entry.failures, reason)
+	}
+}
+
+// markSuccess 记录代理成功，重置连续失败次数
+func (pp *ProxyPool) markSuccess(entry *proxyEntry) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	entry.failures = 0
+	if entry.evicted {
+		entry.evicted = false
+		log.Printf("代理 %s 已恢复", entry.url.Redacted())
+	}
+}
+
+// RoundTrip 通过代理池中的代理发送请求
+func (pp *ProxyPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	entry, err := pp.pick()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := entry.transport.RoundTrip(req)
+	if err != nil {
+		pp.markFailure(entry, err.Error(), false)
+		return nil, err
+	}
+
+	// 代理认证失败视为代理失败；502/504 等来自目标站点的错误不计入，避免目标站点不稳定时剔除正常的代理
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		pp.markFailure(entry, resp.Status, false)
+	} else {
+		pp.markSuccess(entry)
+	}
+
+	return resp, nil
+}
+
+// CheckHealth 通过每个代理访问 testURL，成功则恢复，失败则立即剔除
+// 健康检查是专门的探测请求，一次失败即可判断代理不可用，剔除的代理在之后的健康检查成功时恢复
+func (pp *ProxyPool) CheckHealth(testURL string) {
+	if testURL == "" {
+		testURL = DefaultHealthCheckURL
+	}
+
+	pp.mu.Lock()
+	entries := append([]*proxyEntry(nil), pp.entries...)
+	pp.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, entry := range entries {
+		wg.Add(1)
+		go func(entry *proxyEntry) {
+			defer wg.Done()
+
+			client := &http.Client{Transport: entry.transport, Timeout: 10 * time.Second}
+			resp, err := client.Get(testURL)
+			if err != nil {
+				pp.markFailure(entry, err.Error(), true)
+				return
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode == http.StatusProxyAuthRequired {
+				pp.markFailure(entry, resp.Status, true)
+				return
+			}
+			pp.markSuccess(entry)
+		}(entry)
+	}
+	wg.Wait()
+}
+
+// StartHealthCheck 按固定间隔执行健康检查，返回停止函数
+func (pp *ProxyPool) StartHealthCheck(interval time.Duration, testURL string) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				pp.CheckHealth(testURL)
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// Stats 获取代理池中各代理的状态
+func (pp *ProxyPool) Stats() []ProxyStat {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	stats := make([]ProxyStat, 0, len(pp.entries))
+	for _, entry := range pp.entries {
+		stats = append(stats, ProxyStat{
+			URL:      entry.url.Redacted(),
+			Failures: entry.failures,
+			Total:    entry.total,
+			Evicted:  entry.evicted,
+		})
+	}
+	return stats
+}
+
+// Available 获取未被剔除的代理数量
+func (pp *ProxyPool) Available() int {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	count := 0
+	for _, entry := range pp.entries {
+		if !entry.evicted {
+			count++
+		}
+	}
+	return count
+}
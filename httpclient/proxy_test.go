@@ -0,0 +1,138 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeProxy 模拟HTTP代理：直接以 status 响应转发过来的请求，响应内容为代理名称
+func fakeProxy(t *testing.T, name string, status *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+		io.WriteString(w, name)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// statusOf 创建初始为 code 的状态码
+func statusOf(code int) *atomic.Int32 {
+	status := &atomic.Int32{}
+	status.Store(int32(code))
+	return status
+}
+
+// get 通过代理池请求目标地址，返回响应内容
+func get(t *testing.T, pool *ProxyPool) (string, error) {
+	t.Helper()
+	req, _ := http.NewRequest("GET", "http://origin.test/", nil)
+	resp, err := pool.RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body), nil
+}
+
+func TestProxyPoolRoundRobin(t *testing.T) {
+	a := fakeProxy(t, "a", statusOf(http.StatusOK))
+	b := fakeProxy(t, "b", statusOf(http.StatusOK))
+	pool, err := NewProxyPool([]string{a.URL, b.URL}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var order []string
+	for i := 0; i < 4; i++ {
+		name, err := get(t, pool)
+		if err != nil {
+			t.Fatal(err)
+		}
+		order = append(order, name)
+	}
+	if got := order[0] + order[1] + order[2] + order[3]; got != "abab" {
+		t.Errorf("轮询顺序 = %s, 期望 abab", got)
+	}
+}
+
+func TestProxyPoolEvictionAndRevival(t *testing.T) {
+	good := fakeProxy(t, "good", statusOf(http.StatusOK))
+	badStatus := statusOf(http.StatusProxyAuthRequired)
+	bad := fakeProxy(t, "bad", badStatus)
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close() // 连接被拒绝
+
+	pool, err := NewProxyPool([]string{good.URL, bad.URL, dead.URL}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 每个代理轮到两次后，407和连接失败的代理被剔除
+	for i := 0; i < 6; i++ {
+		get(t, pool)
+	}
+	if pool.Available() != 1 {
+		t.Fatalf("可用代理数 = %d, 期望 1: %+v", pool.Available(), pool.Stats())
+	}
+	for i := 0; i < 3; i++ {
+		if name, err := get(t, pool); err != nil || name != "good" {
+			t.Errorf("剔除后请求 = %q, %v, 期望只使用 good", name, err)
+		}
+	}
+
+	// 代理恢复后健康检查重新启用
+	badStatus.Store(http.StatusOK)
+	pool.CheckHealth("http://origin.test/robots.txt")
+	stats := pool.Stats()
+	if stats[1].Evicted || stats[1].Failures != 0 {
+		t.Errorf("健康检查后 bad 代理状态 = %+v, 期望已恢复", stats[1])
+	}
+	if !stats[2].Evicted {
+		t.Errorf("无法连接的代理不应恢复: %+v", stats[2])
+	}
+}
+
+func TestProxyPoolIgnoresOriginErrors(t *testing.T) {
+	flaky := fakeProxy(t, "flaky", statusOf(http.StatusBadGateway))
+	pool, err := NewProxyPool([]string{flaky.URL}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := get(t, pool); err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+	}
+	if stats := pool.Stats(); stats[0].Evicted || stats[0].Total != 0 {
+		t.Errorf("目标站点返回502不应计入代理失败: %+v", stats[0])
+	}
+}
+
+func TestProxyPoolHealthCheckEvictsUnreachable(t *testing.T) {
+	good := fakeProxy(t, "good", statusOf(http.StatusOK))
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close() // 连接被拒绝
+
+	pool, err := NewProxyPool([]string{good.URL, dead.URL}, DefaultMaxFailures)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 启动时的一次健康检查即剔除无法连接的代理，不需要达到连续失败次数上限
+	pool.CheckHealth("http://origin.test/robots.txt")
+	stats := pool.Stats()
+	if pool.Available() != 1 || stats[0].Evicted || !stats[1].Evicted {
+		t.Fatalf("健康检查后代理状态 = %+v, 期望只剔除无法连接的代理", stats)
+	}
+	for i := 0; i < 2; i++ {
+		if name, err := get(t, pool); err != nil || name != "good" {
+			t.Errorf("剔除后请求 = %q, %v, 期望只使用 good", name, err)
+		}
+	}
+}
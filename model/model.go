@@ -0,0 +1,124 @@
+package model
+
+// CommentInfo B站评论信息结构体
+type CommentInfo struct {
+	SerialNumber int    `json:"serial_number" jsonschema:"description=序号"`
+	ParentID     int64  `json:"parent_id" jsonschema:"description=上级评论ID"`
+	CommentID    int64  `json:"comment_id" jsonschema:"description=评论ID"`
+	UserID       int64  `json:"user_id" jsonschema:"description=用户ID"`
+	Username     string `json:"username" jsonschema:"description=用户名"`
+	UserLevel    int    `json:"user_level" jsonschema:"description=用户等级"`
+	Gender       string `json:"gender" jsonschema:"description=性别,enum=男,enum=女,enum=保密"`
+	Content      string `json:"content" jsonschema:"description=评论内容"`
+	CommentTime  string `json:"comment_time" jsonschema:"description=评论时间"`
+	ReplyCount   int    `json:"reply_count" jsonschema:"description=回复数"`
+	LikeCount    int    `json:"like_count" jsonschema:"description=点赞数"`
+	Signature    string `json:"signature" jsonschema:"description=个性签名"`
+	IPLocation   string `json:"ip_location" jsonschema:"description=IP属地"`
+	IsVIP        string `json:"is_vip" jsonschema:"description=是否是大会员"`
+	Avatar       string `json:"avatar" jsonschema:"description=头像"`
+	BV           string `json:"bv" jsonschema:"description=视频BV号"`
+	VideoTitle   string `json:"video_title" jsonschema:"description=视频标题"`
+}
+
+// VideoInfo B站视频信息结构体
+type VideoInfo struct {
+	Keyword     string `json:"keyword" jsonschema:"description=搜索关键词"`
+	BVID        string `json:"bvid" jsonschema:"description=视频BV号"`
+	Title       string `json:"title" jsonschema:"description=视频标题"`
+	Author      string `json:"author" jsonschema:"description=作者"`
+	Play        int64  `json:"play" jsonschema:"description=播放量"`
+	VideoReview int    `json:"video_review" jsonschema:"description=评论数"`
+	Favorites   int    `json:"favorites" jsonschema:"description=收藏数"`
+	PubDate     int64  `json:"pubdate" jsonschema:"description=发布时间戳"`
+	Duration    string `json:"duration" jsonschema:"description=视频时长"`
+	Like        int    `json:"like" jsonschema:"description=点赞数"`
+	Danmaku     int    `json:"danmaku" jsonschema:"description=弹幕数"`
+	Description string `json:"description" jsonschema:"description=视频描述"`
+	Pic         string `json:"pic" jsonschema:"description=视频封面"`
+	CreateTime  string `json:"create_time" jsonschema:"description=记录创建时间"`
+}
+
+// NewsInfo Gamersky新闻信息结构体
+type NewsInfo struct {
+	SID         string `json:"sid" jsonschema:"description=新闻ID (data-sid)"`
+	Title       string `json:"title" jsonschema:"description=新闻标题"`
+	Time        string `json:"time" jsonschema:"description=发布时间"`
+	CommentNum  int    `json:"comment_num" jsonschema:"description=评论数"`
+	URL         string `json:"url" jsonschema:"description=新闻链接"`
+	ImageURL    string `json:"image_url" jsonschema:"description=图片链接"`
+	CreateTime  string `json:"create_time" jsonschema:"description=记录创建时间"`
+	TopLineTime string `json:"topline_time" jsonschema:"description=置顶时间"`
+	Channel     string `json:"channel" jsonschema:"description=新闻所属频道 (首页信息流为空)"`
+	PublishedAt string `json:"published_at" jsonschema:"description=发布时间 (北京时间，由时间标签和置顶时间计算)"`
+}
+
+// GamerskyComment Gamersky评论信息结构体
+type GamerskyComment struct {
+	ID                 int64  `json:"id" jsonschema:"description=评论ID"`
+	ArticleID          string `json:"article_id" jsonschema:"description=文章ID"`
+	UserID             int    `json:"user_id" jsonschema:"description=用户ID"`
+	Username           string `json:"username" jsonschema:"description=用户名"`
+	Content            string `json:"content" jsonschema:"description=评论内容"`
+	CommentTime        string `json:"comment_time" jsonschema:"description=评论时间"`
+	SupportCount       int    `json:"support_count" jsonschema:"description=点赞数"`
+	ReplyCount         int    `json:"reply_count" jsonschema:"description=回复数"`
+	ParentID           int64  `json:"parent_id" jsonschema:"description=父评论ID (0表示一级评论)"`
+	AnswerToID         int64  `json:"answer_to_id" jsonschema:"description=被回复的评论ID (0表示一级评论)"`
+	AnswerToName       string `json:"answer_to_name" jsonschema:"description=被回复用户名"`
+	UserAvatar         string `json:"user_avatar" jsonschema:"description=用户头像"`
+	UserLevel          int    `json:"user_level" jsonschema:"description=用户等级"`
+	IPLocation         string `json:"ip_location" jsonschema:"description=IP位置"`
+	DeviceName         string `json:"device_name" jsonschema:"description=设备名称"`
+	FloorNumber        int    `json:"floor_number" jsonschema:"description=楼层号"`
+	IsTuijian          bool   `json:"is_tuijian" jsonschema:"description=是否推荐"`
+	IsAuthor           bool   `json:"is_author" jsonschema:"description=是否作者"`
+	IsBest             bool   `json:"is_best" jsonschema:"description=是否最佳"`
+	UserAuthentication string `json:"user_authentication" jsonschema:"description=用户认证"`
+	UserGroupID        int    `json:"user_group_id" jsonschema:"description=用户组ID"`
+	ThirdPlatformBound string `json:"third_platform_bound" jsonschema:"description=第三方平台绑定"`
+	CreateTime         string `json:"create_time" jsonschema:"description=记录创建时间"`
+}
+
+// GamerskyCommentImage Gamersky评论中的图片
+type GamerskyCommentImage struct {
+	CommentID  int64  `json:"comment_id" jsonschema:"description=评论ID"`
+	ArticleID  string `json:"article_id" jsonschema:"description=文章ID"`
+	ImageOrder int    `json:"image_order" jsonschema:"description=图片在评论中的顺序 (从0开始)"`
+	URL        string `json:"url" jsonschema:"description=图片链接"`
+	Width      int    `json:"width" jsonschema:"description=图片宽度"`
+	Height     int    `json:"height" jsonschema:"description=图片高度"`
+	SHA256     string `json:"sha256" jsonschema:"description=已下载图片内容的SHA-256 (未下载时为空)"`
+}
+
+// GamerskyCommentOrder Gamersky一级评论在某种排序方式下出现的位置，用于比较推荐排序与时间排序
+type GamerskyCommentOrder struct {
+	CommentID int64  `json:"comment_id" jsonschema:"description=评论ID"`
+	ArticleID string `json:"article_id" jsonschema:"description=文章ID"`
+	OrderMode string `json:"order_mode" jsonschema:"description=排序方式 (recommended/latest/hottest)"`
+	Rank      int    `json:"rank" jsonschema:"description=在该排序下的名次 (从1开始)"`
+	SeenAt    string `json:"seen_at" jsonschema:"description=在该排序下看到评论的时间"`
+}
+
+// GamerskyArticle Gamersky文章正文
+type GamerskyArticle struct {
+	SID         string   `json:"sid" jsonschema:"description=文章ID，与新闻ID相同"`
+	URL         string   `json:"url" jsonschema:"description=文章第一页链接"`
+	Title       string   `json:"title" jsonschema:"description=文章标题"`
+	Author      string   `json:"author" jsonschema:"description=作者"`
+	Editor      string   `json:"editor" jsonschema:"description=编辑"`
+	Source      string   `json:"source" jsonschema:"description=来源"`
+	PublishTime string   `json:"publish_time" jsonschema:"description=发布时间"`
+	Tags        []string `json:"tags" jsonschema:"description=标签"`
+	Paragraphs  []string `json:"paragraphs" jsonschema:"description=正文段落"`
+	Images      []string `json:"images" jsonschema:"description=正文图片链接"`
+	Pages       int      `json:"pages" jsonschema:"description=文章页数"`
+	CreateTime  string   `json:"create_time" jsonschema:"description=记录创建时间"`
+}
+
+// GamerskyCrawlState Gamersky新闻评论的爬取状态，用于安排评论重爬
+type GamerskyCrawlState struct {
+	SID        string `json:"sid" jsonschema:"description=新闻ID"`
+	CommentNum int    `json:"comment_num" jsonschema:"description=爬取时新闻的评论数"`
+	CrawledAt  string `json:"crawled_at" jsonschema:"description=上次爬取评论的时间"`
+}
@@ -0,0 +1,405 @@
+package runlog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"bili-comment/httpclient"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// 运行状态
+const (
+	StatusRunning     = "running"     // 运行中
+	StatusSuccess     = "success"     // 成功
+	StatusFailed      = "failed"      // 失败
+	StatusInterrupted = "interrupted" // 收到信号后中断
+)
+
+// maxErrorMessages 每次运行最多保存的错误信息条数
+const maxErrorMessages = 20
+
+// RunInfo 爬取运行记录
+type RunInfo struct {
+	ID           int64  `json:"id"`            // 运行ID
+	Command      string `json:"command"`       // 命令名称
+	Args         string `json:"args"`          // 命令参数
+	StartTime    string `json:"start_time"`    // 开始时间
+	EndTime      string `json:"end_time"`      // 结束时间
+	Requests     int64  `json:"requests"`      // 发出的请求数
+	Inserted     int64  `json:"inserted"`      // 新插入的行数
+	Ignored      int64  `json:"ignored"`       // 因重复被忽略的行数
+	Errors       int64  `json:"errors"`        // 错误次数
+	ErrorMessage string `json:"error_message"` // 错误信息
+	Status       string `json:"status"`        // 退出状态
+}
+
+// Run 一次正在进行的爬取运行，所有方法在 nil 上调用时均为空操作
+type Run struct {
+	mu            sync.Mutex
+	db            *sql.DB
+	info          RunInfo
+	startRequests int64
+	messages      []string
+}
+
+// DB 可执行SQL的数据库连接或事务
+type DB interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// EnsureTable 创建运行记录表
+func EnsureTable(db DB) error {
+	createRunsTableSQL := `
+	CREATE TABLE IF NOT EXISTS crawl_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		command TEXT NOT NULL,
+		args TEXT,
+		start_time TEXT,
+		end_time TEXT,
+		requests INTEGER DEFAULT 0,
+		inserted INTEGER DEFAULT 0,
+		ignored INTEGER DEFAULT 0,
+		errors INTEGER DEFAULT 0,
+		error_message TEXT DEFAULT '',
+		status TEXT DEFAULT 'running'
+	)`
+
+	if _, err := db.Exec(createRunsTableSQL); err != nil {
+		return err
+	}
+
+	// 创建断点表，记录中断时的爬取进度
+	createCheckpointTableSQL := `
+	CREATE TABLE IF NOT EXISTS crawl_checkpoints (
+		command TEXT NOT NULL,
+		key TEXT NOT NULL,
+		cursor TEXT,
+		count INTEGER DEFAULT 0,
+		run_id INTEGER DEFAULT 0,
+		update_time TEXT,
+		PRIMARY KEY (command, key)
+	)`
+
+	_, err := db.Exec(createCheckpointTableSQL)
+	return err
+}
+
+// Open 打开数据库并确保运行记录表存在
+func Open(dbPath string) (*sql.DB, error) {
+	if dir := filepath.Dir(dbPath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := sql.Open("sqlite3", dbPath+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := EnsureTable(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Start 在指定数据库中登记一次新的运行
+func Start(dbPath, command string, args []string) (*Run, error) {
+	db, err := Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开运行记录失败: %v", err)
+	}
+
+	run := &Run{
+		db:            db,
+		startRequests: httpclient.RequestCount(),
+		info: RunInfo{
+			Command:   command,
+			Args:      strings.Join(args, " "),
+			StartTime: time.Now().Format("2006-01-02 15:04:05"),
+			Status:    StatusRunning,
+		},
+	}
+
+	result, err := db.Exec(`INSERT INTO crawl_runs (command, args, start_time, status) VALUES (?, ?, ?, ?)`,
+		run.info.Command, run.info.Args, run.info.StartTime, run.info.Status)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("登记运行记录失败: %v", err)
+	}
+
+	run.info.ID, err = result.LastInsertId()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// ID 获取运行ID，nil 运行返回0
+func (r *Run) ID() int64 {
+	if r == nil {
+		return 0
+	}
+	return r.info.ID
+}
+
+// RecordSave 根据存储层的保存结果统计插入或忽略的行数
+func (r *Run) RecordSave(inserted bool, err error) {
+	if r == nil {
+		return
+	}
+
+	if err != nil {
+		r.RecordError(err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if inserted {
+		r.info.Inserted++
+	} else {
+		r.info.Ignored++
+	}
+}
+
+// RecordError 记录一次错误
+func (r *Run) RecordError(err error) {
+	if r == nil || err == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.info.Errors++
+	if len(r.messages) < maxErrorMessages {
+		r.messages = append(r.messages, err.Error())
+	}
+}
+
+// Finish 结束运行并写入统计信息，runErr 为 context.Canceled 时状态为中断，其他错误为失败
+func (r *Run) Finish(runErr error) error {
+	if r == nil {
+		return nil
+	}
+
+	status := StatusSuccess
+	if errors.Is(runErr, context.Canceled) {
+		status = StatusInterrupted
+	} else if runErr != nil {
+		r.RecordError(runErr)
+		status = StatusFailed
+	}
+
+	return r.FinishWithStatus(status)
+}
+
+// FinishWithStatus 以指定状态结束运行
+func (r *Run) FinishWithStatus(status string) error {
+	if r == nil {
+		return nil
+	}
+	defer r.db.Close()
+
+	r.mu.Lock()
+	r.info.EndTime = time.Now().Format("2006-01-02 15:04:05")
+	r.info.Requests = httpclient.RequestCount() - r.startRequests
+	r.info.ErrorMessage = strings.Join(r.messages, "\n")
+	r.info.Status = status
+	info := r.info
+	r.mu.Unlock()
+
+	_, err := r.db.Exec(`
+	UPDATE crawl_runs
+	SET end_time = ?, requests = ?, inserted = ?, ignored = ?, errors = ?, error_message = ?, status = ?
+	WHERE id = ?`,
+		info.EndTime, info.Requests, info.Inserted, info.Ignored, info.Errors, info.ErrorMessage, info.Status, info.ID)
+	if err != nil {
+		return fmt.Errorf("更新运行记录失败: %v", err)
+	}
+
+	return nil
+}
+
+// SaveCheckpoint 保存爬取断点，key 标识爬取对象（如BV号），cursor 为恢复时的起始位置
+func (r *Run) SaveCheckpoint(key, cursor string, count int) error {
+	if r == nil {
+		return fmt.Errorf("没有运行记录，无法保存断点")
+	}
+
+	_, err := r.db.Exec(`
+	INSERT INTO crawl_checkpoints (command, key, cursor, count, run_id, update_time)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT(command, key) DO UPDATE SET
+		cursor = excluded.cursor, count = excluded.count, run_id = excluded.run_id, update_time = excluded.update_time`,
+		r.info.Command, key, cursor, count, r.info.ID, time.Now().Format("2006-01-02 15:04:05"))
+	return err
+}
+
+// TrySaveCheckpoint 保存爬取断点并提示使用 --resume 继续，失败时只记录日志
+func (r *Run) TrySaveCheckpoint(key, cursor string, count int) {
+	if err := r.SaveCheckpoint(key, cursor, count); err != nil {
+		log.Printf("保存断点失败: %v", err)
+		return
+	}
+	log.Printf("已保存断点：%s -> %s，使用 --resume 继续爬取", key, cursor)
+}
+
+// LoadCheckpoint 读取同一命令上次保存的断点，不存在时 ok 为 false
+func (r *Run) LoadCheckpoint(key string) (cursor string, count int, ok bool, err error) {
+	if r == nil {
+		return "", 0, false, nil
+	}
+
+	err = r.db.QueryRow(`SELECT cursor, count FROM crawl_checkpoints WHERE command = ? AND key = ?`,
+		r.info.Command, key).Scan(&cursor, &count)
+	if err == sql.ErrNoRows {
+		return "", 0, false, nil
+	}
+	if err != nil {
+		return "", 0, false, err
+	}
+
+	return cursor, count, true, nil
+}
+
+// ClearCheckpoint 爬取完成后删除断点
+func (r *Run) ClearCheckpoint(key string) error {
+	if r == nil {
+		return nil
+	}
+
+	_, err := r.db.Exec(`DELETE FROM crawl_checkpoints WHERE command = ? AND key = ?`, r.info.Command, key)
+	return err
+}
+
+// Info 获取当前运行的统计快照
+func (r *Run) Info() RunInfo {
+	if r == nil {
+		return RunInfo{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info := r.info
+	info.Requests = httpclient.RequestCount() - r.startRequests
+	return info
+}
+
+// ListRuns 按时间倒序列出运行记录
+func ListRuns(db *sql.DB, limit int) ([]RunInfo, error) {
+	query := `
+	SELECT id, command, args, start_time, COALESCE(end_time, ''), requests, inserted, ignored, errors, COALESCE(error_message, ''), status
+	FROM crawl_runs
+	ORDER BY id DESC`
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []RunInfo
+	for rows.Next() {
+		var info RunInfo
+		err := rows.Scan(&info.ID, &info.Command, &info.Args, &info.StartTime, &info.EndTime,
+			&info.Requests, &info.Inserted, &info.Ignored, &info.Errors, &info.ErrorMessage, &info.Status)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, info)
+	}
+
+	return runs, rows.Err()
+}
+
+// GetRun 获取指定ID的运行记录
+func GetRun(db *sql.DB, id int64) (*RunInfo, error) {
+	var info RunInfo
+	err := db.QueryRow(`
+	SELECT id, command, args, start_time, COALESCE(end_time, ''), requests, inserted, ignored, errors, COALESCE(error_message, ''), status
+	FROM crawl_runs
+	WHERE id = ?`, id).Scan(&info.ID, &info.Command, &info.Args, &info.StartTime, &info.EndTime,
+		&info.Requests, &info.Inserted, &info.Ignored, &info.Errors, &info.ErrorMessage, &info.Status)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("运行记录 %d 不存在", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// CountRowsByRun 统计各数据表中由指定运行首次插入的行数
+func CountRowsByRun(db *sql.DB, id int64) (map[string]int64, error) {
+	rows, err := db.Query(`
+	SELECT m.name FROM sqlite_master m
+	WHERE m.type = 'table' AND m.name NOT LIKE 'crawl\_%' ESCAPE '\'
+	AND m.sql NOT LIKE 'CREATE VIRTUAL TABLE%' AND EXISTS (
+		SELECT 1 FROM pragma_table_info(m.name) p WHERE p.name = 'run_id'
+	)
+	ORDER BY m.name`)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+
+	counts := make(map[string]int64)
+	for _, table := range tables {
+		var count int64
+		if err := db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM "%s" WHERE run_id = ?`, table), id).Scan(&count); err != nil {
+			return nil, err
+		}
+		counts[table] = count
+	}
+
+	return counts, nil
+}
+
+// EnsureRunIDColumn 为旧数据库中的数据表补充 run_id 列
+func EnsureRunIDColumn(db DB, table string) error {
+	var exists int
+	err := db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM pragma_table_info('%s') WHERE name = 'run_id'`, table)).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if exists == 0 {
+		if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN run_id INTEGER DEFAULT 0`, table)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package runlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunStartFinish(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "runs.db")
+
+	run, err := Start(dbPath, "crawl", []string{"--bv=BV1", "--mode=2"})
+	if err != nil {
+		t.Fatalf("登记运行失败: %v", err)
+	}
+	run.RecordSave(true, nil)
+	run.RecordSave(true, nil)
+	run.RecordSave(false, nil)
+	run.RecordSave(false, errors.New("写入失败"))
+	if err := run.Finish(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	failed, err := Start(dbPath, "gamersky", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := failed.Finish(errors.New("接口错误")); err != nil {
+		t.Fatal(err)
+	}
+
+	interrupted, err := Start(dbPath, "gamersky", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := interrupted.Finish(fmt.Errorf("爬取中断: %w", context.Canceled)); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	info, err := GetRun(db, run.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Command != "crawl" || info.Args != "--bv=BV1 --mode=2" || info.Status != StatusSuccess ||
+		info.Inserted != 2 || info.Ignored != 1 || info.Errors != 1 || info.ErrorMessage != "写入失败" || info.EndTime == "" {
+		t.Errorf("运行记录 = %+v", info)
+	}
+
+	runs, err := ListRuns(db, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statuses []string
+	for _, r := range runs {
+		statuses = append(statuses, r.Status)
+	}
+	want := []string{StatusInterrupted, StatusFailed, StatusSuccess}
+	if fmt.Sprint(statuses) != fmt.Sprint(want) {
+		t.Errorf("运行状态 = %v, 期望 %v（按时间倒序）", statuses, want)
+	}
+	if runs[1].ErrorMessage != "接口错误" {
+		t.Errorf("失败运行的错误信息 = %q", runs[1].ErrorMessage)
+	}
+
+	if _, err := GetRun(db, 99); err == nil {
+		t.Error("不存在的运行应返回错误")
+	}
+}
+
+func TestRunCheckpoint(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "runs.db")
+
+	first, err := Start(dbPath, "crawl", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok, err := first.LoadCheckpoint("BV1"); ok || err != nil {
+		t.Fatalf("没有断点时 ok = %v, err = %v", ok, err)
+	}
+	if err := first.SaveCheckpoint("BV1", "cursor-1", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.SaveCheckpoint("BV1", "cursor-2", 20); err != nil {
+		t.Fatal(err)
+	}
+	first.Finish(context.Canceled)
+
+	// 同一命令的下一次运行读取断点
+	second, err := Start(dbPath, "crawl", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor, count, ok, err := second.LoadCheckpoint("BV1")
+	if err != nil || !ok || cursor != "cursor-2" || count != 20 {
+		t.Errorf("断点 = %q, %d, %v, %v, 期望 cursor-2, 20", cursor, count, ok, err)
+	}
+
+	// 其他命令的断点互不影响
+	other, err := Start(dbPath, "gamersky-full", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok, _ := other.LoadCheckpoint("BV1"); ok {
+		t.Error("不同命令不应读取到同一断点")
+	}
+	other.Finish(nil)
+
+	if err := second.ClearCheckpoint("BV1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok, _ := second.LoadCheckpoint("BV1"); ok {
+		t.Error("清除后不应再读取到断点")
+	}
+	second.Finish(nil)
+
+	// nil 运行的方法为空操作
+	var none *Run
+	if none.ID() != 0 || none.Finish(nil) != nil || none.SaveCheckpoint("k", "c", 1) == nil {
+		t.Error("nil 运行的行为不符合预期")
+	}
+}
+
+func TestCountRowsByRun(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "runs.db")
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	statements := []string{
+		`CREATE TABLE gamersky_news (sid TEXT PRIMARY KEY, title TEXT)`,
+		`CREATE TABLE bilibili_comments (comment_id INTEGER PRIMARY KEY, run_id INTEGER DEFAULT 0)`,
+		`CREATE TABLE no_run_id (id INTEGER PRIMARY KEY)`,
+		`INSERT INTO gamersky_news (sid, title) VALUES ('1', 'a')`,
+		`INSERT INTO bilibili_comments (comment_id, run_id) VALUES (1, 1), (2, 1), (3, 2)`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 旧数据库补充 run_id 列，已有的行视为运行0插入
+	if err := EnsureRunIDColumn(db, "gamersky_news"); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnsureRunIDColumn(db, "gamersky_news"); err != nil {
+		t.Fatalf("重复补充 run_id 列失败: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO gamersky_news (sid, title, run_id) VALUES ('2', 'b', 1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err := CountRowsByRun(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 2 || counts["bilibili_comments"] != 2 || counts["gamersky_news"] != 1 {
+		t.Errorf("运行1插入的行数 = %v", counts)
+	}
+	if _, ok := counts["crawl_runs"]; ok {
+		t.Error("不应统计运行记录表")
+	}
+}
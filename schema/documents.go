@@ -0,0 +1,101 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"bili-comment/model"
+)
+
+// Document 一个随仓库提交的模式文件
+type Document struct {
+	Name  string      // 名称，用于 export 等命令引用
+	File  string      // 文件名，相对于仓库根目录
+	Title string      // 文档标题
+	Type  interface{} // 生成模式的结构体
+}
+
+// Documents 所有模式文件
+// gamersky.NewsInfo 和 gamersky.Comment 分别是 model.NewsInfo 和 model.GamerskyComment 的别名
+var Documents = []Document{
+	{Name: "comment-info", File: "comment_info_schema.json", Title: "CommentInfoArray", Type: model.CommentInfo{}},
+	{Name: "video-info", File: "video_info_schema.json", Title: "VideoInfoArray", Type: model.VideoInfo{}},
+	{Name: "news-info", File: "news_info_schema.json", Title: "NewsInfoArray", Type: model.NewsInfo{}},
+	{Name: "gamersky-comment", File: "gamersky_comment_schema.json", Title: "GamerskyCommentArray", Type: model.GamerskyComment{}},
+}
+
+// Item 生成单条记录的模式
+func (d Document) Item() (*Schema, error) {
+	return FromStruct(d.Type)
+}
+
+// Generate 生成模式文件内容
+func (d Document) Generate() ([]byte, error) {
+	item, err := d.Item()
+	if err != nil {
+		return nil, err
+	}
+	return ArrayOf(d.Title, item).Marshal()
+}
+
+// Lookup 按名称查找模式文件
+func Lookup(name string) (Document, bool) {
+	for _, document := range Documents {
+		if document.Name == name {
+			return document, true
+		}
+	}
+	return Document{}, false
+}
+
+// MustItem 获取单条记录的模式，结构体标签有误时 panic
+// 供包级变量初始化使用，标签错误在程序启动时即可发现
+func MustItem(name string) *Schema {
+	document, ok := Lookup(name)
+	if !ok {
+		panic(fmt.Sprintf("未知的模式: %s", name))
+	}
+	item, err := document.Item()
+	if err != nil {
+		panic(fmt.Sprintf("生成模式 %s 失败: %v", name, err))
+	}
+	return item
+}
+
+// WriteFiles 将所有模式文件写入 dir
+func WriteFiles(dir string) ([]string, error) {
+	var written []string
+	for _, document := range Documents {
+		content, err := document.Generate()
+		if err != nil {
+			return written, fmt.Errorf("生成 %s 失败: %v", document.File, err)
+		}
+
+		path := filepath.Join(dir, document.File)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return written, fmt.Errorf("写入 %s 失败: %v", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// CheckFiles 比较 dir 中已提交的模式文件与生成结果，返回缺失或内容不一致的文件
+func CheckFiles(dir string) ([]string, error) {
+	var stale []string
+	for _, document := range Documents {
+		content, err := document.Generate()
+		if err != nil {
+			return nil, fmt.Errorf("生成 %s 失败: %v", document.File, err)
+		}
+
+		path := filepath.Join(dir, document.File)
+		committed, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(committed, content) {
+			stale = append(stale, path)
+		}
+	}
+	return stale, nil
+}
@@ -0,0 +1,211 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Schema JSON Schema 文档，只包含本项目数据结构用到的关键字
+// 字段顺序即序列化后的键顺序
+type Schema struct {
+	Title       string      `json:"title,omitempty"`
+	Type        string      `json:"type"`
+	Description string      `json:"description,omitempty"`
+	Enum        []string    `json:"enum,omitempty"`
+	Properties  *Properties `json:"properties,omitempty"`
+	Items       *Schema     `json:"items,omitempty"`
+	Required    []string    `json:"required,omitempty"`
+}
+
+// Property 对象的一个属性
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Properties 按结构体字段顺序排列的对象属性
+type Properties []Property
+
+// MarshalJSON 按属性顺序输出，保证生成的文件稳定可比较
+func (p Properties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, property := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(property.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshal(property.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Get 获取属性
+func (p Properties) Get(name string) (*Schema, bool) {
+	for _, property := range p {
+		if property.Name == name {
+			return property.Schema, true
+		}
+	}
+	return nil, false
+}
+
+// FromStruct 根据结构体的 json 和 jsonschema 标签生成对象的模式
+//
+// jsonschema 标签为逗号分隔的 key=value 列表，支持 description 和可重复的 enum：
+//
+//	Gender string `json:"gender" jsonschema:"description=性别,enum=男,enum=女,enum=保密"`
+//
+// 没有 omitempty 的字段都是必填字段，json 标签为 "-" 的字段被忽略。
+func FromStruct(v interface{}) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s 不是结构体", t)
+	}
+
+	properties := Properties{}
+	schema := &Schema{Type: "object", Properties: &properties}
+	if err := addFields(schema, t); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// addFields 将结构体字段加入对象模式，匿名嵌入的结构体字段会被展开
+func addFields(schema *Schema, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, omitempty := parseJSONTag(field)
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			if err := addFields(schema, field.Type); err != nil {
+				return err
+			}
+			continue
+		}
+
+		property, err := fieldSchema(field)
+		if err != nil {
+			return err
+		}
+
+		*schema.Properties = append(*schema.Properties, Property{Name: name, Schema: property})
+		if !omitempty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return nil
+}
+
+// parseJSONTag 解析 json 标签，返回字段名和是否 omitempty
+func parseJSONTag(field reflect.StructField) (string, bool) {
+	parts := strings.Split(field.Tag.Get("json"), ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	omitempty := false
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty
+}
+
+// fieldSchema 生成单个字段的模式
+func fieldSchema(field reflect.StructField) (*Schema, error) {
+	schema := &Schema{}
+
+	switch field.Type.Kind() {
+	case reflect.String:
+		schema.Type = "string"
+	case reflect.Bool:
+		schema.Type = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema.Type = "integer"
+	case reflect.Float32, reflect.Float64:
+		schema.Type = "number"
+	default:
+		return nil, fmt.Errorf("字段 %s 的类型 %s 暂不支持生成模式", field.Name, field.Type)
+	}
+
+	tag := field.Tag.Get("jsonschema")
+	if tag == "" {
+		return schema, nil
+	}
+	for _, item := range strings.Split(tag, ",") {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("字段 %s 的 jsonschema 标签无效: %q", field.Name, item)
+		}
+		switch key {
+		case "description":
+			schema.Description = value
+		case "enum":
+			schema.Enum = append(schema.Enum, value)
+		default:
+			return nil, fmt.Errorf("字段 %s 的 jsonschema 标签包含未知的键: %s", field.Name, key)
+		}
+	}
+	return schema, nil
+}
+
+// ArrayOf 生成以 items 数组包装的文档模式，如 CommentInfoArray
+func ArrayOf(title string, item *Schema) *Schema {
+	return &Schema{
+		Title: title,
+		Type:  "object",
+		Properties: &Properties{
+			{Name: "items", Schema: &Schema{Type: "array", Items: item}},
+		},
+		Required: []string{"items"},
+	}
+}
+
+// Marshal 以4个空格缩进输出模式，末尾带换行
+func (s *Schema) Marshal() ([]byte, error) {
+	compact, err := marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, compact, "", "    "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// marshal 序列化时不转义 HTML 字符
+func marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,15 @@
+package schema
+
+import "testing"
+
+// TestCommittedSchemasUpToDate 已提交的模式文件必须与结构体标签生成的结果一致
+// 修改 model 中的结构体后运行 bili-comment schema 重新生成
+func TestCommittedSchemasUpToDate(t *testing.T) {
+	stale, err := CheckFiles("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range stale {
+		t.Errorf("%s 与结构体不一致，请运行 bili-comment schema 重新生成", path)
+	}
+}
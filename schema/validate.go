@@ -0,0 +1,128 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ValidateJSON 校验一段JSON文本是否符合模式
+func (s *Schema) ValidateJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("解析JSON失败: %v", err)
+	}
+	return s.Validate(value)
+}
+
+// Validate 校验已解码的JSON值是否符合模式
+// 数字需以 json.Number 或 float64 表示，对象需为 map[string]interface{}
+func (s *Schema) Validate(value interface{}) error {
+	return s.validate("$", value)
+}
+
+func (s *Schema) validate(path string, value interface{}) error {
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return typeError(path, s.Type, value)
+		}
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s: 缺少必填字段 %s", path, name)
+			}
+		}
+		if s.Properties == nil {
+			return nil
+		}
+		for name, fieldValue := range object {
+			property, ok := s.Properties.Get(name)
+			if !ok {
+				continue
+			}
+			if err := property.validate(path+"."+name, fieldValue); err != nil {
+				return err
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return typeError(path, s.Type, value)
+		}
+		if s.Items == nil {
+			return nil
+		}
+		for i, item := range array {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return typeError(path, s.Type, value)
+		}
+		if len(s.Enum) > 0 && !containsString(s.Enum, text) {
+			return fmt.Errorf("%s: %q 不在可选值 %s 中", path, text, strings.Join(s.Enum, "、"))
+		}
+	case "integer":
+		if !isInteger(value) {
+			return typeError(path, s.Type, value)
+		}
+	case "number":
+		switch value.(type) {
+		case json.Number, float64:
+		default:
+			return typeError(path, s.Type, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return typeError(path, s.Type, value)
+		}
+	}
+	return nil
+}
+
+// isInteger 判断JSON数字是否为整数
+func isInteger(value interface{}) bool {
+	switch v := value.(type) {
+	case json.Number:
+		_, err := v.Int64()
+		return err == nil
+	case float64:
+		return v == float64(int64(v))
+	}
+	return false
+}
+
+// typeError 类型不符的错误
+func typeError(path, expected string, value interface{}) error {
+	actual := "null"
+	switch value.(type) {
+	case string:
+		actual = "string"
+	case bool:
+		actual = "boolean"
+	case json.Number, float64:
+		actual = "number"
+	case map[string]interface{}:
+		actual = "object"
+	case []interface{}:
+		actual = "array"
+	}
+	return fmt.Errorf("%s: 应为 %s，实际为 %s", path, expected, actual)
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
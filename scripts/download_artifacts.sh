@@ -4,6 +4,9 @@
 REPO="fanlun008/bili-comment"
 WORKFLOW_NAME="Gamersky News and Comments Crawler"
 
+# 构建标签，与 Makefile 的 TAGS 一致，启用 FTS5 以便合并时维护评论全文索引
+TAGS="${TAGS:-sqlite_fts5}"
+
 echo "🔍 正在查看最近的工作流运行..."
 
 # 检查是否安装了 gh CLI
@@ -63,3 +66,12 @@ if ls "$DOWNLOAD_DIR"/*/*.db &> /dev/null; then
         echo "---"
     done
 fi
+
+# 合并下载的数据库到本地数据库
+if ls "$DOWNLOAD_DIR"/*/*.db &> /dev/null; then
+    echo ""
+    read -p "🔗 是否将下载的数据库合并到 ./data/gamersky.db？(y/N): " MERGE
+    if [[ "$MERGE" =~ ^[Yy]$ ]]; then
+        CGO_ENABLED=1 go run -tags "$TAGS" main.go db merge ./data/gamersky.db "$DOWNLOAD_DIR"/*/*.db
+    fi
+fi
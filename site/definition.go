@@ -0,0 +1,326 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"bili-comment/model"
+
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDir 默认的站点定义目录
+const DefaultDir = "./sites"
+
+// 响应格式
+const (
+	FormatJSON = "json" // 字段选择器为 JSONPath
+	FormatHTML = "html" // 字段选择器为 CSS 选择器
+)
+
+// Definition YAML站点定义：描述如何从一个新闻/评论站点获取条目列表和评论，不需要编写Go代码
+type Definition struct {
+	Name        string            `yaml:"name"`        // 来源名称，即命令行 --source 的取值
+	Description string            `yaml:"description"` // 来源说明
+	Output      string            `yaml:"output"`      // 默认数据库路径，为空时为 ./data/<name>.db
+	Timezone    string            `yaml:"timezone"`    // 时间字段的时区，默认 Asia/Shanghai
+	Headers     map[string]string `yaml:"headers"`     // 所有请求共用的请求头
+	RateLimit   RateLimit         `yaml:"rate_limit"`  // 请求频率限制
+	List        *Endpoint         `yaml:"list"`        // 条目列表接口，映射到新闻结构 (model.NewsInfo)
+	Comments    *Endpoint         `yaml:"comments"`    // 评论接口，映射到评论结构 (model.GamerskyComment)
+
+	path     string         // 定义文件路径
+	location *time.Location // 解析后的时区
+}
+
+// RateLimit 请求频率限制，两个条件同时生效，取较长的请求间隔
+type RateLimit struct {
+	Delay     time.Duration `yaml:"delay"`      // 两次请求的最小间隔
+	PerMinute int           `yaml:"per_minute"` // 每分钟最多请求次数
+}
+
+// Interval 获取两次请求的最小间隔
+func (r RateLimit) Interval() time.Duration {
+	interval := r.Delay
+	if r.PerMinute > 0 {
+		if perRequest := time.Minute / time.Duration(r.PerMinute); perRequest > interval {
+			interval = perRequest
+		}
+	}
+	return interval
+}
+
+// Endpoint 列表或评论接口
+// url、params、body 中可使用占位符 {page}、{offset}、{page_size}、{query}（列表）和 {item}（评论）
+type Endpoint struct {
+	URL        string            `yaml:"url"`        // 请求地址，占位符的值会做URL编码
+	Method     string            `yaml:"method"`     // GET 或 POST，默认 GET
+	Params     map[string]string `yaml:"params"`     // 查询参数，值中的占位符原样替换后整体编码
+	Headers    map[string]string `yaml:"headers"`    // 请求头
+	Body       string            `yaml:"body"`       // 请求体，占位符原样替换
+	Format     string            `yaml:"format"`     // 响应格式 json 或 html，默认 json
+	Error      *ErrorRule        `yaml:"error"`      // 响应中的错误码 (仅JSON)
+	Pagination Pagination        `yaml:"pagination"` // 分页规则
+	Mapping    `yaml:",inline"`
+}
+
+// Mapping 记录的选取和字段映射
+// fields 的键为模型的JSON字段名，值为选择器：JSON为 JSONPath (如 $.user.name)，
+// HTML为 CSS 选择器，可加 @属性 取属性值 (如 a@href，单独的 @data-id 取记录元素本身的属性)；
+// 选择器后可接过滤器，以 " | " 分隔，见 applyFilter
+type Mapping struct {
+	Items   string            `yaml:"items"`   // 选取记录：JSONPath 或 CSS 选择器
+	Fields  map[string]string `yaml:"fields"`  // 字段映射
+	Replies *Mapping          `yaml:"replies"` // 评论中嵌套的回复，选择器相对于所属评论 (仅评论接口)
+
+	items  jsonPath             // 编译后的 items (JSON)
+	fields map[string]*selector // 编译后的字段选择器
+}
+
+// ErrorRule 响应中的错误码，取值不为空、0 或 false 时视为请求失败
+type ErrorRule struct {
+	Code    string `yaml:"code"`    // 错误码的 JSONPath
+	Message string `yaml:"message"` // 错误信息的 JSONPath
+
+	code    jsonPath
+	message jsonPath
+}
+
+// Pagination 分页规则
+type Pagination struct {
+	Start    int `yaml:"start"`     // 第一页的页码，默认 1
+	PageSize int `yaml:"page_size"` // 每页记录数，返回的记录少于该值时停止翻页，同时作为 {page_size} 的值
+	MaxPages int `yaml:"max_pages"` // 最多页数 (0=不限制)
+}
+
+// namePattern 来源名称格式
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Load 读取并校验一个站点定义文件
+func Load(path string) (*Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取站点定义失败: %v", err)
+	}
+
+	def := &Definition{}
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(def); err != nil {
+		return nil, fmt.Errorf("解析站点定义 %s 失败: %v", path, err)
+	}
+	def.path = path
+
+	if err := def.compile(); err != nil {
+		return nil, fmt.Errorf("站点定义 %s 无效: %v", path, err)
+	}
+	return def, nil
+}
+
+// LoadDir 读取目录下所有 .yaml 和 .yml 站点定义，按文件名排序
+func LoadDir(dir string) ([]*Definition, error) {
+	var paths []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("查找站点定义失败: %v", err)
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+
+	defs := make([]*Definition, 0, len(paths))
+	names := make(map[string]string)
+	for _, path := range paths {
+		def, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		if other, exists := names[def.Name]; exists {
+			return nil, fmt.Errorf("站点定义 %s 与 %s 的名称 %s 重复", path, other, def.Name)
+		}
+		names[def.Name] = path
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// compile 校验定义并编译所有选择器
+func (d *Definition) compile() error {
+	if !namePattern.MatchString(d.Name) {
+		return fmt.Errorf("name 必须由小写字母、数字、- 和 _ 组成: %q", d.Name)
+	}
+	if d.List == nil && d.Comments == nil {
+		return fmt.Errorf("至少需要定义 list 或 comments")
+	}
+	if d.RateLimit.Delay < 0 || d.RateLimit.PerMinute < 0 {
+		return fmt.Errorf("rate_limit 不能为负数")
+	}
+
+	d.location = time.FixedZone("CST", 8*3600)
+	if d.Timezone != "" {
+		loc, err := time.LoadLocation(d.Timezone)
+		if err != nil {
+			return fmt.Errorf("timezone 无效: %v", err)
+		}
+		d.location = loc
+	} else if loc, err := time.LoadLocation("Asia/Shanghai"); err == nil {
+		d.location = loc
+	}
+
+	if d.List != nil {
+		if err := d.List.compile(reflect.TypeOf(model.NewsInfo{}), "sid"); err != nil {
+			return fmt.Errorf("list: %v", err)
+		}
+		if d.List.Replies != nil {
+			return fmt.Errorf("list: 条目列表不支持 replies")
+		}
+	}
+	if d.Comments != nil {
+		if err := d.Comments.compile(reflect.TypeOf(model.GamerskyComment{}), "id"); err != nil {
+			return fmt.Errorf("comments: %v", err)
+		}
+		if strings.Contains(d.Comments.templates(), "{query}") {
+			return fmt.Errorf("comments: 评论接口不支持 {query} 占位符")
+		}
+	}
+	return nil
+}
+
+// compile 校验接口并编译选择器，target 为字段映射的目标模型，required 为必须映射的字段
+func (e *Endpoint) compile(target reflect.Type, required string) error {
+	if e.URL == "" {
+		return fmt.Errorf("缺少 url")
+	}
+
+	e.Method = strings.ToUpper(e.Method)
+	switch e.Method {
+	case "":
+		e.Method = "GET"
+	case "GET", "POST":
+	default:
+		return fmt.Errorf("不支持的请求方法 %s (可选 GET、POST)", e.Method)
+	}
+
+	switch e.Format {
+	case "":
+		e.Format = FormatJSON
+	case FormatJSON, FormatHTML:
+	default:
+		return fmt.Errorf("不支持的格式 %s (可选 json、html)", e.Format)
+	}
+
+	if e.Pagination.Start == 0 {
+		e.Pagination.Start = 1
+	}
+	if e.Pagination.PageSize < 0 || e.Pagination.MaxPages < 0 {
+		return fmt.Errorf("pagination 不能为负数")
+	}
+
+	if e.Error != nil {
+		if e.Format != FormatJSON {
+			return fmt.Errorf("error 只支持 json 格式")
+		}
+		var err error
+		if e.Error.code, err = compileJSONPath(e.Error.Code); err != nil {
+			return fmt.Errorf("error.code: %v", err)
+		}
+		if e.Error.Message != "" {
+			if e.Error.message, err = compileJSONPath(e.Error.Message); err != nil {
+				return fmt.Errorf("error.message: %v", err)
+			}
+		}
+	}
+
+	if err := e.Mapping.compile(e.Format, target); err != nil {
+		return err
+	}
+	if _, ok := e.Mapping.fields[required]; !ok {
+		return fmt.Errorf("fields 必须映射 %s", required)
+	}
+	if e.Replies != nil {
+		if err := e.Replies.compile(e.Format, target); err != nil {
+			return fmt.Errorf("replies: %v", err)
+		}
+		if e.Replies.Replies != nil {
+			return fmt.Errorf("replies: 不支持多层嵌套的回复")
+		}
+		if _, ok := e.Replies.fields[required]; !ok {
+			return fmt.Errorf("replies: fields 必须映射 %s", required)
+		}
+	}
+	return nil
+}
+
+// templates 返回接口中所有可包含占位符的文本
+func (e *Endpoint) templates() string {
+	var b strings.Builder
+	b.WriteString(e.URL)
+	b.WriteString(e.Body)
+	for _, value := range e.Params {
+		b.WriteString(value)
+	}
+	return b.String()
+}
+
+// compile 编译记录选择器和字段选择器，字段名必须是目标模型的JSON字段
+func (m *Mapping) compile(format string, target reflect.Type) error {
+	if m.Items == "" {
+		return fmt.Errorf("缺少 items")
+	}
+	if format == FormatJSON {
+		path, err := compileJSONPath(m.Items)
+		if err != nil {
+			return fmt.Errorf("items: %v", err)
+		}
+		m.items = path
+	} else if _, err := cascadia.Compile(m.Items); err != nil {
+		return fmt.Errorf("items: CSS选择器无效: %v", err)
+	}
+
+	if len(m.Fields) == 0 {
+		return fmt.Errorf("缺少 fields")
+	}
+	known := modelFields(target)
+	m.fields = make(map[string]*selector, len(m.Fields))
+	for name, expr := range m.Fields {
+		if _, ok := known[name]; !ok {
+			return fmt.Errorf("fields: %s 不是 %s 的字段 (可选 %s)", name, target.Name(), strings.Join(fieldNames(known), "、"))
+		}
+		sel, err := parseSelector(expr, format)
+		if err != nil {
+			return fmt.Errorf("fields.%s: %v", name, err)
+		}
+		m.fields[name] = sel
+	}
+	return nil
+}
+
+// modelFields 获取模型的JSON字段名与字段下标的对应关系
+func modelFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}
+
+// fieldNames 按字母顺序返回字段名
+func fieldNames(fields map[string]int) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
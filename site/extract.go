@@ -0,0 +1,301 @@
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// timeLayout 时间字段的格式，与爬虫写入数据库的格式一致
+const timeLayout = "2006-01-02 15:04:05"
+
+// numberRegex 从文本中提取数字
+var numberRegex = regexp.MustCompile(`-?\d+`)
+
+// selector 编译后的字段选择器
+type selector struct {
+	path    jsonPath // JSONPath (JSON)
+	css     string   // CSS 选择器 (HTML)，为空时为记录元素本身
+	attr    string   // 属性名 (HTML)，为空时取文本
+	filters []filter // 过滤器
+}
+
+// filter 选择器后的过滤器
+type filter struct {
+	name string
+	arg  string
+	re   *regexp.Regexp
+}
+
+// parseSelector 解析字段选择器，格式为 "选择器 | 过滤器 | 过滤器:参数"
+func parseSelector(expr, format string) (*selector, error) {
+	parts := strings.Split(expr, " | ")
+	sel := &selector{}
+
+	target := strings.TrimSpace(parts[0])
+	if format == FormatJSON {
+		path, err := compileJSONPath(target)
+		if err != nil {
+			return nil, err
+		}
+		sel.path = path
+	} else {
+		if at := strings.LastIndex(target, "@"); at >= 0 {
+			sel.css, sel.attr = strings.TrimSpace(target[:at]), target[at+1:]
+			if sel.attr == "" {
+				return nil, fmt.Errorf("选择器 %q 缺少属性名", expr)
+			}
+		} else {
+			sel.css = target
+		}
+		if sel.css == "" && sel.attr == "" {
+			return nil, fmt.Errorf("选择器为空")
+		}
+		if sel.css != "" {
+			if _, err := cascadia.Compile(sel.css); err != nil {
+				return nil, fmt.Errorf("CSS选择器无效: %v", err)
+			}
+		}
+	}
+
+	for _, part := range parts[1:] {
+		f, err := parseFilter(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		sel.filters = append(sel.filters, f)
+	}
+	return sel, nil
+}
+
+// parseFilter 解析过滤器
+func parseFilter(text string) (filter, error) {
+	name, arg, _ := strings.Cut(text, ":")
+	f := filter{name: name, arg: arg}
+
+	switch name {
+	case "trim", "number", "unix", "unixms", "absurl":
+	case "prefix", "default":
+		if arg == "" {
+			return f, fmt.Errorf("过滤器 %s 需要参数", name)
+		}
+	case "regex":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return f, fmt.Errorf("过滤器 regex 的正则表达式无效: %v", err)
+		}
+		f.re = re
+	default:
+		return f, fmt.Errorf("未知的过滤器 %s (可选 trim、number、unix、unixms、absurl、prefix、default、regex)", name)
+	}
+	return f, nil
+}
+
+// extractor 从一页响应中取值
+type extractor struct {
+	def     *Definition
+	pageURL *url.URL // 请求地址，用于 absurl
+}
+
+// jsonValue 在 JSON 记录上执行选择器
+func (x *extractor) jsonValue(item interface{}, sel *selector) (interface{}, error) {
+	return x.applyFilters(sel.path.first(item), sel.filters)
+}
+
+// htmlValue 在 HTML 记录元素上执行选择器
+func (x *extractor) htmlValue(item *goquery.Selection, sel *selector) (interface{}, error) {
+	node := item
+	if sel.css != "" {
+		node = item.Find(sel.css).First()
+	}
+
+	var value interface{}
+	if node.Length() > 0 {
+		if sel.attr != "" {
+			if attr, ok := node.Attr(sel.attr); ok {
+				value = strings.TrimSpace(attr)
+			}
+		} else {
+			value = strings.TrimSpace(node.Text())
+		}
+	}
+	return x.applyFilters(value, sel.filters)
+}
+
+// applyFilters 依次执行过滤器
+func (x *extractor) applyFilters(value interface{}, filters []filter) (interface{}, error) {
+	for _, f := range filters {
+		var err error
+		if value, err = x.applyFilter(value, f); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// applyFilter 执行过滤器
+//
+//	trim          去掉首尾空白
+//	number        提取文本中的第一个整数，如 "123评论" 得到 123
+//	unix          秒级时间戳转换为 "2006-01-02 15:04:05"（使用定义的时区）
+//	unixms        毫秒级时间戳转换为 "2006-01-02 15:04:05"
+//	absurl        相对链接转换为绝对链接
+//	prefix:文本   在值前添加文本
+//	default:文本  值为空时使用文本
+//	regex:表达式  取第一个分组（没有分组时取整个匹配），不匹配时为空
+func (x *extractor) applyFilter(value interface{}, f filter) (interface{}, error) {
+	text := toString(value)
+
+	switch f.name {
+	case "trim":
+		return strings.TrimSpace(text), nil
+	case "number":
+		return numberRegex.FindString(text), nil
+	case "unix", "unixms":
+		if text == "" {
+			return "", nil
+		}
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("过滤器 %s: 时间戳无效: %q", f.name, text)
+		}
+		var t time.Time
+		if f.name == "unix" {
+			t = time.Unix(int64(n), 0)
+		} else {
+			t = time.UnixMilli(int64(n))
+		}
+		return t.In(x.def.location).Format(timeLayout), nil
+	case "absurl":
+		if text == "" || x.pageURL == nil {
+			return text, nil
+		}
+		ref, err := url.Parse(text)
+		if err != nil {
+			return text, nil
+		}
+		return x.pageURL.ResolveReference(ref).String(), nil
+	case "prefix":
+		if text == "" {
+			return "", nil
+		}
+		return f.arg + text, nil
+	case "default":
+		if text == "" {
+			return f.arg, nil
+		}
+		return value, nil
+	case "regex":
+		matches := f.re.FindStringSubmatch(text)
+		switch {
+		case matches == nil:
+			return "", nil
+		case len(matches) > 1:
+			return matches[1], nil
+		default:
+			return matches[0], nil
+		}
+	}
+	return value, nil
+}
+
+// setField 将取到的值写入模型字段，按字段类型转换
+func setField(field reflect.Value, name string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(toString(value))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		text := strings.TrimSpace(toString(value))
+		if text == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			f, ferr := strconv.ParseFloat(text, 64)
+			if ferr != nil {
+				return fmt.Errorf("字段 %s: 无法将 %q 转换为整数", name, text)
+			}
+			n = int64(f)
+		}
+		field.SetInt(n)
+	case reflect.Bool:
+		switch v := value.(type) {
+		case bool:
+			field.SetBool(v)
+		default:
+			text := strings.ToLower(strings.TrimSpace(toString(v)))
+			field.SetBool(text != "" && text != "0" && text != "false")
+		}
+	default:
+		return fmt.Errorf("字段 %s 的类型 %s 不支持映射", name, field.Kind())
+	}
+	return nil
+}
+
+// fill 按字段映射填充模型，get 返回选择器取到的值
+func fill(target interface{}, fields map[string]*selector, get func(*selector) (interface{}, error)) error {
+	v := reflect.ValueOf(target).Elem()
+	index := modelFields(v.Type())
+
+	for _, name := range sortedKeys(fields) {
+		value, err := get(fields[name])
+		if err != nil {
+			return fmt.Errorf("字段 %s: %v", name, err)
+		}
+		if err := setField(v.Field(index[name]), name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toString 将 JSON 或 HTML 取到的值转换为文本
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// isErrorCode 判断错误码是否表示失败
+func isErrorCode(value interface{}) bool {
+	text := strings.TrimSpace(toString(value))
+	return text != "" && text != "0" && text != "false"
+}
+
+// sortedKeys 按字母顺序返回 map 的键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,127 @@
+package site
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPath 编译后的 JSONPath
+// 支持的语法：$ 根节点（字段选择器中为当前记录）、.name、['name']、[n]（负数从末尾计）、.* 和 [*]
+type jsonPath []pathStep
+
+// pathStep JSONPath 中的一步
+type pathStep struct {
+	key      string // 对象键
+	index    int    // 数组下标
+	isIndex  bool   // 是否按数组下标选取
+	wildcard bool   // 是否选取所有子节点
+}
+
+// compileJSONPath 编译 JSONPath 表达式
+func compileJSONPath(expr string) (jsonPath, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("JSONPath 必须以 $ 开头: %q", expr)
+	}
+
+	var path jsonPath
+	rest := expr[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".*"):
+			path = append(path, pathStep{wildcard: true})
+			rest = rest[2:]
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("JSONPath %q 中有空的字段名", expr)
+			}
+			path = append(path, pathStep{key: key})
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath %q 缺少 ]", expr)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			switch {
+			case inner == "*":
+				path = append(path, pathStep{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				path = append(path, pathStep{key: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("JSONPath %q 中的下标无效: %s", expr, inner)
+				}
+				path = append(path, pathStep{index: index, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("JSONPath %q 无法解析: %s", expr, rest)
+		}
+	}
+	return path, nil
+}
+
+// eval 在 JSON 值上执行路径，返回所有匹配的节点
+func (p jsonPath) eval(value interface{}) []interface{} {
+	nodes := []interface{}{value}
+	for _, step := range p {
+		var next []interface{}
+		for _, node := range nodes {
+			next = append(next, step.apply(node)...)
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// first 返回第一个匹配的节点，没有匹配时返回 nil
+func (p jsonPath) first(value interface{}) interface{} {
+	nodes := p.eval(value)
+	if len(nodes) == 0 {
+		return nil
+	}
+	return nodes[0]
+}
+
+// apply 在单个节点上执行一步
+func (s pathStep) apply(node interface{}) []interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if s.wildcard {
+			children := make([]interface{}, 0, len(v))
+			for _, key := range sortedKeys(v) {
+				children = append(children, v[key])
+			}
+			return children
+		}
+		if child, ok := v[s.key]; ok && !s.isIndex {
+			return []interface{}{child}
+		}
+	case []interface{}:
+		if s.wildcard {
+			return v
+		}
+		if s.isIndex {
+			index := s.index
+			if index < 0 {
+				index += len(v)
+			}
+			if index >= 0 && index < len(v) {
+				return []interface{}{v[index]}
+			}
+		}
+	}
+	return nil
+}
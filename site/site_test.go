@@ -0,0 +1,202 @@
+package site
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bili-comment/model"
+	"bili-comment/source"
+)
+
+// loadBundled 读取仓库自带的 gamersky-wap 站点定义
+func loadBundled(t *testing.T) *Definition {
+	t.Helper()
+	defs, err := LoadDir("../sites")
+	if err != nil {
+		t.Fatalf("读取站点定义失败: %v", err)
+	}
+	for _, def := range defs {
+		if def.Name == "gamersky-wap" {
+			return def
+		}
+	}
+	t.Fatal("没有找到 gamersky-wap 站点定义")
+	return nil
+}
+
+func TestHTMLListFromSnapshot(t *testing.T) {
+	html, err := os.ReadFile("../html-snapshot/wap-gs.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(html)
+	}))
+	defer server.Close()
+
+	def := loadBundled(t)
+	def.List.URL = server.URL + "/"
+	def.RateLimit = RateLimit{}
+
+	items, err := newSource(def).ListItems(context.Background(), "", 1, source.Options{})
+	if err != nil {
+		t.Fatalf("获取条目失败: %v", err)
+	}
+	if len(items) == 0 {
+		t.Fatal("快照中没有解析出条目")
+	}
+
+	withTitle := 0
+	for _, item := range items {
+		news := item.(model.NewsInfo)
+		if news.SID == "" {
+			t.Errorf("条目缺少ID: %+v", news)
+		}
+		if news.Title != "" {
+			withTitle++
+		}
+		if news.URL != "" && !strings.HasPrefix(news.URL, "http") {
+			t.Errorf("链接未转换为绝对地址: %s", news.URL)
+		}
+	}
+	if withTitle == 0 {
+		t.Error("没有解析出标题")
+	}
+
+	// 定义中列表只有一页
+	items, err = newSource(def).ListItems(context.Background(), "", 2, source.Options{})
+	if err != nil || len(items) != 0 {
+		t.Errorf("第2页应为空: %d 条, %v", len(items), err)
+	}
+}
+
+func TestJSONCommentsWithReplies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := r.URL.Query().Get("request")
+		if !strings.Contains(request, `"articleId":"100"`) {
+			t.Errorf("请求参数缺少文章ID: %s", request)
+		}
+		switch {
+		case strings.Contains(request, `"pageIndex":1,`):
+			// 第1页：page_size 条一级评论，第一条带一条回复
+			var comments []string
+			for i := 1; i <= 20; i++ {
+				replies := "[]"
+				if i == 1 {
+					replies = `[{"replyId":9001,"userName":"乙","replyContent":"回复","createTime":1700000060000,"objectCommentId":1,"objectUserName":"甲"}]`
+				}
+				comments = append(comments, fmt.Sprintf(`{"comment_id":%d,"nickname":"甲","content":"评论%d","create_time":1700000000000,"support_count":"3","is_best":true,"replies":%s}`, i, i, replies))
+			}
+			fmt.Fprintf(w, `{"errorCode":0,"result":{"comments":[%s]}}`, strings.Join(comments, ","))
+		case strings.Contains(request, `"pageIndex":2,`):
+			// 第2页不足 page_size 条，之后停止翻页
+			fmt.Fprint(w, `{"errorCode":0,"result":{"comments":[{"comment_id":21,"nickname":"丙","content":"最后","create_time":1700000000000,"replies":[]}]}}`)
+		default:
+			t.Errorf("不应请求该页: %s", request)
+		}
+	}))
+	defer server.Close()
+
+	def := loadBundled(t)
+	def.Comments.URL = server.URL
+	def.RateLimit = RateLimit{}
+
+	emit, collected := source.Collect()
+	count, err := newSource(def).FetchComments(context.Background(), "100", source.Options{}, emit)
+	if err != nil {
+		t.Fatalf("爬取评论失败: %v", err)
+	}
+	if count != 22 {
+		t.Fatalf("评论数 = %d, 期望 22", count)
+	}
+
+	records := collected()
+	first := records[0].(model.GamerskyComment)
+	if first.ID != 1 || first.ArticleID != "100" || first.SupportCount != 3 || !first.IsBest {
+		t.Errorf("一级评论映射错误: %+v", first)
+	}
+	if first.CommentTime != "2023-11-15 06:13:20" {
+		t.Errorf("评论时间 = %s, 期望 2023-11-15 06:13:20", first.CommentTime)
+	}
+
+	reply := records[1].(model.GamerskyComment)
+	if reply.ID != 9001 || reply.ParentID != 1 || reply.AnswerToID != 1 || reply.AnswerToName != "甲" {
+		t.Errorf("回复映射错误: %+v", reply)
+	}
+}
+
+func TestAPIErrorCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"errorCode":5,"errorMessage":"参数错误"}`)
+	}))
+	defer server.Close()
+
+	def := loadBundled(t)
+	def.Comments.URL = server.URL
+	def.RateLimit = RateLimit{}
+
+	records, err := newSource(def).fetchPage(context.Background(), def.Comments, 1, "100", "", 0)
+	if err == nil || !strings.Contains(err.Error(), "参数错误") {
+		t.Fatalf("期望API错误, 得到 %d 条记录, %v", len(records), err)
+	}
+}
+
+func TestInvalidDefinitions(t *testing.T) {
+	cases := map[string]string{
+		"未知字段":   "name: x\nlist:\n  url: http://a\n  items: $.a\n  fields:\n    sid: $.id\n    nope: $.b\n",
+		"缺少必需字段": "name: x\ncomments:\n  url: http://a\n  items: $.a\n  fields:\n    content: $.c\n",
+		"无效名称":   "name: X Y\nlist:\n  url: http://a\n  items: $.a\n  fields:\n    sid: $.id\n",
+		"未知过滤器":  "name: x\nlist:\n  url: http://a\n  items: $.a\n  fields:\n    sid: $.id | upper\n",
+		"无效选择器":  "name: x\nlist:\n  url: http://a\n  format: html\n  items: li[\n  fields:\n    sid: \"@id\"\n",
+		"未知配置项":  "name: x\nlimit: 1\nlist:\n  url: http://a\n  items: $.a\n  fields:\n    sid: $.id\n",
+	}
+
+	dir := t.TempDir()
+	for name, content := range cases {
+		path := filepath.Join(dir, "site.yaml")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: 期望校验失败", name)
+		}
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	root := map[string]interface{}{
+		"a": []interface{}{
+			map[string]interface{}{"b": "x"},
+			map[string]interface{}{"b": "y", "c d": "z"},
+		},
+	}
+
+	cases := map[string][]interface{}{
+		"$.a[*].b":       {"x", "y"},
+		"$.a[-1]['c d']": {"z"},
+		"$['a'][0].b":    {"x"},
+		"$.missing":      nil,
+	}
+	for expr, want := range cases {
+		path, err := compileJSONPath(expr)
+		if err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		got := path.eval(root)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s = %v, 期望 %v", expr, got, want)
+		}
+	}
+
+	for _, expr := range []string{"a.b", "$.a[", "$.a[x]", "$..a"} {
+		if _, err := compileJSONPath(expr); err == nil {
+			t.Errorf("%s: 期望编译失败", expr)
+		}
+	}
+}
@@ -0,0 +1,469 @@
+package site
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bili-comment/httpclient"
+	"bili-comment/model"
+	"bili-comment/source"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// userAgent 定义未设置 User-Agent 时使用的默认值
+const userAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36"
+
+// siteSource 由YAML站点定义驱动的评论来源插件
+type siteSource struct {
+	def *Definition
+
+	mu          sync.Mutex
+	lastRequest time.Time // 上次请求的时间，用于频率限制
+}
+
+// newSource 根据站点定义创建评论来源插件
+func newSource(def *Definition) *siteSource {
+	return &siteSource{def: def}
+}
+
+// Register 将站点定义注册为评论来源插件，名称与已有来源重复时返回错误
+func Register(defs []*Definition) error {
+	for _, def := range defs {
+		if _, err := source.Get(def.Name); err == nil {
+			return fmt.Errorf("站点定义 %s 的名称 %s 与已有的评论来源重复", def.path, def.Name)
+		}
+		source.Register(newSource(def))
+	}
+	return nil
+}
+
+func (s *siteSource) Name() string {
+	return s.def.Name
+}
+
+func (s *siteSource) Description() string {
+	if s.def.Description != "" {
+		return s.def.Description
+	}
+	return "YAML站点定义 " + s.def.path
+}
+
+func (s *siteSource) DefaultOutputPath() string {
+	if s.def.Output != "" {
+		return s.def.Output
+	}
+	return "./data/" + s.def.Name + ".db"
+}
+
+func (s *siteSource) ListItems(ctx context.Context, query string, page int, opts source.Options) ([]interface{}, error) {
+	ep := s.def.List
+	if ep == nil {
+		return nil, fmt.Errorf("站点 %s 没有定义条目列表 (list)", s.def.Name)
+	}
+	if query != "" && !strings.Contains(ep.templates(), "{query}") {
+		return nil, fmt.Errorf("站点 %s 的条目列表不支持关键词搜索", s.def.Name)
+	}
+	if opts.Order != "" {
+		return nil, fmt.Errorf("站点 %s 不支持排序 %s", s.def.Name, opts.Order)
+	}
+	if opts.Channel != "" {
+		return nil, fmt.Errorf("站点 %s 不支持频道 %s", s.def.Name, opts.Channel)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if ep.Pagination.MaxPages > 0 && page > ep.Pagination.MaxPages {
+		log.Printf("站点 %s 的条目列表最多 %d 页", s.def.Name, ep.Pagination.MaxPages)
+		return nil, nil
+	}
+
+	records, err := s.fetchPage(ctx, ep, page, "", query, opts.RequestDelay)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]interface{}, 0, len(records))
+	now := time.Now().Format(timeLayout)
+	for _, r := range records {
+		news := model.NewsInfo{}
+		if err := fill(&news, ep.fields, r.get); err != nil {
+			log.Printf("解析条目失败: %v", err)
+			continue
+		}
+		if news.SID == "" {
+			log.Printf("跳过没有ID的条目: %s", news.Title)
+			continue
+		}
+		if news.CreateTime == "" {
+			news.CreateTime = now
+		}
+		items = append(items, news)
+	}
+	return items, nil
+}
+
+func (s *siteSource) FetchComments(ctx context.Context, itemID string, opts source.Options, emit source.EmitFunc) (int, error) {
+	ep := s.def.Comments
+	if ep == nil {
+		return 0, fmt.Errorf("站点 %s 没有定义评论接口 (comments)", s.def.Name)
+	}
+	if opts.Order != "" {
+		return 0, fmt.Errorf("站点 %s 不支持排序 %s", s.def.Name, opts.Order)
+	}
+	if opts.MinPraises > 0 {
+		return 0, fmt.Errorf("站点 %s 不支持按最低点赞数过滤", s.def.Name)
+	}
+
+	// 命令行页数和定义中的页数限制取较小值
+	maxPages := opts.Pages
+	if limit := ep.Pagination.MaxPages; limit > 0 && (maxPages <= 0 || limit < maxPages) {
+		maxPages = limit
+	}
+
+	totalCount := 0
+	var previousFirst int64
+	for page := 1; maxPages <= 0 || page <= maxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return totalCount, err
+		}
+
+		log.Printf("正在爬取 %s 条目 %s 第 %d 页评论...", s.def.Name, itemID, page)
+
+		comments, topLevel, firstID, err := s.fetchComments(ctx, ep, itemID, page, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return totalCount, ctx.Err()
+			}
+			opts.Run.RecordError(err)
+			log.Printf("爬取第 %d 页评论失败: %v", page, err)
+			break
+		}
+
+		// 站点忽略页码时每页内容相同，避免无限翻页
+		if topLevel > 0 && page > 1 && firstID == previousFirst {
+			log.Printf("第 %d 页与上一页相同，停止爬取", page)
+			break
+		}
+		previousFirst = firstID
+
+		count := 0
+		for _, comment := range comments {
+			if !inTimeRange(comment.CommentTime, s.def.location, opts.Since, opts.Until) {
+				continue
+			}
+			inserted, err := emit(comment)
+			opts.Run.RecordSave(inserted, err)
+			if err != nil {
+				log.Printf("保存评论失败 (ID: %d): %v", comment.ID, err)
+				continue
+			}
+			count++
+		}
+
+		totalCount += count
+		log.Printf("第 %d 页爬取完成，获取 %d 条评论", page, count)
+
+		if topLevel == 0 || (ep.Pagination.PageSize > 0 && topLevel < ep.Pagination.PageSize) {
+			log.Printf("第 %d 页没有更多评论，停止爬取", page)
+			break
+		}
+	}
+
+	return totalCount, nil
+}
+
+// fetchComments 获取一页评论，返回评论和回复、一级评论的数量和第一条一级评论的ID
+// 时间范围外的一级评论连同其回复一起跳过
+func (s *siteSource) fetchComments(ctx context.Context, ep *Endpoint, itemID string, page int, opts source.Options) ([]model.GamerskyComment, int, int64, error) {
+	records, err := s.fetchPage(ctx, ep, page, itemID, "", opts.RequestDelay)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var comments []model.GamerskyComment
+	var firstID int64
+	topLevel := 0
+	for _, r := range records {
+		comment, err := s.newComment(ep.fields, r, itemID, 0)
+		if err != nil {
+			log.Printf("解析评论失败: %v", err)
+			continue
+		}
+		if topLevel == 0 {
+			firstID = comment.ID
+		}
+		topLevel++
+		if !inTimeRange(comment.CommentTime, s.def.location, opts.Since, opts.Until) {
+			continue
+		}
+		comments = append(comments, comment)
+
+		if ep.Replies == nil {
+			continue
+		}
+		for _, rr := range r.children(ep.Replies) {
+			reply, err := s.newComment(ep.Replies.fields, rr, itemID, comment.ID)
+			if err != nil {
+				log.Printf("跳过无效回复数据: %v", err)
+				continue
+			}
+			comments = append(comments, reply)
+		}
+	}
+	return comments, topLevel, firstID, nil
+}
+
+// newComment 按字段映射创建评论，parentID 为回复所属的一级评论ID
+func (s *siteSource) newComment(fields map[string]*selector, r record, itemID string, parentID int64) (model.GamerskyComment, error) {
+	comment := model.GamerskyComment{}
+	if err := fill(&comment, fields, r.get); err != nil {
+		return comment, err
+	}
+	if comment.ID == 0 {
+		return comment, fmt.Errorf("评论ID为空")
+	}
+
+	comment.ArticleID = itemID
+	if comment.ParentID == 0 {
+		comment.ParentID = parentID
+	}
+	if comment.CreateTime == "" {
+		comment.CreateTime = time.Now().Format(timeLayout)
+	}
+	return comment, nil
+}
+
+// inTimeRange 判断时间是否在 [since, until) 范围内，无法解析的时间视为在范围内
+func inTimeRange(value string, loc *time.Location, since, until time.Time) bool {
+	if since.IsZero() && until.IsZero() {
+		return true
+	}
+	t, err := time.ParseInLocation(timeLayout, value, loc)
+	if err != nil {
+		return true
+	}
+	if !since.IsZero() && t.Before(since) {
+		return false
+	}
+	if !until.IsZero() && !t.Before(until) {
+		return false
+	}
+	return true
+}
+
+// record 响应中的一条记录 (JSON节点或HTML元素)
+type record struct {
+	x    *extractor
+	json interface{}
+	html *goquery.Selection
+}
+
+// get 在记录上执行字段选择器
+func (r record) get(sel *selector) (interface{}, error) {
+	if r.html != nil {
+		return r.x.htmlValue(r.html, sel)
+	}
+	return r.x.jsonValue(r.json, sel)
+}
+
+// children 选取记录中嵌套的回复
+func (r record) children(m *Mapping) []record {
+	if r.html != nil {
+		return htmlRecords(r.x, r.html, m.Items)
+	}
+	return jsonRecords(r.x, r.json, m.items)
+}
+
+// jsonRecords 选取JSON记录，选取到数组时展开
+func jsonRecords(x *extractor, root interface{}, items jsonPath) []record {
+	var records []record
+	for _, node := range items.eval(root) {
+		if list, ok := node.([]interface{}); ok {
+			for _, item := range list {
+				records = append(records, record{x: x, json: item})
+			}
+			continue
+		}
+		records = append(records, record{x: x, json: node})
+	}
+	return records
+}
+
+// htmlRecords 选取HTML记录元素
+func htmlRecords(x *extractor, root *goquery.Selection, items string) []record {
+	var records []record
+	root.Find(items).Each(func(_ int, sel *goquery.Selection) {
+		records = append(records, record{x: x, html: sel})
+	})
+	return records
+}
+
+// fetchPage 请求接口的第 page 页 (从1开始) 并选取记录
+func (s *siteSource) fetchPage(ctx context.Context, ep *Endpoint, page int, itemID, query string, delay time.Duration) ([]record, error) {
+	pageNumber := ep.Pagination.Start + page - 1
+	vars := map[string]string{
+		"page":      strconv.Itoa(pageNumber),
+		"offset":    strconv.Itoa((page - 1) * ep.Pagination.PageSize),
+		"page_size": strconv.Itoa(ep.Pagination.PageSize),
+		"item":      itemID,
+		"query":     query,
+	}
+
+	req, err := s.newRequest(ctx, ep, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.wait(ctx, delay); err != nil {
+		return nil, err
+	}
+
+	resp, err := httpclient.Default().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+
+	log.Printf("%s 响应状态: %d, 大小: %d bytes", s.def.Name, resp.StatusCode, len(body))
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
+	}
+
+	x := &extractor{def: s.def, pageURL: req.URL}
+	if ep.Format == FormatHTML {
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("解析HTML失败: %v", err)
+		}
+		return htmlRecords(x, doc.Selection, ep.Items), nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var root interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, fmt.Errorf("解析JSON失败: %v, 响应内容: %s", err, string(body[:min(200, len(body))]))
+	}
+
+	if ep.Error != nil {
+		if code := ep.Error.code.first(root); isErrorCode(code) {
+			message := ""
+			if ep.Error.message != nil {
+				message = toString(ep.Error.message.first(root))
+			}
+			return nil, fmt.Errorf("API错误: %s (代码: %s)", message, toString(code))
+		}
+	}
+	return jsonRecords(x, root, ep.items), nil
+}
+
+// newRequest 替换占位符并创建请求
+func (s *siteSource) newRequest(ctx context.Context, ep *Endpoint, vars map[string]string) (*http.Request, error) {
+	rawPairs := make([]string, 0, len(vars)*2)
+	escapedPairs := make([]string, 0, len(vars)*2)
+	for key, value := range vars {
+		rawPairs = append(rawPairs, "{"+key+"}", value)
+		escapedPairs = append(escapedPairs, "{"+key+"}", url.QueryEscape(value))
+	}
+	raw := strings.NewReplacer(rawPairs...)
+
+	u, err := url.Parse(strings.NewReplacer(escapedPairs...).Replace(ep.URL))
+	if err != nil {
+		return nil, fmt.Errorf("请求地址无效: %v", err)
+	}
+	if len(ep.Params) > 0 {
+		params := u.Query()
+		for _, key := range sortedKeys(ep.Params) {
+			params.Set(key, raw.Replace(ep.Params[key]))
+		}
+		u.RawQuery = params.Encode()
+	}
+
+	var body io.Reader
+	if ep.Body != "" {
+		body = strings.NewReader(raw.Replace(ep.Body))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, ep.Method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+
+	// 设置请求头，接口的请求头覆盖站点共用的请求头
+	req.Header.Set("User-Agent", userAgent)
+	for key, value := range s.def.Headers {
+		req.Header.Set(key, value)
+	}
+	for key, value := range ep.Headers {
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+// wait 按频率限制等待，请求间隔取站点定义和命令行 --delay 中的较大值
+func (s *siteSource) wait(ctx context.Context, delay time.Duration) error {
+	interval := s.def.RateLimit.Interval()
+	if delay > interval {
+		interval = delay
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.lastRequest.IsZero() {
+		if err := httpclient.Sleep(ctx, time.Until(s.lastRequest.Add(interval))); err != nil {
+			return err
+		}
+	}
+	s.lastRequest = time.Now()
+	return nil
+}
+
+func (s *siteSource) Normalize(record interface{}) (source.Entry, error) {
+	switch r := record.(type) {
+	case model.GamerskyComment:
+		entry := source.Entry{
+			Source:  s.def.Name,
+			Kind:    source.KindComment,
+			ID:      strconv.FormatInt(r.ID, 10),
+			ItemID:  r.ArticleID,
+			Author:  r.Username,
+			Content: r.Content,
+			Time:    r.CommentTime,
+			Likes:   int64(r.SupportCount),
+			Replies: int64(r.ReplyCount),
+		}
+		if r.ParentID != 0 {
+			entry.ParentID = strconv.FormatInt(r.ParentID, 10)
+		}
+		return entry, nil
+	case model.NewsInfo:
+		return source.Entry{
+			Source:  s.def.Name,
+			Kind:    source.KindItem,
+			ID:      r.SID,
+			Title:   r.Title,
+			Time:    r.Time,
+			Replies: int64(r.CommentNum),
+			URL:     r.URL,
+		}, nil
+	default:
+		return source.Entry{}, fmt.Errorf("站点 %s 不支持的记录类型: %T", s.def.Name, record)
+	}
+}
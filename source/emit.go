@@ -0,0 +1,85 @@
+package source
+
+import (
+	"bili-comment/model"
+	"bili-comment/store"
+)
+
+// EmitStore 将保存操作转交给 EmitFunc 的存储
+// 插件用它包装已有的爬虫：爬虫照常调用 Save* 方法，记录经由 emit 交给调用方处理。查询方法返回空结果。
+type EmitStore struct {
+	emit EmitFunc
+}
+
+// NewEmitStore 创建转交保存操作的存储
+func NewEmitStore(emit EmitFunc) *EmitStore {
+	return &EmitStore{emit: emit}
+}
+
+// Collect 返回收集记录的 EmitFunc 和读取已收集记录的函数，所有记录都视为新记录
+func Collect() (EmitFunc, func() []interface{}) {
+	var records []interface{}
+	emit := func(record interface{}) (bool, error) {
+		records = append(records, record)
+		return true, nil
+	}
+	return emit, func() []interface{} { return records }
+}
+
+func (s *EmitStore) SaveComment(comment model.CommentInfo, runID int64) (bool, error) {
+	return s.emit(comment)
+}
+
+func (s *EmitStore) SaveGamerskyComment(comment model.GamerskyComment, runID int64) (bool, error) {
+	return s.emit(comment)
+}
+
+func (s *EmitStore) SaveGamerskyCommentImage(image model.GamerskyCommentImage, runID int64) (bool, error) {
+	return s.emit(image)
+}
+
+func (s *EmitStore) SaveGamerskyCommentOrder(order model.GamerskyCommentOrder, runID int64) error {
+	_, err := s.emit(order)
+	return err
+}
+
+func (s *EmitStore) QueryGamerskyComments(filter store.CommentFilter) ([]model.GamerskyComment, error) {
+	return nil, nil
+}
+
+func (s *EmitStore) SaveVideo(video model.VideoInfo, runID int64) (bool, error) {
+	return s.emit(video)
+}
+
+func (s *EmitStore) QueryVideos(keyword string, limit int) ([]model.VideoInfo, error) {
+	return nil, nil
+}
+
+func (s *EmitStore) SaveNews(news model.NewsInfo, runID int64) (bool, error) {
+	return s.emit(store.FillPublishedAt(news))
+}
+
+func (s *EmitStore) QueryNews(offset, limit int) ([]model.NewsInfo, error) {
+	return nil, nil
+}
+
+func (s *EmitStore) SaveArticle(article model.GamerskyArticle, runID int64) (bool, error) {
+	return s.emit(article)
+}
+
+func (s *EmitStore) HasArticle(sid string) (bool, error) {
+	return false, nil
+}
+
+// SaveCrawlState 爬取状态不是条目数据，不输出
+func (s *EmitStore) SaveCrawlState(state model.GamerskyCrawlState, runID int64) error {
+	return nil
+}
+
+func (s *EmitStore) QueryCrawlStates() (map[string]model.GamerskyCrawlState, error) {
+	return nil, nil
+}
+
+func (s *EmitStore) Close() error {
+	return nil
+}
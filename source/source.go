@@ -0,0 +1,117 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"bili-comment/runlog"
+)
+
+// Source 评论来源插件
+// 每个站点实现一个插件并在 init 中调用 Register 注册，list、crawl 等通用命令通过名称使用插件。
+// 插件只负责获取和解析站点数据，数据的保存由调用方完成。
+type Source interface {
+	// Name 插件名称，即命令行 --source 的取值
+	Name() string
+	// Description 插件说明
+	Description() string
+	// DefaultOutputPath 默认的数据库路径
+	DefaultOutputPath() string
+
+	// ListItems 获取第 page 页可爬取评论的条目（视频、文章等），query 为搜索关键词，不需要时为空
+	// 返回的条目为站点的数据结构，可用 Normalize 转换为统一格式
+	ListItems(ctx context.Context, query string, page int, opts Options) ([]interface{}, error)
+
+	// FetchComments 获取条目的评论，每获取一条评论或回复调用一次 emit
+	// emit 返回该记录是否为新记录，插件据此统计运行记录；emit 返回错误时只记录日志，不中止爬取
+	FetchComments(ctx context.Context, itemID string, opts Options, emit EmitFunc) (int, error)
+
+	// Normalize 将 ListItems 或 FetchComments 返回的站点数据转换为统一格式
+	Normalize(record interface{}) (Entry, error)
+}
+
+// 内置插件名称
+const (
+	Bilibili = "bilibili"
+	Gamersky = "gamersky"
+)
+
+// EmitFunc 接收插件获取到的一条记录，返回是否为新记录
+type EmitFunc func(record interface{}) (bool, error)
+
+// Options 插件的通用参数，插件忽略不适用的参数
+type Options struct {
+	Order        string        // 评论排序，为空时使用来源的默认排序
+	MinPraises   int           // 只获取点赞数不少于该值的评论 (0=不限制，来源支持时)
+	Channel      string        // 条目列表的频道或分类，为空时使用来源的默认列表
+	Pages        int           // 最多爬取的评论页数 (0=全部)
+	PageSize     int           // 条目列表每页数量 (0=来源默认)
+	WithReplies  bool          // 是否爬取回复
+	ReplyPages   int           // 每条评论最多爬取的回复页数 (0=来源默认)
+	Resume       bool          // 从上次中断保存的断点继续（来源支持时）
+	CookiePath   string        // Cookie文件路径（来源需要登录时）
+	RequestDelay time.Duration // 请求间隔
+	Since        time.Time     // 只获取该时间及之后的评论 (零值表示不限制)
+	Until        time.Time     // 只获取该时间之前的评论 (零值表示不限制)
+	Run          *runlog.Run   // 本次运行记录 (可为空)
+}
+
+// 统一格式的记录类型
+const (
+	KindItem    = "item"    // 条目：视频、文章
+	KindComment = "comment" // 评论或回复
+)
+
+// Entry 统一格式的条目或评论
+type Entry struct {
+	Source   string // 插件名称
+	Kind     string // KindItem 或 KindComment
+	ID       string // 条目ID或评论ID
+	ItemID   string // 评论所属条目的ID，条目本身为空
+	ParentID string // 回复的上级评论ID，条目和一级评论为空
+	Title    string // 条目标题，评论为所属条目的标题（已知时）
+	Author   string // 条目作者或评论用户名
+	Content  string // 评论内容或条目简介
+	Time     string // 发布时间
+	Likes    int64  // 点赞数
+	Replies  int64  // 评论数（条目）或回复数（评论）
+	URL      string // 条目链接
+}
+
+// IsReply 判断评论是否为回复
+func (e Entry) IsReply() bool {
+	return e.ParentID != ""
+}
+
+var registry = map[string]Source{}
+
+// Register 注册插件，名称重复时 panic
+func Register(s Source) {
+	name := s.Name()
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("评论来源 %s 重复注册", name))
+	}
+	registry[name] = s
+}
+
+// Get 按名称获取插件
+func Get(name string) (Source, error) {
+	s, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("未知的评论来源: %s (可选 %s)", name, strings.Join(Names(), "、"))
+	}
+	return s, nil
+}
+
+// Names 获取所有已注册的插件名称
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
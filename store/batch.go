@@ -0,0 +1,250 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 批量写入的默认参数
+const (
+	DefaultBatchSize     = 500                    // 每批最多写入的行数
+	DefaultFlushInterval = 200 * time.Millisecond // 批次开始后最长多久提交
+)
+
+// writeRequest 一次写入请求
+type writeRequest struct {
+	query string
+	args  []interface{}
+	reply chan writeResult
+}
+
+// writeResult 写入结果，inserted 表示插入了新行
+type writeResult struct {
+	inserted bool
+	err      error
+}
+
+// batchWriter 批量写入协程
+// 所有写入在同一个连接上使用预编译语句执行，每写入 size 行或批次开始 interval 后提交一次事务。
+// 写入在事务内执行完成后立即返回是否插入了新行，提交前其他连接看不到这些数据；
+// 提交失败时整批数据都不会写入，错误由下一次 write、flush 或 close 返回，调用方据此得知之前报告的新行未能保存。
+type batchWriter struct {
+	db       *sql.DB
+	conn     *sql.Conn
+	size     int
+	interval time.Duration
+
+	mu       sync.RWMutex
+	closed   bool
+	requests chan writeRequest
+	flushes  chan chan error
+	done     chan struct{}
+
+	// 以下字段只在写入协程中使用
+	tx      *sql.Tx
+	stmts   map[string]*sql.Stmt // 连接池上的预编译语句
+	txStmts map[string]*sql.Stmt // 当前事务中的语句，提交后失效
+	pending int
+	timer   *time.Timer
+	timerC  <-chan time.Time
+	lastErr error // 定时提交失败的错误，在下一次写入或关闭时返回
+}
+
+// newBatchWriter 创建批量写入协程，独占数据库连接池中的一个连接
+func newBatchWriter(db *sql.DB, size int, interval time.Duration) (*batchWriter, error) {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	w := &batchWriter{
+		db:       db,
+		conn:     conn,
+		size:     size,
+		interval: interval,
+		requests: make(chan writeRequest),
+		flushes:  make(chan chan error),
+		done:     make(chan struct{}),
+		stmts:    make(map[string]*sql.Stmt),
+		txStmts:  make(map[string]*sql.Stmt),
+	}
+	go w.run()
+	return w, nil
+}
+
+// write 提交一次写入并等待执行结果
+func (w *batchWriter) write(query string, args ...interface{}) (bool, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return false, fmt.Errorf("存储已关闭")
+	}
+
+	reply := make(chan writeResult, 1)
+	w.requests <- writeRequest{query: query, args: args, reply: reply}
+	result := <-reply
+	return result.inserted, result.err
+}
+
+// flush 立即提交当前批次
+func (w *batchWriter) flush() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return nil
+	}
+
+	reply := make(chan error, 1)
+	w.flushes <- reply
+	return <-reply
+}
+
+// close 提交剩余数据并停止写入协程
+func (w *batchWriter) close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.requests)
+	w.mu.Unlock()
+
+	<-w.done
+
+	for _, stmt := range w.stmts {
+		stmt.Close()
+	}
+	if err := w.conn.Close(); err != nil && w.lastErr == nil {
+		return err
+	}
+	return w.lastErr
+}
+
+// run 写入协程主循环
+func (w *batchWriter) run() {
+	defer close(w.done)
+
+	for {
+		select {
+		case req, ok := <-w.requests:
+			if !ok {
+				if err := w.commit(); err != nil && w.lastErr == nil {
+					w.lastErr = err
+				}
+				return
+			}
+			req.reply <- w.exec(req)
+		case reply := <-w.flushes:
+			err := w.commit()
+			if err == nil {
+				err = w.takeLastErr()
+			}
+			reply <- err
+		case <-w.timerC:
+			if err := w.commit(); err != nil && w.lastErr == nil {
+				w.lastErr = err
+			}
+		}
+	}
+}
+
+// exec 在当前批次的事务中执行写入，达到批次大小时提交
+func (w *batchWriter) exec(req writeRequest) writeResult {
+	if err := w.takeLastErr(); err != nil {
+		return writeResult{err: err}
+	}
+
+	if w.tx == nil {
+		tx, err := w.conn.BeginTx(context.Background(), nil)
+		if err != nil {
+			return writeResult{err: err}
+		}
+		w.tx = tx
+		w.startTimer()
+	}
+
+	stmt, ok := w.txStmts[req.query]
+	if !ok {
+		prepared, err := w.prepare(req.query)
+		if err != nil {
+			return writeResult{err: err}
+		}
+		stmt = w.tx.Stmt(prepared)
+		w.txStmts[req.query] = stmt
+	}
+
+	ok, err := inserted(stmt.Exec(req.args...))
+	if err != nil {
+		return writeResult{err: err}
+	}
+
+	w.pending++
+	if w.pending >= w.size {
+		if err := w.commit(); err != nil {
+			return writeResult{err: fmt.Errorf("提交批次失败: %v", err)}
+		}
+	}
+
+	return writeResult{inserted: ok}
+}
+
+// takeLastErr 取出定时提交失败的错误，每个错误只返回一次
+func (w *batchWriter) takeLastErr() error {
+	if w.lastErr == nil {
+		return nil
+	}
+	err := w.lastErr
+	w.lastErr = nil
+	return fmt.Errorf("上一批数据提交失败: %v", err)
+}
+
+// prepare 获取预编译语句
+// 语句在连接池上预编译，tx.Stmt 会复用其在写入连接上的预编译结果，各批次事务无需重新编译
+func (w *batchWriter) prepare(query string) (*sql.Stmt, error) {
+	if stmt, ok := w.stmts[query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := w.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	w.stmts[query] = stmt
+	return stmt, nil
+}
+
+// commit 提交当前批次，没有未提交数据时为空操作
+func (w *batchWriter) commit() error {
+	if w.tx == nil {
+		return nil
+	}
+
+	w.stopTimer()
+	err := w.tx.Commit()
+	w.tx = nil
+	w.pending = 0
+	for query := range w.txStmts {
+		delete(w.txStmts, query)
+	}
+	return err
+}
+
+func (w *batchWriter) startTimer() {
+	if w.timer == nil {
+		w.timer = time.NewTimer(w.interval)
+	} else {
+		w.timer.Reset(w.interval)
+	}
+	w.timerC = w.timer.C
+}
+
+func (w *batchWriter) stopTimer() {
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timerC = nil
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openCommitFailDB 创建一个提交时才检查外键的数据库，插入没有对应 parent 的 child 行会在提交时失败
+func openCommitFailDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "batch.db")+"?_foreign_keys=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	statements := []string{
+		`CREATE TABLE parent (id INTEGER PRIMARY KEY)`,
+		`CREATE TABLE child (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parent(id) DEFERRABLE INITIALLY DEFERRED)`,
+		`INSERT INTO parent (id) VALUES (1)`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+const insertChild = `INSERT OR IGNORE INTO child (id, parent_id) VALUES (?, ?)`
+
+// countChildren 统计已提交的 child 行数
+func countChildren(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM child`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestBatchWriterCommitFailure(t *testing.T) {
+	db := openCommitFailDB(t)
+	w, err := newBatchWriter(db, 100, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 写入时只在事务内执行，提交前无法发现外键错误
+	if ok, err := w.write(insertChild, 1, 1); !ok || err != nil {
+		t.Fatalf("写入 = %t, %v", ok, err)
+	}
+	if ok, err := w.write(insertChild, 2, 99); !ok || err != nil {
+		t.Fatalf("写入 = %t, %v", ok, err)
+	}
+
+	// 提交失败时 flush 返回错误，整批数据都没有写入
+	if err := w.flush(); err == nil {
+		t.Fatal("flush 应返回提交失败的错误")
+	}
+	if n := countChildren(t, db); n != 0 {
+		t.Errorf("提交失败后 child 行数 = %d, 期望 0", n)
+	}
+
+	// 提交失败后写入协程仍可继续写入
+	if ok, err := w.write(insertChild, 3, 1); !ok || err != nil {
+		t.Fatalf("提交失败后写入 = %t, %v", ok, err)
+	}
+	if err := w.flush(); err != nil {
+		t.Fatalf("flush 失败: %v", err)
+	}
+	if n := countChildren(t, db); n != 1 {
+		t.Errorf("child 行数 = %d, 期望 1", n)
+	}
+
+	// 关闭时提交剩余数据，失败时返回错误
+	if _, err := w.write(insertChild, 4, 99); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.close(); err == nil {
+		t.Error("close 应返回提交失败的错误")
+	}
+}
+
+func TestBatchWriterTimedCommitFailure(t *testing.T) {
+	db := openCommitFailDB(t)
+	w, err := newBatchWriter(db, 100, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.close()
+
+	if _, err := w.write(insertChild, 1, 99); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// 定时提交失败的错误由之后的 flush 返回，且只返回一次
+	if err := w.flush(); err == nil {
+		t.Fatal("flush 应返回定时提交失败的错误")
+	}
+	if err := w.flush(); err != nil {
+		t.Errorf("错误已返回后 flush = %v, 期望 nil", err)
+	}
+	if ok, err := w.write(insertChild, 2, 1); !ok || err != nil {
+		t.Errorf("写入 = %t, %v", ok, err)
+	}
+}
@@ -0,0 +1,274 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DiffReport 两个数据库之间的差异
+type DiffReport struct {
+	Old             string          `json:"old"`              // 旧数据库路径
+	New             string          `json:"new"`              // 新数据库路径
+	Threshold       int64           `json:"threshold"`        // 计数变化阈值
+	Summary         DiffSummary     `json:"summary"`          // 各类差异的总数
+	NewNews         []NewsChange    `json:"new_news"`         // 新增的新闻
+	RemovedNews     []NewsChange    `json:"removed_news"`     // 删除的新闻
+	NewComments     []CommentGroup  `json:"new_comments"`     // 按文章或视频统计的新增评论
+	ChangedComments []CounterChange `json:"changed_comments"` // 点赞数或回复数变化达到阈值的评论
+}
+
+// DiffSummary 各类差异的总数，列表被截断时仍为完整数量
+type DiffSummary struct {
+	NewNews         int   `json:"new_news"`
+	RemovedNews     int   `json:"removed_news"`
+	NewComments     int64 `json:"new_comments"`
+	ChangedComments int   `json:"changed_comments"`
+}
+
+// NewsChange 新增或删除的新闻
+type NewsChange struct {
+	SID        string `json:"sid"`
+	Title      string `json:"title"`
+	CommentNum int    `json:"comment_num"`
+}
+
+// CommentGroup 一篇文章或一个视频下的新增评论数
+type CommentGroup struct {
+	Source   string `json:"source"`    // bilibili / gamersky
+	TargetID string `json:"target_id"` // 视频BV号或文章ID
+	Title    string `json:"title"`     // 视频标题或文章标题
+	Count    int64  `json:"count"`     // 新增评论数
+}
+
+// CounterChange 计数发生变化的评论
+type CounterChange struct {
+	Source     string `json:"source"`
+	ID         int64  `json:"id"`
+	TargetID   string `json:"target_id"`
+	Username   string `json:"username"`
+	Content    string `json:"content"`
+	OldLikes   int64  `json:"old_likes"`
+	NewLikes   int64  `json:"new_likes"`
+	OldReplies int64  `json:"old_replies"`
+	NewReplies int64  `json:"new_replies"`
+}
+
+// diffSources 评论差异的查询定义，新库为 main，旧库为 old
+var diffSources = []struct {
+	source      string
+	newComments string
+	changed     string
+}{
+	{
+		source: SourceGamersky,
+		newComments: `SELECT c.article_id, COALESCE(MAX(n.title), ''), COUNT(*)
+			FROM main.gamersky_comments c LEFT JOIN main.gamersky_news n ON n.sid = c.article_id
+			WHERE NOT EXISTS (SELECT 1 FROM old.gamersky_comments o WHERE o.id = c.id)
+			GROUP BY c.article_id`,
+		changed: `SELECT c.id, c.article_id, COALESCE(c.username, ''), COALESCE(c.content, ''),
+			COALESCE(o.support_count, 0), COALESCE(c.support_count, 0), COALESCE(o.reply_count, 0), COALESCE(c.reply_count, 0)
+			FROM main.gamersky_comments c JOIN old.gamersky_comments o ON o.id = c.id
+			WHERE ABS(COALESCE(c.support_count, 0) - COALESCE(o.support_count, 0)) >= ?1
+			OR ABS(COALESCE(c.reply_count, 0) - COALESCE(o.reply_count, 0)) >= ?1`,
+	},
+	{
+		source: SourceBilibili,
+		newComments: `SELECT COALESCE(c.bv, ''), COALESCE(MAX(c.video_title), ''), COUNT(*)
+			FROM main.bilibili_comments c
+			WHERE NOT EXISTS (SELECT 1 FROM old.bilibili_comments o WHERE o.comment_id = c.comment_id)
+			GROUP BY c.bv`,
+		changed: `SELECT c.comment_id, COALESCE(c.bv, ''), COALESCE(c.username, ''), COALESCE(c.content, ''),
+			COALESCE(o.like_count, 0), COALESCE(c.like_count, 0), COALESCE(o.reply_count, 0), COALESCE(c.reply_count, 0)
+			FROM main.bilibili_comments c JOIN old.bilibili_comments o ON o.comment_id = c.comment_id
+			WHERE ABS(COALESCE(c.like_count, 0) - COALESCE(o.like_count, 0)) >= ?1
+			OR ABS(COALESCE(c.reply_count, 0) - COALESCE(o.reply_count, 0)) >= ?1`,
+	},
+}
+
+// DiffDatabases 比较两个数据库，threshold 为计数变化阈值，limit 限制每个列表的条数 (<=0 表示不限制)
+// 两个数据库都先复制到临时文件并迁移到最新版本，不会修改输入文件
+func DiffDatabases(oldPath, newPath string, threshold int64, limit int) (*DiffReport, error) {
+	for _, path := range []string{oldPath, newPath} {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("数据库不存在: %s", path)
+		}
+	}
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	tmpDir, err := os.MkdirTemp("", "bili-comment-diff-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	oldCopy := filepath.Join(tmpDir, "old.db")
+	newCopy := filepath.Join(tmpDir, "new.db")
+	if err := copyAndMigrate(oldPath, oldCopy); err != nil {
+		return nil, err
+	}
+	if err := copyAndMigrate(newPath, newCopy); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", newCopy)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// ATTACH 只对当前连接生效
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("ATTACH DATABASE ? AS old", oldCopy); err != nil {
+		return nil, fmt.Errorf("附加数据库失败: %v", err)
+	}
+
+	report := &DiffReport{
+		Old:             oldPath,
+		New:             newPath,
+		Threshold:       threshold,
+		NewComments:     []CommentGroup{},
+		ChangedComments: []CounterChange{},
+	}
+
+	if report.NewNews, err = diffNews(db, "main", "old"); err != nil {
+		return nil, fmt.Errorf("比较新闻失败: %v", err)
+	}
+	if report.RemovedNews, err = diffNews(db, "old", "main"); err != nil {
+		return nil, fmt.Errorf("比较新闻失败: %v", err)
+	}
+
+	for _, source := range diffSources {
+		groups, err := diffNewComments(db, source.source, source.newComments)
+		if err != nil {
+			return nil, fmt.Errorf("比较评论失败: %v", err)
+		}
+		report.NewComments = append(report.NewComments, groups...)
+
+		changes, err := diffChangedComments(db, source.source, source.changed, threshold)
+		if err != nil {
+			return nil, fmt.Errorf("比较评论计数失败: %v", err)
+		}
+		report.ChangedComments = append(report.ChangedComments, changes...)
+	}
+
+	sortCommentGroups(report.NewComments)
+	sortCounterChanges(report.ChangedComments)
+
+	report.Summary = DiffSummary{
+		NewNews:         len(report.NewNews),
+		RemovedNews:     len(report.RemovedNews),
+		ChangedComments: len(report.ChangedComments),
+	}
+	for _, group := range report.NewComments {
+		report.Summary.NewComments += group.Count
+	}
+
+	if limit > 0 {
+		if len(report.NewNews) > limit {
+			report.NewNews = report.NewNews[:limit]
+		}
+		if len(report.RemovedNews) > limit {
+			report.RemovedNews = report.RemovedNews[:limit]
+		}
+		if len(report.NewComments) > limit {
+			report.NewComments = report.NewComments[:limit]
+		}
+		if len(report.ChangedComments) > limit {
+			report.ChangedComments = report.ChangedComments[:limit]
+		}
+	}
+
+	return report, nil
+}
+
+// diffNews 查询 from 库中存在而 other 库中不存在的新闻
+func diffNews(db *sql.DB, from, other string) ([]NewsChange, error) {
+	rows, err := db.Query(fmt.Sprintf(`
+	SELECT n.sid, COALESCE(n.title, ''), COALESCE(n.comment_num, 0)
+	FROM %s.gamersky_news n
+	WHERE NOT EXISTS (SELECT 1 FROM %s.gamersky_news o WHERE o.sid = n.sid)
+	ORDER BY n.create_time DESC, n.sid`, from, other))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	news := []NewsChange{}
+	for rows.Next() {
+		var item NewsChange
+		if err := rows.Scan(&item.SID, &item.Title, &item.CommentNum); err != nil {
+			return nil, err
+		}
+		news = append(news, item)
+	}
+	return news, rows.Err()
+}
+
+// diffNewComments 按文章或视频统计新增评论数
+func diffNewComments(db *sql.DB, source, query string) ([]CommentGroup, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []CommentGroup
+	for rows.Next() {
+		group := CommentGroup{Source: source}
+		if err := rows.Scan(&group.TargetID, &group.Title, &group.Count); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+// diffChangedComments 查询计数变化达到阈值的评论
+func diffChangedComments(db *sql.DB, source, query string, threshold int64) ([]CounterChange, error) {
+	rows, err := db.Query(query, threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []CounterChange
+	for rows.Next() {
+		change := CounterChange{Source: source}
+		err := rows.Scan(&change.ID, &change.TargetID, &change.Username, &change.Content,
+			&change.OldLikes, &change.NewLikes, &change.OldReplies, &change.NewReplies)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// sortCommentGroups 按新增评论数倒序排列
+func sortCommentGroups(groups []CommentGroup) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Count > groups[j].Count
+	})
+}
+
+// sortCounterChanges 按点赞数和回复数的变化量之和倒序排列
+func sortCounterChanges(changes []CounterChange) {
+	delta := func(c CounterChange) int64 {
+		return abs(c.NewLikes-c.OldLikes) + abs(c.NewReplies-c.OldReplies)
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return delta(changes[i]) > delta(changes[j])
+	})
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
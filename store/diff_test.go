@@ -0,0 +1,114 @@
+package store
+
+import (
+	"encoding/json"
+	"testing"
+
+	"bili-comment/model"
+)
+
+func TestDiffDatabases(t *testing.T) {
+	oldPath := newMergeFixture(t, "old.db",
+		[]model.NewsInfo{
+			{SID: "1", Title: "第一条", CommentNum: 2},
+			{SID: "2", Title: "第二条", CommentNum: 5},
+		},
+		[]model.GamerskyComment{
+			{ID: 10, ArticleID: "1", Username: "a", Content: "旧评论", SupportCount: 5, ReplyCount: 1},
+			{ID: 12, ArticleID: "1", Username: "c", Content: "变化较小", SupportCount: 3},
+		})
+
+	// 删除新闻2、新增新闻3；评论10点赞数增加，评论12变化低于阈值，新增评论11和13
+	newPath := newMergeFixture(t, "new.db",
+		[]model.NewsInfo{
+			{SID: "1", Title: "第一条", CommentNum: 4},
+			{SID: "3", Title: "第三条", CommentNum: 1},
+		},
+		[]model.GamerskyComment{
+			{ID: 10, ArticleID: "1", Username: "a", Content: "旧评论", SupportCount: 20, ReplyCount: 3},
+			{ID: 11, ArticleID: "1", Username: "b", Content: "新评论"},
+			{ID: 12, ArticleID: "1", Username: "c", Content: "变化较小", SupportCount: 4},
+			{ID: 13, ArticleID: "3", Username: "d", Content: "另一篇"},
+		})
+
+	report, err := DiffDatabases(oldPath, newPath, 2, 0)
+	if err != nil {
+		t.Fatalf("比较数据库失败: %v", err)
+	}
+
+	// 按 db diff --format=json 的输出检查
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Threshold int64 `json:"threshold"`
+		Summary   struct {
+			NewNews         int   `json:"new_news"`
+			RemovedNews     int   `json:"removed_news"`
+			NewComments     int64 `json:"new_comments"`
+			ChangedComments int   `json:"changed_comments"`
+		} `json:"summary"`
+		NewNews []struct {
+			SID   string `json:"sid"`
+			Title string `json:"title"`
+		} `json:"new_news"`
+		RemovedNews []struct {
+			SID string `json:"sid"`
+		} `json:"removed_news"`
+		NewComments []struct {
+			Source   string `json:"source"`
+			TargetID string `json:"target_id"`
+			Title    string `json:"title"`
+			Count    int64  `json:"count"`
+		} `json:"new_comments"`
+		ChangedComments []struct {
+			Source     string `json:"source"`
+			ID         int64  `json:"id"`
+			OldLikes   int64  `json:"old_likes"`
+			NewLikes   int64  `json:"new_likes"`
+			OldReplies int64  `json:"old_replies"`
+			NewReplies int64  `json:"new_replies"`
+		} `json:"changed_comments"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("解析JSON失败: %v\n%s", err, data)
+	}
+
+	if got.Threshold != 2 {
+		t.Errorf("threshold = %d, 期望 2", got.Threshold)
+	}
+	if s := got.Summary; s.NewNews != 1 || s.RemovedNews != 1 || s.NewComments != 2 || s.ChangedComments != 1 {
+		t.Errorf("summary = %+v", s)
+	}
+	if len(got.NewNews) != 1 || got.NewNews[0].SID != "3" || got.NewNews[0].Title != "第三条" {
+		t.Errorf("new_news = %+v", got.NewNews)
+	}
+	if len(got.RemovedNews) != 1 || got.RemovedNews[0].SID != "2" {
+		t.Errorf("removed_news = %+v", got.RemovedNews)
+	}
+
+	// 每篇文章各新增一条评论
+	if len(got.NewComments) != 2 {
+		t.Fatalf("new_comments = %+v", got.NewComments)
+	}
+	groups := map[string]string{}
+	for _, group := range got.NewComments {
+		if group.Source != SourceGamersky || group.Count != 1 {
+			t.Errorf("new_comments 中的分组 = %+v", group)
+		}
+		groups[group.TargetID] = group.Title
+	}
+	if groups["1"] != "第一条" || groups["3"] != "第三条" {
+		t.Errorf("new_comments 的文章 = %v", groups)
+	}
+
+	// 只有评论10的计数变化达到阈值
+	if len(got.ChangedComments) != 1 {
+		t.Fatalf("changed_comments = %+v", got.ChangedComments)
+	}
+	if c := got.ChangedComments[0]; c.Source != SourceGamersky || c.ID != 10 ||
+		c.OldLikes != 5 || c.NewLikes != 20 || c.OldReplies != 1 || c.NewReplies != 3 {
+		t.Errorf("changed_comments[0] = %+v", c)
+	}
+}
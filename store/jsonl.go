@@ -0,0 +1,440 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"bili-comment/model"
+)
+
+// JSONL 文件名，与SQLite表名一致
+const (
+	bilibiliCommentsFile = "bilibili_comments.jsonl"
+	bilibiliVideosFile   = "bilibili_videos.jsonl"
+	gamerskyNewsFile     = "gamersky_news.jsonl"
+	gamerskyCommentsFile = "gamersky_comments.jsonl"
+	commentImagesFile    = "gamersky_comment_images.jsonl"
+	articlesFile         = "gamersky_articles.jsonl"
+	crawlStatesFile      = "gamersky_comment_crawls.jsonl"
+	commentOrdersFile    = "gamersky_comment_orders.jsonl"
+)
+
+// jsonlFile 一个只追加的JSONL文件及其已写入记录的主键
+type jsonlFile struct {
+	file *os.File
+	keys map[string]bool
+}
+
+// JSONLStore 只追加的JSONL目录存储，每种数据写入目录下的一个 .jsonl 文件
+// 每行一条记录，附带 run_id 字段；打开文件时读取已有记录的主键用于去重
+type JSONLStore struct {
+	mu    sync.Mutex
+	dir   string
+	files map[string]*jsonlFile
+}
+
+// 每行记录在数据字段之外附带写入时的运行ID
+type (
+	commentRecord struct {
+		model.CommentInfo
+		RunID int64 `json:"run_id"`
+	}
+	videoRecord struct {
+		model.VideoInfo
+		RunID int64 `json:"run_id"`
+	}
+	newsRecord struct {
+		model.NewsInfo
+		RunID int64 `json:"run_id"`
+	}
+	gamerskyCommentRecord struct {
+		model.GamerskyComment
+		RunID int64 `json:"run_id"`
+	}
+	commentImageRecord struct {
+		model.GamerskyCommentImage
+		RunID int64 `json:"run_id"`
+	}
+	articleRecord struct {
+		model.GamerskyArticle
+		RunID int64 `json:"run_id"`
+	}
+	crawlStateRecord struct {
+		model.GamerskyCrawlState
+		RunID int64 `json:"run_id"`
+	}
+	commentOrderRecord struct {
+		model.GamerskyCommentOrder
+		RunID int64 `json:"run_id"`
+	}
+)
+
+// OpenJSONL 打开JSONL目录存储，目录不存在时自动创建
+func OpenJSONL(dir string) (*JSONLStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %v", err)
+	}
+
+	return &JSONLStore{
+		dir:   dir,
+		files: make(map[string]*jsonlFile),
+	}, nil
+}
+
+// open 打开JSONL文件，首次打开时读取已有记录的主键
+func (s *JSONLStore) open(name string, keyOf func(line []byte) (string, error)) (*jsonlFile, error) {
+	if f, ok := s.files[name]; ok {
+		return f, nil
+	}
+
+	path := filepath.Join(s.dir, name)
+	keys := make(map[string]bool)
+	err := readLines(path, func(line []byte) error {
+		key, err := keyOf(line)
+		if err != nil {
+			return err
+		}
+		keys[key] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &jsonlFile{file: file, keys: keys}
+	s.files[name] = f
+	return f, nil
+}
+
+// appendRecord 追加一条记录，主键已存在时忽略
+func (s *JSONLStore) appendRecord(name, key string, keyOf func(line []byte) (string, error), record interface{}) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.open(name, keyOf)
+	if err != nil {
+		return false, err
+	}
+
+	if f.keys[key] {
+		return false, nil
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return false, err
+	}
+	if _, err := f.file.Write(append(data, '\n')); err != nil {
+		return false, err
+	}
+
+	f.keys[key] = true
+	return true, nil
+}
+
+// readLines 逐行读取JSONL文件，文件不存在时视为空
+func readLines(path string, fn func(line []byte) error) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return fmt.Errorf("%s 第 %d 行: %v", filepath.Base(path), lineNum, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// commentKey B站评论以评论ID为主键
+func commentKey(line []byte) (string, error) {
+	var record model.CommentInfo
+	if err := json.Unmarshal(line, &record); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(record.CommentID, 10), nil
+}
+
+// videoRecordKey 视频以关键词和BV号为主键
+func videoRecordKey(line []byte) (string, error) {
+	var record model.VideoInfo
+	if err := json.Unmarshal(line, &record); err != nil {
+		return "", err
+	}
+	return videoKey(record), nil
+}
+
+// newsKey 新闻以SID为主键
+func newsKey(line []byte) (string, error) {
+	var record model.NewsInfo
+	if err := json.Unmarshal(line, &record); err != nil {
+		return "", err
+	}
+	return record.SID, nil
+}
+
+// gamerskyCommentKey Gamersky评论以评论ID为主键
+func gamerskyCommentKey(line []byte) (string, error) {
+	var record model.GamerskyComment
+	if err := json.Unmarshal(line, &record); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(record.ID, 10), nil
+}
+
+// commentImageRecordKey 评论图片以评论ID和图片顺序为主键
+func commentImageRecordKey(line []byte) (string, error) {
+	var record model.GamerskyCommentImage
+	if err := json.Unmarshal(line, &record); err != nil {
+		return "", err
+	}
+	return commentImageKey(record), nil
+}
+
+// commentOrderRecordKey 评论排序记录以评论ID和排序方式为主键
+func commentOrderRecordKey(line []byte) (string, error) {
+	var record model.GamerskyCommentOrder
+	if err := json.Unmarshal(line, &record); err != nil {
+		return "", err
+	}
+	return commentOrderKey(record), nil
+}
+
+// crawlStateKey 爬取状态以新闻ID为主键
+func crawlStateKey(line []byte) (string, error) {
+	var record model.GamerskyCrawlState
+	if err := json.Unmarshal(line, &record); err != nil {
+		return "", err
+	}
+	return record.SID, nil
+}
+
+// articleKey 文章以SID为主键
+func articleKey(line []byte) (string, error) {
+	var record model.GamerskyArticle
+	if err := json.Unmarshal(line, &record); err != nil {
+		return "", err
+	}
+	return record.SID, nil
+}
+
+// SaveComment 保存B站评论
+func (s *JSONLStore) SaveComment(comment model.CommentInfo, runID int64) (bool, error) {
+	return s.appendRecord(bilibiliCommentsFile, strconv.FormatInt(comment.CommentID, 10), commentKey,
+		commentRecord{CommentInfo: comment, RunID: runID})
+}
+
+// SaveVideo 保存视频搜索结果
+func (s *JSONLStore) SaveVideo(video model.VideoInfo, runID int64) (bool, error) {
+	return s.appendRecord(bilibiliVideosFile, videoKey(video), videoRecordKey,
+		videoRecord{VideoInfo: video, RunID: runID})
+}
+
+// QueryVideos 查询视频信息
+func (s *JSONLStore) QueryVideos(keyword string, limit int) ([]model.VideoInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var videos []model.VideoInfo
+	err := readLines(filepath.Join(s.dir, bilibiliVideosFile), func(line []byte) error {
+		var video model.VideoInfo
+		if err := json.Unmarshal(line, &video); err != nil {
+			return err
+		}
+		videos = append(videos, video)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return filterVideos(videos, keyword, limit), nil
+}
+
+// SaveNews 保存Gamersky新闻
+// 文件只追加，已存在的新闻不更新；评论数历史只保存在SQLite存储中
+func (s *JSONLStore) SaveNews(news model.NewsInfo, runID int64) (bool, error) {
+	return s.appendRecord(gamerskyNewsFile, news.SID, newsKey,
+		newsRecord{NewsInfo: FillPublishedAt(news), RunID: runID})
+}
+
+// QueryNews 查询Gamersky新闻
+func (s *JSONLStore) QueryNews(offset, limit int) ([]model.NewsInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var news []model.NewsInfo
+	err := readLines(filepath.Join(s.dir, gamerskyNewsFile), func(line []byte) error {
+		var item model.NewsInfo
+		if err := json.Unmarshal(line, &item); err != nil {
+			return err
+		}
+		news = append(news, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pageNews(news, offset, limit), nil
+}
+
+// SaveGamerskyComment 保存Gamersky评论
+func (s *JSONLStore) SaveGamerskyComment(comment model.GamerskyComment, runID int64) (bool, error) {
+	return s.appendRecord(gamerskyCommentsFile, strconv.FormatInt(comment.ID, 10), gamerskyCommentKey,
+		gamerskyCommentRecord{GamerskyComment: comment, RunID: runID})
+}
+
+// SaveGamerskyCommentImage 保存Gamersky评论图片
+// 文件只追加，图片已存在时不再记录之后下载得到的内容哈希
+func (s *JSONLStore) SaveGamerskyCommentImage(image model.GamerskyCommentImage, runID int64) (bool, error) {
+	return s.appendRecord(commentImagesFile, commentImageKey(image), commentImageRecordKey,
+		commentImageRecord{GamerskyCommentImage: image, RunID: runID})
+}
+
+// SaveGamerskyCommentOrder 记录一级评论在某种排序方式下的名次
+// 文件只追加，只记录评论在每种排序方式下第一次出现时的名次和时间
+func (s *JSONLStore) SaveGamerskyCommentOrder(order model.GamerskyCommentOrder, runID int64) error {
+	_, err := s.appendRecord(commentOrdersFile, commentOrderKey(order), commentOrderRecordKey,
+		commentOrderRecord{GamerskyCommentOrder: order, RunID: runID})
+	return err
+}
+
+// QueryGamerskyComments 查询Gamersky评论
+func (s *JSONLStore) QueryGamerskyComments(filter CommentFilter) ([]model.GamerskyComment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var comments []model.GamerskyComment
+	err := readLines(filepath.Join(s.dir, gamerskyCommentsFile), func(line []byte) error {
+		var comment model.GamerskyComment
+		if err := json.Unmarshal(line, &comment); err != nil {
+			return err
+		}
+		comments = append(comments, comment)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return filterGamerskyComments(comments, filter), nil
+}
+
+// SaveArticle 保存Gamersky文章正文
+// 文件只追加，重新获取的文章追加为新的一行，读取时以最后一行为准
+func (s *JSONLStore) SaveArticle(article model.GamerskyArticle, runID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.open(articlesFile, articleKey)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := json.Marshal(articleRecord{GamerskyArticle: article, RunID: runID})
+	if err != nil {
+		return false, err
+	}
+	if _, err := f.file.Write(append(data, '\n')); err != nil {
+		return false, err
+	}
+
+	exists := f.keys[article.SID]
+	f.keys[article.SID] = true
+	return !exists, nil
+}
+
+// HasArticle 判断是否已保存文章正文
+func (s *JSONLStore) HasArticle(sid string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.open(articlesFile, articleKey)
+	if err != nil {
+		return false, err
+	}
+	return f.keys[sid], nil
+}
+
+// SaveCrawlState 记录新闻评论的爬取状态
+// 文件只追加，每次爬取追加一行，读取时以最后一行为准
+func (s *JSONLStore) SaveCrawlState(state model.GamerskyCrawlState, runID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.open(crawlStatesFile, crawlStateKey)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(crawlStateRecord{GamerskyCrawlState: state, RunID: runID})
+	if err != nil {
+		return err
+	}
+	if _, err := f.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	f.keys[state.SID] = true
+	return nil
+}
+
+// QueryCrawlStates 查询所有新闻的评论爬取状态，同一新闻以最后一行为准
+func (s *JSONLStore) QueryCrawlStates() (map[string]model.GamerskyCrawlState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states := make(map[string]model.GamerskyCrawlState)
+	err := readLines(filepath.Join(s.dir, crawlStatesFile), func(line []byte) error {
+		var state model.GamerskyCrawlState
+		if err := json.Unmarshal(line, &state); err != nil {
+			return err
+		}
+		states[state.SID] = state
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// Close 关闭所有已打开的文件
+func (s *JSONLStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for name, f := range s.files {
+		if err := f.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(s.files, name)
+	}
+	return firstErr
+}
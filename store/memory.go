@@ -0,0 +1,310 @@
+package store
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"bili-comment/model"
+)
+
+// MemoryStore 内存存储，主要用于测试和不需要落盘的试运行
+type MemoryStore struct {
+	mu sync.Mutex
+
+	comments         []model.CommentInfo
+	commentIDs       map[int64]bool
+	videos           []model.VideoInfo
+	videoKeys        map[string]bool
+	news             []model.NewsInfo
+	newsIDs          map[string]int // 新闻ID到 news 下标
+	gamerskyComments []model.GamerskyComment
+	gamerskyIDs      map[int64]bool
+	commentImages    map[string]model.GamerskyCommentImage
+	commentOrders    map[string]model.GamerskyCommentOrder
+	articles         map[string]model.GamerskyArticle
+	crawlStates      map[string]model.GamerskyCrawlState
+}
+
+// NewMemory 创建内存存储
+func NewMemory() *MemoryStore {
+	return &MemoryStore{
+		commentIDs:    make(map[int64]bool),
+		videoKeys:     make(map[string]bool),
+		newsIDs:       make(map[string]int),
+		gamerskyIDs:   make(map[int64]bool),
+		commentImages: make(map[string]model.GamerskyCommentImage),
+		commentOrders: make(map[string]model.GamerskyCommentOrder),
+		articles:      make(map[string]model.GamerskyArticle),
+		crawlStates:   make(map[string]model.GamerskyCrawlState),
+	}
+}
+
+// SaveComment 保存B站评论
+func (m *MemoryStore) SaveComment(comment model.CommentInfo, runID int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.commentIDs[comment.CommentID] {
+		return false, nil
+	}
+	m.commentIDs[comment.CommentID] = true
+	m.comments = append(m.comments, comment)
+	return true, nil
+}
+
+// Comments 获取已保存的B站评论
+func (m *MemoryStore) Comments() []model.CommentInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]model.CommentInfo(nil), m.comments...)
+}
+
+// SaveVideo 保存视频搜索结果
+func (m *MemoryStore) SaveVideo(video model.VideoInfo, runID int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := videoKey(video)
+	if m.videoKeys[key] {
+		return false, nil
+	}
+	m.videoKeys[key] = true
+	m.videos = append(m.videos, video)
+	return true, nil
+}
+
+// QueryVideos 查询视频信息
+func (m *MemoryStore) QueryVideos(keyword string, limit int) ([]model.VideoInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return filterVideos(m.videos, keyword, limit), nil
+}
+
+// SaveNews 保存Gamersky新闻
+func (m *MemoryStore) SaveNews(news model.NewsInfo, runID int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	news = FillPublishedAt(news)
+	if i, ok := m.newsIDs[news.SID]; ok {
+		m.news[i] = updateNews(m.news[i], news)
+		return false, nil
+	}
+	m.newsIDs[news.SID] = len(m.news)
+	m.news = append(m.news, news)
+	return true, nil
+}
+
+// QueryNews 查询Gamersky新闻
+func (m *MemoryStore) QueryNews(offset, limit int) ([]model.NewsInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return pageNews(m.news, offset, limit), nil
+}
+
+// SaveGamerskyComment 保存Gamersky评论
+func (m *MemoryStore) SaveGamerskyComment(comment model.GamerskyComment, runID int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.gamerskyIDs[comment.ID] {
+		return false, nil
+	}
+	m.gamerskyIDs[comment.ID] = true
+	m.gamerskyComments = append(m.gamerskyComments, comment)
+	return true, nil
+}
+
+// SaveGamerskyCommentImage 保存Gamersky评论图片
+func (m *MemoryStore) SaveGamerskyCommentImage(image model.GamerskyCommentImage, runID int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := commentImageKey(image)
+	existing, ok := m.commentImages[key]
+	if ok && (image.SHA256 == "" || image.SHA256 == existing.SHA256) {
+		return false, nil
+	}
+	if ok {
+		existing.SHA256 = image.SHA256
+		image = existing
+	}
+	m.commentImages[key] = image
+	return true, nil
+}
+
+// SaveGamerskyCommentOrder 记录一级评论在某种排序方式下的名次，已记录时覆盖为最新的名次和时间
+func (m *MemoryStore) SaveGamerskyCommentOrder(order model.GamerskyCommentOrder, runID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.commentOrders[commentOrderKey(order)] = order
+	return nil
+}
+
+// QueryGamerskyComments 查询Gamersky评论
+func (m *MemoryStore) QueryGamerskyComments(filter CommentFilter) ([]model.GamerskyComment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return filterGamerskyComments(m.gamerskyComments, filter), nil
+}
+
+// SaveArticle 保存Gamersky文章正文，文章已存在时覆盖
+func (m *MemoryStore) SaveArticle(article model.GamerskyArticle, runID int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, exists := m.articles[article.SID]
+	m.articles[article.SID] = article
+	return !exists, nil
+}
+
+// HasArticle 判断是否已保存文章正文
+func (m *MemoryStore) HasArticle(sid string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, exists := m.articles[sid]
+	return exists, nil
+}
+
+// SaveCrawlState 记录新闻评论的爬取状态，已存在时覆盖
+func (m *MemoryStore) SaveCrawlState(state model.GamerskyCrawlState, runID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.crawlStates[state.SID] = state
+	return nil
+}
+
+// QueryCrawlStates 查询所有新闻的评论爬取状态
+func (m *MemoryStore) QueryCrawlStates() (map[string]model.GamerskyCrawlState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	states := make(map[string]model.GamerskyCrawlState, len(m.crawlStates))
+	for sid, state := range m.crawlStates {
+		states[sid] = state
+	}
+	return states, nil
+}
+
+// Close 内存存储无需关闭
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+// commentOrderKey 评论排序记录以评论ID和排序方式为主键
+func commentOrderKey(order model.GamerskyCommentOrder) string {
+	return strconv.FormatInt(order.CommentID, 10) + "\x00" + order.OrderMode
+}
+
+// commentImageKey 评论图片以评论ID和图片顺序为主键
+func commentImageKey(image model.GamerskyCommentImage) string {
+	return strconv.FormatInt(image.CommentID, 10) + "\x00" + strconv.Itoa(image.ImageOrder)
+}
+
+// videoKey 视频在同一关键词下唯一
+func videoKey(video model.VideoInfo) string {
+	return video.Keyword + "\x00" + video.BVID
+}
+
+// filterVideos 按关键词过滤并按播放量倒序排列视频
+func filterVideos(videos []model.VideoInfo, keyword string, limit int) []model.VideoInfo {
+	var result []model.VideoInfo
+	for _, video := range videos {
+		if keyword == "" || video.Keyword == keyword {
+			result = append(result, video)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Play > result[j].Play
+	})
+
+	if limit >= 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// updateNews 用重新爬取到的新闻更新已有新闻，空值和为0的评论数不覆盖已有数据，已有的发布时间不更新
+func updateNews(existing, news model.NewsInfo) model.NewsInfo {
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&existing.Title, news.Title},
+		{&existing.Time, news.Time},
+		{&existing.URL, news.URL},
+		{&existing.ImageURL, news.ImageURL},
+		{&existing.TopLineTime, news.TopLineTime},
+		{&existing.Channel, news.Channel},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	if news.CommentNum > 0 {
+		existing.CommentNum = news.CommentNum
+	}
+	if existing.PublishedAt == "" {
+		existing.PublishedAt = news.PublishedAt
+	}
+	return existing
+}
+
+// pageNews 按发布时间倒序分页新闻，没有发布时间的新闻按记录时间排序，limit<=0 时返回全部
+func pageNews(news []model.NewsInfo, offset, limit int) []model.NewsInfo {
+	result := append([]model.NewsInfo(nil), news...)
+	sortKey := func(item model.NewsInfo) string {
+		if item.PublishedAt != "" {
+			return item.PublishedAt
+		}
+		return item.CreateTime
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if ki, kj := sortKey(result[i]), sortKey(result[j]); ki != kj {
+			return ki > kj
+		}
+		return result[i].CreateTime > result[j].CreateTime
+	})
+
+	if limit <= 0 {
+		return result
+	}
+	if offset >= len(result) {
+		return nil
+	}
+	result = result[offset:]
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// filterGamerskyComments 按条件过滤评论，指定文章时按评论时间倒序，否则按记录时间倒序
+func filterGamerskyComments(comments []model.GamerskyComment, filter CommentFilter) []model.GamerskyComment {
+	var result []model.GamerskyComment
+	for _, comment := range comments {
+		if filter.match(comment) {
+			result = append(result, comment)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if filter.ArticleID != "" {
+			return result[i].CommentTime > result[j].CommentTime
+		}
+		return result[i].CreateTime > result[j].CreateTime
+	})
+
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+	return result
+}
@@ -0,0 +1,225 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// mergeTable 合并数据库时一张数据表的合并规则
+type mergeTable struct {
+	name     string
+	keys     []string // 判断是否为同一条记录的列
+	counters []string // 冲突时取较大值的列：计数，或以文本保存的时间
+	earliest []string // 冲突时取较小值的列：以文本保存的首次出现时间
+	follow   []string // 输入数据库中 counters 第一列较大时一并更新的列，空值不覆盖已有数据
+	skip     []string // 不复制的列（自增主键等）
+}
+
+// mergeTables 参与合并的数据表
+// 计数只增不减，冲突时取较大值即保留最新的计数，计数较新的一方的标题、链接等也较新；
+// 评论爬取状态以较晚的爬取时间为准，同时采用当时记录的评论数；
+// 评论排序记录保留较早的首次出现时间和较晚的最近出现时间，名次以最近一次出现时为准；
+// run_id 指向来源数据库的运行记录，合并后置为0
+var mergeTables = []mergeTable{
+	{name: "gamersky_news", keys: []string{"sid"}, counters: []string{"comment_num"}, follow: []string{"title", "url", "image_url", "published_at"}},
+	{name: "gamersky_news_history", keys: []string{"sid", "recorded_at", "comment_num"}, skip: []string{"id"}},
+	{name: "gamersky_comment_crawls", keys: []string{"sid"}, counters: []string{"crawled_at"}, follow: []string{"comment_num"}},
+	{name: "gamersky_comments", keys: []string{"id"}, counters: []string{"support_count", "reply_count"}},
+	{name: "gamersky_comment_images", keys: []string{"comment_id", "image_order"}},
+	{name: "gamersky_comment_orders", keys: []string{"comment_id", "order_mode"}, counters: []string{"last_seen_at"}, earliest: []string{"first_seen_at"}, follow: []string{"rank"}},
+	{name: "gamersky_articles", keys: []string{"sid"}},
+	{name: "bilibili_videos", keys: []string{"keyword", "bvid"}, counters: []string{"play", "video_review", "favorites", "like_count", "danmaku"}, skip: []string{"id"}},
+	{name: "bilibili_comments", keys: []string{"comment_id"}, counters: []string{"like_count", "reply_count"}},
+}
+
+// MergeResult 一张数据表的合并结果
+type MergeResult struct {
+	Table    string // 数据表
+	Inserted int64  // 新插入的行数
+	Updated  int64  // 计数被更新的行数
+}
+
+// MergeDatabase 将 inPath 数据库中的数据合并到 out
+// 输入数据库先复制到临时文件并迁移到最新版本，不会修改输入文件
+func MergeDatabase(out *SQLiteStore, inPath string) ([]MergeResult, error) {
+	if _, err := os.Stat(inPath); err != nil {
+		return nil, fmt.Errorf("数据库不存在: %s", inPath)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "bili-comment-merge-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcPath := filepath.Join(tmpDir, "src.db")
+	if err := copyAndMigrate(inPath, srcPath); err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	conn, err := out.DB().Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS src", srcPath); err != nil {
+		return nil, fmt.Errorf("附加数据库失败: %v", err)
+	}
+	defer conn.ExecContext(ctx, "DETACH DATABASE src")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var results []MergeResult
+	for _, table := range mergeTables {
+		result, err := mergeTableRows(tx, table)
+		if err != nil {
+			return nil, fmt.Errorf("合并 %s 失败: %v", table.name, err)
+		}
+		results = append(results, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// copyAndMigrate 以只读方式打开输入数据库，复制到 dst 后执行结构迁移
+// VACUUM INTO 会一并复制尚未写回主文件的WAL数据
+func copyAndMigrate(src, dst string) error {
+	in, err := sql.Open("sqlite3", "file:"+src+"?mode=ro&_busy_timeout=5000")
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if _, err := in.Exec("VACUUM INTO ?", dst); err != nil {
+		return fmt.Errorf("复制数据库 %s 失败: %v", src, err)
+	}
+
+	db, err := sql.Open("sqlite3", dst)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := Migrate(db); err != nil {
+		return fmt.Errorf("迁移数据库 %s 失败: %v", src, err)
+	}
+	return nil
+}
+
+// mergeTableRows 先更新已有记录中较小的计数，再插入 keys 不存在的新记录
+func mergeTableRows(tx *sql.Tx, table mergeTable) (MergeResult, error) {
+	result := MergeResult{Table: table.name}
+
+	columns, err := tableColumns(tx, table.name)
+	if err != nil {
+		return result, err
+	}
+
+	// 更新计数，没有计数列的表只插入新记录
+	if len(table.counters) > 0 || len(table.earliest) > 0 {
+		updated, err := updateCounters(tx, table)
+		if err != nil {
+			return result, err
+		}
+		result.Updated = updated
+	}
+
+	// 插入新记录
+	var insertColumns, selectColumns []string
+	for _, column := range columns {
+		if containsString(table.skip, column) {
+			continue
+		}
+		insertColumns = append(insertColumns, column)
+		if column == "run_id" {
+			selectColumns = append(selectColumns, "0")
+		} else {
+			selectColumns = append(selectColumns, column)
+		}
+	}
+
+	// 按 keys 跳过已有记录，没有唯一约束的表（如新闻评论数历史）重复合并时也不会插入重复行
+	var match []string
+	for _, key := range table.keys {
+		match = append(match, fmt.Sprintf("m.%s IS s.%s", key, key))
+	}
+	insertSQL := fmt.Sprintf("INSERT OR IGNORE INTO main.%s (%s) SELECT %s FROM src.%s AS s WHERE NOT EXISTS (SELECT 1 FROM main.%s AS m WHERE %s)",
+		table.name, strings.Join(insertColumns, ", "), strings.Join(selectColumns, ", "), table.name,
+		table.name, strings.Join(match, " AND "))
+	inserted, err := rowsAffected(tx.Exec(insertSQL))
+	if err != nil {
+		return result, err
+	}
+	result.Inserted = inserted
+
+	return result, nil
+}
+
+// updateCounters 将已有记录中较小的计数更新为输入数据库中的值，返回被更新的行数
+// earliest 中的列更新为输入数据库中较早的非空值；
+// 输入数据库中 counters 第一列较大时，follow 中的列一并更新为输入数据库中的非空值
+func updateCounters(tx *sql.Tx, table mergeTable) (int64, error) {
+	var sets, changed, match []string
+	for _, counter := range table.counters {
+		sets = append(sets, fmt.Sprintf("%s = MAX(COALESCE(m.%s, 0), COALESCE(s.%s, 0))", counter, counter, counter))
+		changed = append(changed, fmt.Sprintf("COALESCE(s.%s, 0) > COALESCE(m.%s, 0)", counter, counter))
+	}
+	for _, column := range table.earliest {
+		sets = append(sets, fmt.Sprintf("%s = MIN(COALESCE(NULLIF(m.%s, ''), s.%s), COALESCE(NULLIF(s.%s, ''), m.%s))",
+			column, column, column, column, column))
+		changed = append(changed, fmt.Sprintf("(NULLIF(s.%s, '') IS NOT NULL AND (NULLIF(m.%s, '') IS NULL OR s.%s < m.%s))",
+			column, column, column, column))
+	}
+	for _, column := range table.follow {
+		sets = append(sets, fmt.Sprintf("%s = CASE WHEN %s THEN COALESCE(NULLIF(s.%s, ''), m.%s) ELSE m.%s END",
+			column, changed[0], column, column, column))
+	}
+	for _, key := range table.keys {
+		match = append(match, fmt.Sprintf("m.%s = s.%s", key, key))
+	}
+
+	updateSQL := fmt.Sprintf("UPDATE main.%s AS m SET %s FROM src.%s AS s WHERE %s AND (%s)",
+		table.name, strings.Join(sets, ", "), table.name,
+		strings.Join(match, " AND "), strings.Join(changed, " OR "))
+	return rowsAffected(tx.Exec(updateSQL))
+}
+
+// tableColumns 获取主数据库中数据表的列名
+func tableColumns(tx *sql.Tx, table string) ([]string, error) {
+	rows, err := tx.Query("SELECT name FROM main.pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// rowsAffected 获取语句影响的行数
+func rowsAffected(result sql.Result, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
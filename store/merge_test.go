@@ -0,0 +1,341 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bili-comment/model"
+)
+
+// newMergeFixture 创建一个只包含指定新闻和评论的数据库，返回文件路径
+func newMergeFixture(t *testing.T, name string, news []model.NewsInfo, comments []model.GamerskyComment) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	st, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range news {
+		if _, err := st.SaveNews(item, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, comment := range comments {
+		if _, err := st.SaveGamerskyComment(comment, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// saveCrawlStates 向数据库写入评论爬取状态
+func saveCrawlStates(t *testing.T, path string, states ...model.GamerskyCrawlState) {
+	t.Helper()
+
+	st, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, state := range states {
+		if err := st.SaveCrawlState(state, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// saveCommentOrders 按顺序向数据库写入评论排序记录
+func saveCommentOrders(t *testing.T, path string, orders ...model.GamerskyCommentOrder) {
+	t.Helper()
+
+	st, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, order := range orders {
+		if err := st.SaveGamerskyCommentOrder(order, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeDatabase(t *testing.T) {
+	// 第一次爬取：新闻1评论数较少，新闻2评论数较多
+	first := newMergeFixture(t, "first.db",
+		[]model.NewsInfo{
+			{SID: "1", Title: "旧标题", Time: "2025-01-01 08:00", CommentNum: 3, URL: "https://old/1", ImageURL: "https://old/1.jpg", CreateTime: "2025-01-01 09:00:00"},
+			{SID: "2", Title: "第二条", Time: "2025-01-01 10:00", CommentNum: 9, URL: "https://news/2", CreateTime: "2025-01-01 11:00:00"},
+		},
+		[]model.GamerskyComment{
+			{ID: 10, ArticleID: "1", Username: "a", CommentTime: "2025-01-01 09:30:00", SupportCount: 5, ReplyCount: 1},
+		})
+
+	// 第二次爬取：新闻1评论数增加、标题修改、没有图片；新闻2评论数减少；新增新闻3
+	second := newMergeFixture(t, "second.db",
+		[]model.NewsInfo{
+			{SID: "1", Title: "新标题", Time: "2025-01-01 08:00", CommentNum: 8, URL: "https://new/1", CreateTime: "2025-01-02 09:00:00"},
+			{SID: "2", Title: "第二条（改）", Time: "2025-01-01 10:00", CommentNum: 4, URL: "https://news/2b", CreateTime: "2025-01-02 11:00:00"},
+			{SID: "3", Title: "第三条", Time: "2025-01-02 10:00", CommentNum: 1, CreateTime: "2025-01-02 11:00:00"},
+		},
+		[]model.GamerskyComment{
+			{ID: 10, ArticleID: "1", Username: "a", CommentTime: "2025-01-01 09:30:00", SupportCount: 2, ReplyCount: 4},
+			{ID: 11, ArticleID: "1", Username: "b", CommentTime: "2025-01-02 09:30:00"},
+		})
+
+	// 新闻1第二次爬取较晚；新闻2第一次爬取较晚，评论数较少也保留第一次的记录
+	saveCrawlStates(t, first,
+		model.GamerskyCrawlState{SID: "1", CommentNum: 3, CrawledAt: "2025-01-02 09:00:00"},
+		model.GamerskyCrawlState{SID: "2", CommentNum: 9, CrawledAt: "2025-01-05 09:00:00"})
+	saveCrawlStates(t, second,
+		model.GamerskyCrawlState{SID: "1", CommentNum: 8, CrawledAt: "2025-01-03 09:00:00"},
+		model.GamerskyCrawlState{SID: "2", CommentNum: 12, CrawledAt: "2025-01-04 09:00:00"},
+		model.GamerskyCrawlState{SID: "3", CommentNum: 1, CrawledAt: "2025-01-03 10:00:00"})
+
+	out, err := OpenSQLite(filepath.Join(t.TempDir(), "merged.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	for _, in := range []string{first, second} {
+		if _, err := MergeDatabase(out, in); err != nil {
+			t.Fatalf("合并 %s 失败: %v", in, err)
+		}
+	}
+
+	// 重复合并不产生新行也不修改数据
+	results, err := MergeDatabase(out, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.Inserted != 0 || result.Updated != 0 {
+			t.Errorf("重复合并 %s: 插入 %d 行, 更新 %d 行", result.Table, result.Inserted, result.Updated)
+		}
+	}
+
+	news, err := out.QueryNews(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bySID := make(map[string]model.NewsInfo)
+	for _, item := range news {
+		bySID[item.SID] = item
+	}
+	if len(bySID) != 3 {
+		t.Fatalf("合并后的新闻 = %+v, 期望 3 条", news)
+	}
+
+	// 评论数较大的一方的标题和链接较新，空的图片不覆盖已有图片
+	want := model.NewsInfo{Title: "新标题", CommentNum: 8, URL: "https://new/1", ImageURL: "https://old/1.jpg"}
+	if got := bySID["1"]; got.Title != want.Title || got.CommentNum != want.CommentNum || got.URL != want.URL || got.ImageURL != want.ImageURL {
+		t.Errorf("新闻1 = %+v, 期望 %+v", got, want)
+	}
+	// 评论数较小的一方不覆盖已有数据
+	want = model.NewsInfo{Title: "第二条", CommentNum: 9, URL: "https://news/2"}
+	if got := bySID["2"]; got.Title != want.Title || got.CommentNum != want.CommentNum || got.URL != want.URL {
+		t.Errorf("新闻2 = %+v, 期望 %+v", got, want)
+	}
+
+	// 评论的每个计数分别取较大值
+	comments, err := out.QueryGamerskyComments(CommentFilter{ArticleID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("合并后的评论 = %+v, 期望 2 条", comments)
+	}
+	for _, comment := range comments {
+		if comment.ID == 10 && (comment.SupportCount != 5 || comment.ReplyCount != 4) {
+			t.Errorf("评论10 点赞数 %d、回复数 %d, 期望 5 和 4", comment.SupportCount, comment.ReplyCount)
+		}
+	}
+
+	// 评论数历史按 (sid, recorded_at, comment_num) 去重，保留两个数据库各自的记录
+	rows, err := out.DB().Query(`SELECT sid, comment_num FROM gamersky_news_history ORDER BY sid, comment_num`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var history []string
+	for rows.Next() {
+		var sid string
+		var commentNum int
+		if err := rows.Scan(&sid, &commentNum); err != nil {
+			t.Fatal(err)
+		}
+		history = append(history, fmt.Sprintf("%s:%d", sid, commentNum))
+	}
+	rows.Close()
+	if got := strings.Join(history, " "); got != "1:3 1:8 2:4 2:9 3:1" {
+		t.Errorf("合并后的评论数历史 = %s, 期望 1:3 1:8 2:4 2:9 3:1", got)
+	}
+
+	// 评论爬取状态取爬取时间较晚的一方
+	states, err := out.QueryCrawlStates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStates := map[string]model.GamerskyCrawlState{
+		"1": {SID: "1", CommentNum: 8, CrawledAt: "2025-01-03 09:00:00"},
+		"2": {SID: "2", CommentNum: 9, CrawledAt: "2025-01-05 09:00:00"},
+		"3": {SID: "3", CommentNum: 1, CrawledAt: "2025-01-03 10:00:00"},
+	}
+	if fmt.Sprint(states) != fmt.Sprint(wantStates) {
+		t.Errorf("合并后的爬取状态 = %v, 期望 %v", states, wantStates)
+	}
+
+	// 合并进来的数据行 run_id 为0
+	for _, table := range []string{"gamersky_news", "gamersky_news_history", "gamersky_comment_crawls"} {
+		var runIDs int
+		if err := out.DB().QueryRow(`SELECT COUNT(*) FROM ` + table + ` WHERE run_id != 0`).Scan(&runIDs); err != nil || runIDs != 0 {
+			t.Errorf("%s 中 run_id 不为0的行 %d 条, %v", table, runIDs, err)
+		}
+	}
+}
+
+// historyRecord 距离 now 多久之前记录的评论数
+type historyRecord struct {
+	ago        time.Duration
+	commentNum int
+}
+
+// setNewsHistory 按顺序用指定时间的评论数记录替换新闻的历史记录
+func setNewsHistory(t *testing.T, path, sid string, now time.Time, history ...historyRecord) {
+	t.Helper()
+
+	st, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	db := st.DB()
+	if _, err := db.Exec("DELETE FROM gamersky_news_history WHERE sid = ?", sid); err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range history {
+		recordedAt := now.Add(-record.ago).UTC().Format("2006-01-02 15:04:05")
+		if _, err := db.Exec("INSERT INTO gamersky_news_history (sid, comment_num, recorded_at, run_id) VALUES (?, ?, ?, 1)",
+			sid, record.commentNum, recordedAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMergeDatabaseNewsTrends(t *testing.T) {
+	now := time.Now()
+
+	// 较新的数据库先存在，较旧的产物合并进来后历史记录ID比新记录大
+	newer := newMergeFixture(t, "newer.db",
+		[]model.NewsInfo{{SID: "1", Title: "新闻", CommentNum: 50, URL: "https://news/1"}}, nil)
+	setNewsHistory(t, newer, "1", now, historyRecord{2 * time.Hour, 20}, historyRecord{10 * time.Minute, 50})
+
+	older := newMergeFixture(t, "older.db",
+		[]model.NewsInfo{{SID: "1", Title: "新闻", CommentNum: 30, URL: "https://news/1"}}, nil)
+	setNewsHistory(t, older, "1", now, historyRecord{3 * time.Hour, 10}, historyRecord{time.Hour, 30})
+
+	out, err := OpenSQLite(newer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if _, err := MergeDatabase(out, older); err != nil {
+		t.Fatal(err)
+	}
+
+	// 基准为时间段开始前最后记录的20，而不是ID最大的10
+	trends, err := out.NewsTrends(now.Add(-90*time.Minute), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trends) != 1 {
+		t.Fatalf("趋势条数 = %d, 期望 1", len(trends))
+	}
+	if got := trends[0]; got.CommentNum != 50 || got.Baseline != 20 || got.Growth != 30 || got.Samples != 2 {
+		t.Errorf("趋势 = %+v, 期望评论数 50、基准 20、增长 30、记录 2 条", got)
+	}
+
+	// 时间上最新的记录是50，评论数变为30时应追加历史记录
+	if _, err := out.SaveNews(model.NewsInfo{SID: "1", Title: "新闻", CommentNum: 30, URL: "https://news/1"}, 2); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err := out.DB().QueryRow("SELECT COUNT(*) FROM gamersky_news_history WHERE sid = '1' AND run_id = 2").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("评论数变化后追加历史记录 %d 条, 期望 1", count)
+	}
+}
+
+func TestMergeDatabaseCommentOrders(t *testing.T) {
+	order := func(rank int, seenAt string) model.GamerskyCommentOrder {
+		return model.GamerskyCommentOrder{CommentID: 10, ArticleID: "1", OrderMode: "latest", Rank: rank, SeenAt: seenAt}
+	}
+
+	// 较新的数据库在1月2日首次看到评论，1月3日名次变为2
+	newer := newMergeFixture(t, "newer.db", nil, nil)
+	saveCommentOrders(t, newer, order(3, "2025-01-02 10:00:00"), order(2, "2025-01-03 10:00:00"))
+	// 较旧的产物在1月1日看到评论，较晚的产物在1月4日看到评论
+	older := newMergeFixture(t, "older.db", nil, nil)
+	saveCommentOrders(t, older, order(5, "2025-01-01 10:00:00"))
+	later := newMergeFixture(t, "later.db", nil, nil)
+	saveCommentOrders(t, later, order(1, "2025-01-04 10:00:00"))
+
+	out, err := OpenSQLite(newer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	check := func(step string, rank int, first, last string) {
+		t.Helper()
+		var gotRank int
+		var gotFirst, gotLast string
+		if err := out.DB().QueryRow(`SELECT rank, first_seen_at, last_seen_at FROM gamersky_comment_orders
+			WHERE comment_id = 10 AND order_mode = 'latest'`).Scan(&gotRank, &gotFirst, &gotLast); err != nil {
+			t.Fatal(err)
+		}
+		if gotRank != rank || gotFirst != first || gotLast != last {
+			t.Errorf("%s: 名次 %d, 首次 %s, 最近 %s, 期望 %d、%s、%s", step, gotRank, gotFirst, gotLast, rank, first, last)
+		}
+	}
+
+	// 较旧的产物只提前首次出现时间，名次和最近出现时间保持不变
+	if _, err := MergeDatabase(out, older); err != nil {
+		t.Fatal(err)
+	}
+	check("合并较旧的产物", 2, "2025-01-01 10:00:00", "2025-01-03 10:00:00")
+
+	// 较晚的产物更新最近出现时间和名次
+	if _, err := MergeDatabase(out, later); err != nil {
+		t.Fatal(err)
+	}
+	check("合并较晚的产物", 1, "2025-01-01 10:00:00", "2025-01-04 10:00:00")
+
+	// 重复合并不修改数据
+	for _, in := range []string{older, later} {
+		results, err := MergeDatabase(out, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, result := range results {
+			if result.Inserted != 0 || result.Updated != 0 {
+				t.Errorf("重复合并 %s: 插入 %d 行, 更新 %d 行", result.Table, result.Inserted, result.Updated)
+			}
+		}
+	}
+	check("重复合并", 1, "2025-01-01 10:00:00", "2025-01-04 10:00:00")
+}
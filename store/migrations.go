@@ -0,0 +1,434 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"bili-comment/model"
+	"bili-comment/runlog"
+)
+
+// Migration 一次数据库结构迁移，按版本号顺序执行，执行后 PRAGMA user_version 更新为该版本号
+type Migration struct {
+	Version     int                    // 迁移后的版本号
+	Description string                 // 迁移说明
+	Up          func(tx *sql.Tx) error // 迁移操作，在事务中执行
+}
+
+// migrations 所有迁移，只能在末尾追加，不能修改已发布的迁移
+var migrations = []Migration{
+	{Version: 1, Description: "创建基础数据表并补齐旧数据库缺失的列", Up: migrateBaseline},
+	{Version: 2, Description: "bilibili_comments 改用英文列名，并创建旧列名兼容视图 bilibili_comments_legacy", Up: migrateEnglishColumns},
+	{Version: 3, Description: "创建Gamersky评论图片表 gamersky_comment_images", Up: migrateCommentImages},
+	{Version: 4, Description: "创建Gamersky文章正文表 gamersky_articles", Up: migrateArticles},
+	{Version: 5, Description: "创建Gamersky新闻评论数历史表 gamersky_news_history", Up: migrateNewsHistory},
+	{Version: 6, Description: "创建Gamersky评论爬取状态表 gamersky_comment_crawls", Up: migrateCommentCrawls},
+	{Version: 7, Description: "gamersky_news 增加频道列 channel", Up: migrateNewsChannel},
+	{Version: 8, Description: "gamersky_news 增加发布时间列 published_at，并由时间标签和置顶时间回填", Up: migrateNewsPublishedAt},
+	{Version: 9, Description: "创建Gamersky评论排序记录表 gamersky_comment_orders", Up: migrateCommentOrders},
+}
+
+// Migrations 获取所有迁移
+func Migrations() []Migration {
+	return append([]Migration(nil), migrations...)
+}
+
+// LatestVersion 获取最新的数据库版本号
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion 读取数据库当前版本号
+func SchemaVersion(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// PendingMigrations 获取尚未执行的迁移
+func PendingMigrations(db *sql.DB) ([]Migration, error) {
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if version > LatestVersion() {
+		return nil, fmt.Errorf("数据库版本 %d 高于程序支持的版本 %d，请升级程序", version, LatestVersion())
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate 按顺序执行所有未执行的迁移，返回本次执行的迁移
+func Migrate(db *sql.DB) ([]Migration, error) {
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, m := range pending {
+		if err := applyMigration(db, m); err != nil {
+			return pending[:i], fmt.Errorf("执行迁移 %d (%s) 失败: %v", m.Version, m.Description, err)
+		}
+	}
+
+	return pending, nil
+}
+
+// applyMigration 在事务中执行一次迁移并更新版本号
+func applyMigration(db *sql.DB, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.Up(tx); err != nil {
+		return err
+	}
+
+	// PRAGMA 不支持参数绑定
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", m.Version)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// columnExists 判断数据表中是否存在指定列
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	var count int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	return count > 0, err
+}
+
+// addColumnIfMissing 为旧数据库补充缺失的列
+func addColumnIfMissing(tx *sql.Tx, table, column, definition string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN "%s" %s`, table, column, definition))
+	return err
+}
+
+// migrateBaseline 创建基础数据表，旧数据库在此基础上补齐后来新增的列
+func migrateBaseline(tx *sql.Tx) error {
+	statements := []string{
+		// B站评论表
+		`CREATE TABLE IF NOT EXISTS bilibili_comments (
+			序号 INTEGER,
+			上级评论ID INTEGER,
+			评论ID INTEGER PRIMARY KEY,
+			用户ID INTEGER,
+			用户名 TEXT,
+			用户等级 INTEGER,
+			性别 TEXT,
+			评论内容 TEXT,
+			评论时间 TEXT,
+			回复数 INTEGER,
+			点赞数 INTEGER,
+			个性签名 TEXT,
+			IP属地 TEXT,
+			是否是大会员 TEXT,
+			头像 TEXT,
+			视频BV号 TEXT,
+			视频标题 TEXT,
+			run_id INTEGER DEFAULT 0
+		)`,
+		// B站视频搜索结果表
+		`CREATE TABLE IF NOT EXISTS bilibili_videos (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			keyword TEXT NOT NULL,
+			bvid TEXT NOT NULL,
+			title TEXT,
+			author TEXT,
+			play INTEGER,
+			video_review INTEGER,
+			favorites INTEGER,
+			pubdate INTEGER,
+			duration TEXT,
+			like_count INTEGER,
+			danmaku INTEGER,
+			description TEXT,
+			pic TEXT,
+			create_time TEXT,
+			UNIQUE(keyword, bvid)
+		)`,
+		// Gamersky新闻表
+		`CREATE TABLE IF NOT EXISTS gamersky_news (
+			sid TEXT PRIMARY KEY,
+			title TEXT NOT NULL,
+			time TEXT,
+			comment_num INTEGER DEFAULT 0,
+			url TEXT,
+			image_url TEXT,
+			topline_time TEXT,
+			create_time TEXT DEFAULT CURRENT_TIMESTAMP,
+			run_id INTEGER DEFAULT 0
+		)`,
+		// Gamersky评论表
+		`CREATE TABLE IF NOT EXISTS gamersky_comments (
+			id INTEGER PRIMARY KEY,
+			article_id TEXT NOT NULL,
+			user_id INTEGER,
+			username TEXT,
+			content TEXT,
+			comment_time TEXT,
+			support_count INTEGER DEFAULT 0,
+			reply_count INTEGER DEFAULT 0,
+			parent_id INTEGER DEFAULT 0,
+			answer_to_id INTEGER DEFAULT 0,
+			answer_to_name TEXT DEFAULT '',
+			user_avatar TEXT,
+			user_level INTEGER DEFAULT 0,
+			ip_location TEXT,
+			device_name TEXT,
+			floor_number INTEGER DEFAULT 0,
+			is_tuijian BOOLEAN DEFAULT FALSE,
+			is_author BOOLEAN DEFAULT FALSE,
+			is_best BOOLEAN DEFAULT FALSE,
+			user_authentication TEXT,
+			user_group_id INTEGER DEFAULT 0,
+			third_platform_bound TEXT,
+			create_time TEXT DEFAULT CURRENT_TIMESTAMP,
+			run_id INTEGER DEFAULT 0,
+			UNIQUE(id, article_id)
+		)`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	// 旧数据库缺少回复关系列
+	if err := addColumnIfMissing(tx, "gamersky_comments", "answer_to_id", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "gamersky_comments", "answer_to_name", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+
+	// 旧数据库补充 run_id 列
+	for _, table := range []string{"bilibili_comments", "gamersky_news", "gamersky_comments"} {
+		if err := runlog.EnsureRunIDColumn(tx, table); err != nil {
+			return err
+		}
+	}
+
+	// 运行记录表
+	return runlog.EnsureTable(tx)
+}
+
+// bilibiliCommentColumns bilibili_comments 的英文列名与旧中文列名
+var bilibiliCommentColumns = [][2]string{
+	{"serial_number", "序号"},
+	{"parent_id", "上级评论ID"},
+	{"comment_id", "评论ID"},
+	{"user_id", "用户ID"},
+	{"username", "用户名"},
+	{"user_level", "用户等级"},
+	{"gender", "性别"},
+	{"content", "评论内容"},
+	{"comment_time", "评论时间"},
+	{"reply_count", "回复数"},
+	{"like_count", "点赞数"},
+	{"signature", "个性签名"},
+	{"ip_location", "IP属地"},
+	{"is_vip", "是否是大会员"},
+	{"avatar", "头像"},
+	{"bv", "视频BV号"},
+	{"video_title", "视频标题"},
+}
+
+// migrateEnglishColumns 将 bilibili_comments 的中文列名改为英文，并创建使用旧列名的只读兼容视图
+func migrateEnglishColumns(tx *sql.Tx) error {
+	for _, column := range bilibiliCommentColumns {
+		exists, err := columnExists(tx, "bilibili_comments", column[1])
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE bilibili_comments RENAME COLUMN "%s" TO "%s"`, column[1], column[0])); err != nil {
+			return err
+		}
+	}
+
+	// 兼容视图：旧的查询语句把表名换成 bilibili_comments_legacy 即可继续使用
+	selects := make([]string, 0, len(bilibiliCommentColumns)+1)
+	for _, column := range bilibiliCommentColumns {
+		selects = append(selects, fmt.Sprintf(`%s AS "%s"`, column[0], column[1]))
+	}
+	selects = append(selects, "run_id")
+
+	_, err := tx.Exec(fmt.Sprintf("CREATE VIEW IF NOT EXISTS bilibili_comments_legacy AS SELECT %s FROM bilibili_comments",
+		strings.Join(selects, ", ")))
+	return err
+}
+
+// migrateCommentImages 创建Gamersky评论图片表，每条评论的图片按 image_order 排列
+func migrateCommentImages(tx *sql.Tx) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS gamersky_comment_images (
+			comment_id INTEGER NOT NULL,
+			article_id TEXT NOT NULL,
+			image_order INTEGER NOT NULL,
+			url TEXT NOT NULL,
+			width INTEGER DEFAULT 0,
+			height INTEGER DEFAULT 0,
+			sha256 TEXT DEFAULT '',
+			create_time TEXT DEFAULT CURRENT_TIMESTAMP,
+			run_id INTEGER DEFAULT 0,
+			PRIMARY KEY (comment_id, image_order)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_gamersky_comment_images_article ON gamersky_comment_images (article_id)`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateArticles 创建Gamersky文章正文表，标签、段落和图片以JSON数组保存
+func migrateArticles(tx *sql.Tx) error {
+	_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS gamersky_articles (
+		sid TEXT PRIMARY KEY,
+		url TEXT,
+		title TEXT,
+		author TEXT DEFAULT '',
+		editor TEXT DEFAULT '',
+		source TEXT DEFAULT '',
+		publish_time TEXT DEFAULT '',
+		tags TEXT DEFAULT '[]',
+		paragraphs TEXT DEFAULT '[]',
+		images TEXT DEFAULT '[]',
+		pages INTEGER DEFAULT 1,
+		create_time TEXT DEFAULT CURRENT_TIMESTAMP,
+		run_id INTEGER DEFAULT 0
+	)`)
+	return err
+}
+
+// migrateNewsHistory 创建新闻评论数历史表，以已有新闻的当前评论数作为起点
+func migrateNewsHistory(tx *sql.Tx) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS gamersky_news_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			sid TEXT NOT NULL,
+			comment_num INTEGER NOT NULL,
+			recorded_at TEXT DEFAULT CURRENT_TIMESTAMP,
+			run_id INTEGER DEFAULT 0
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_gamersky_news_history_sid ON gamersky_news_history (sid, id)`,
+		`INSERT INTO gamersky_news_history (sid, comment_num)
+			SELECT sid, comment_num FROM gamersky_news WHERE comment_num > 0`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateCommentCrawls 创建新闻评论爬取状态表，每条新闻一行，记录上次爬取评论的时间和当时的评论数
+func migrateCommentCrawls(tx *sql.Tx) error {
+	_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS gamersky_comment_crawls (
+		sid TEXT PRIMARY KEY,
+		comment_num INTEGER DEFAULT 0,
+		crawled_at TEXT NOT NULL,
+		run_id INTEGER DEFAULT 0
+	)`)
+	return err
+}
+
+// migrateNewsChannel 为新闻增加所属频道，已有新闻来自首页信息流，频道为空
+func migrateNewsChannel(tx *sql.Tx) error {
+	if err := addColumnIfMissing(tx, "gamersky_news", "channel", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_gamersky_news_channel ON gamersky_news (channel)`)
+	return err
+}
+
+// migrateNewsPublishedAt 为新闻增加北京时间的发布时间，已有新闻以记录创建时间为基准解析时间标签回填
+func migrateNewsPublishedAt(tx *sql.Tx) error {
+	if err := addColumnIfMissing(tx, "gamersky_news", "published_at", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_gamersky_news_published_at ON gamersky_news (published_at)`); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT sid, COALESCE(time, ''), COALESCE(topline_time, ''), COALESCE(create_time, '')
+		FROM gamersky_news WHERE published_at IS NULL OR published_at = ''`)
+	if err != nil {
+		return err
+	}
+	var news []model.NewsInfo
+	for rows.Next() {
+		var item model.NewsInfo
+		if err := rows.Scan(&item.SID, &item.Time, &item.TopLineTime, &item.CreateTime); err != nil {
+			rows.Close()
+			return err
+		}
+		news = append(news, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, item := range news {
+		item = FillPublishedAt(item)
+		if item.PublishedAt == "" {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE gamersky_news SET published_at = ? WHERE sid = ?`, item.PublishedAt, item.SID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateCommentOrders 创建评论排序记录表，记录一级评论在每种排序方式下首次和最近一次出现的时间及名次
+func migrateCommentOrders(tx *sql.Tx) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS gamersky_comment_orders (
+			comment_id INTEGER NOT NULL,
+			article_id TEXT NOT NULL,
+			order_mode TEXT NOT NULL,
+			rank INTEGER DEFAULT 0,
+			first_seen_at TEXT NOT NULL,
+			last_seen_at TEXT NOT NULL,
+			run_id INTEGER DEFAULT 0,
+			PRIMARY KEY (comment_id, order_mode)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_gamersky_comment_orders_article ON gamersky_comment_orders (article_id, order_mode, rank)`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// baselineSchema 加入迁移之前的程序创建的数据表：B站评论使用中文列名，没有 run_id 和回复关系列
+var baselineSchema = []string{
+	`CREATE TABLE bilibili_comments (
+		序号 INTEGER,
+		上级评论ID INTEGER,
+		评论ID INTEGER PRIMARY KEY,
+		用户ID INTEGER,
+		用户名 TEXT,
+		用户等级 INTEGER,
+		性别 TEXT,
+		评论内容 TEXT,
+		评论时间 TEXT,
+		回复数 INTEGER,
+		点赞数 INTEGER,
+		个性签名 TEXT,
+		IP属地 TEXT,
+		是否是大会员 TEXT,
+		头像 TEXT,
+		视频BV号 TEXT,
+		视频标题 TEXT
+	)`,
+	`CREATE TABLE gamersky_news (
+		sid TEXT PRIMARY KEY,
+		title TEXT NOT NULL,
+		time TEXT,
+		comment_num INTEGER DEFAULT 0,
+		url TEXT,
+		image_url TEXT,
+		topline_time TEXT,
+		create_time TEXT DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE gamersky_comments (
+		id INTEGER PRIMARY KEY,
+		article_id TEXT NOT NULL,
+		user_id INTEGER,
+		username TEXT,
+		content TEXT,
+		comment_time TEXT,
+		support_count INTEGER DEFAULT 0,
+		reply_count INTEGER DEFAULT 0,
+		parent_id INTEGER DEFAULT 0,
+		user_avatar TEXT,
+		user_level INTEGER DEFAULT 0,
+		ip_location TEXT,
+		device_name TEXT,
+		floor_number INTEGER DEFAULT 0,
+		is_tuijian BOOLEAN DEFAULT FALSE,
+		is_author BOOLEAN DEFAULT FALSE,
+		is_best BOOLEAN DEFAULT FALSE,
+		user_authentication TEXT,
+		user_group_id INTEGER DEFAULT 0,
+		third_platform_bound TEXT,
+		create_time TEXT DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(id, article_id)
+	)`,
+	`INSERT INTO bilibili_comments (序号, 评论ID, 用户名, 评论内容, 评论时间, 视频BV号)
+		VALUES (1, 1001, '用户', '旧评论', '2024-05-01 10:00:00', 'BV1xx')`,
+	`INSERT INTO gamersky_news (sid, title, time, comment_num, topline_time, create_time)
+		VALUES ('2013305', '旧新闻', '10:10', 3, '2025-09-13 10:10:52', '2025-09-13 11:00:00')`,
+	`INSERT INTO gamersky_comments (id, article_id, username, content, comment_time)
+		VALUES (1, '2013305', '玩家', '旧评论', '2025-09-13 10:20:00')`,
+}
+
+func TestMigrateBaselineDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, statement := range baselineSchema {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("创建旧数据库失败: %v", err)
+		}
+	}
+
+	// 打开存储时自动迁移到最新版本
+	st, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("迁移旧数据库失败: %v", err)
+	}
+	st.Close()
+
+	if version, err := SchemaVersion(db); err != nil || version != LatestVersion() {
+		t.Errorf("user_version = %d, %v, 期望 %d", version, err, LatestVersion())
+	}
+
+	// 中文列名已改为英文，数据保留
+	for _, column := range bilibiliCommentColumns {
+		var english, chinese int
+		db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('bilibili_comments') WHERE name = ?`, column[0]).Scan(&english)
+		db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('bilibili_comments') WHERE name = ?`, column[1]).Scan(&chinese)
+		if english != 1 || chinese != 0 {
+			t.Errorf("列 %s/%s: 英文列 %d 个, 中文列 %d 个", column[0], column[1], english, chinese)
+		}
+	}
+	var content, bv string
+	var runID int64
+	err = db.QueryRow(`SELECT content, bv, run_id FROM bilibili_comments WHERE comment_id = 1001`).Scan(&content, &bv, &runID)
+	if err != nil || content != "旧评论" || bv != "BV1xx" || runID != 0 {
+		t.Errorf("迁移后的评论 = %q %q %d, %v", content, bv, runID, err)
+	}
+
+	// 兼容视图使用旧的中文列名
+	err = db.QueryRow(`SELECT 评论内容, 视频BV号 FROM bilibili_comments_legacy WHERE 评论ID = 1001`).Scan(&content, &bv)
+	if err != nil || content != "旧评论" || bv != "BV1xx" {
+		t.Errorf("兼容视图中的评论 = %q %q, %v", content, bv, err)
+	}
+
+	// 旧的Gamersky表补齐了后来新增的列
+	var answerToID int64
+	var answerToName string
+	err = db.QueryRow(`SELECT answer_to_id, answer_to_name, run_id FROM gamersky_comments WHERE id = 1`).Scan(&answerToID, &answerToName, &runID)
+	if err != nil || answerToID != 0 || answerToName != "" || runID != 0 {
+		t.Errorf("迁移后的Gamersky评论 = %d %q %d, %v", answerToID, answerToName, runID, err)
+	}
+	var channel, publishedAt string
+	err = db.QueryRow(`SELECT channel, published_at FROM gamersky_news WHERE sid = '2013305'`).Scan(&channel, &publishedAt)
+	if err != nil || channel != "" || publishedAt != "2025-09-13 10:10:52" {
+		t.Errorf("迁移后的新闻 = %q %q, %v", channel, publishedAt, err)
+	}
+
+	// 之后的迁移创建的数据表
+	for _, table := range []string{"crawl_runs", "gamersky_comment_images", "gamersky_articles", "gamersky_news_history", "gamersky_comment_crawls", "gamersky_comment_orders"} {
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count); err != nil || count != 1 {
+			t.Errorf("数据表 %s 不存在: %v", table, err)
+		}
+	}
+
+	// 再次打开时没有待执行的迁移
+	if pending, err := PendingMigrations(db); err != nil || len(pending) != 0 {
+		t.Errorf("待执行的迁移 = %v, %v", pending, err)
+	}
+}
@@ -0,0 +1,58 @@
+package store
+
+import "time"
+
+// NewsTrend 新闻在一段时间内的评论数增长
+type NewsTrend struct {
+	SID        string // 新闻ID
+	Title      string // 新闻标题
+	URL        string // 新闻链接
+	CommentNum int    // 最新评论数
+	Baseline   int    // 时间段开始时的评论数，新闻在时间段内首次记录时为首次记录的评论数
+	Growth     int    // 评论数增长
+	Samples    int    // 时间段内的历史记录数
+}
+
+// NewsTrends 按评论数增长倒序列出 since 之后评论数有变化的新闻，limit<=0 时列出全部
+func (s *SQLiteStore) NewsTrends(since time.Time, limit int) ([]NewsTrend, error) {
+	if err := s.Flush(); err != nil {
+		return nil, err
+	}
+
+	// recorded_at 为SQLite的 CURRENT_TIMESTAMP，即UTC时间；合并后的记录ID不按时间递增，按 recorded_at 排序
+	from := since.UTC().Format("2006-01-02 15:04:05")
+	query := `
+	SELECT n.sid, n.title, n.url, n.comment_num, w.samples,
+		COALESCE(
+			(SELECT h.comment_num FROM gamersky_news_history h
+				WHERE h.sid = n.sid AND h.recorded_at < ? ORDER BY h.recorded_at DESC, h.id DESC LIMIT 1),
+			(SELECT h.comment_num FROM gamersky_news_history h
+				WHERE h.sid = n.sid AND h.recorded_at >= ? ORDER BY h.recorded_at, h.id LIMIT 1)
+		) AS baseline
+	FROM gamersky_news n
+	JOIN (SELECT sid, COUNT(*) AS samples FROM gamersky_news_history WHERE recorded_at >= ? GROUP BY sid) w
+		ON w.sid = n.sid
+	ORDER BY n.comment_num - baseline DESC, n.comment_num DESC`
+	args := []interface{}{from, from, from}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trends []NewsTrend
+	for rows.Next() {
+		var trend NewsTrend
+		if err := rows.Scan(&trend.SID, &trend.Title, &trend.URL, &trend.CommentNum, &trend.Samples, &trend.Baseline); err != nil {
+			return nil, err
+		}
+		trend.Growth = trend.CommentNum - trend.Baseline
+		trends = append(trends, trend)
+	}
+	return trends, rows.Err()
+}
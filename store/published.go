@@ -0,0 +1,145 @@
+package store
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bili-comment/model"
+)
+
+// publishedLayout 发布时间以北京时间 (Asia/Shanghai) 字符串保存，与评论时间格式相同
+const publishedLayout = "2006-01-02 15:04:05"
+
+// 新闻列表中的发布时间标签
+var (
+	agoLabelRegex      = regexp.MustCompile(`^(\d+)\s*(秒|分钟|小时|天)前$`)
+	dayLabelRegex      = regexp.MustCompile(`^(今天|昨天|前天)\s*(\d{1,2}:\d{2})?$`)
+	monthDayLabelRegex = regexp.MustCompile(`^(\d{1,2})[-/月](\d{1,2})日?\s*(\d{1,2}:\d{2})?$`)
+	clockLabelRegex    = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+)
+
+// absoluteLayouts 带年份的绝对时间格式
+var absoluteLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	"2006年01月02日 15:04",
+	"2006年01月02日",
+}
+
+// FillPublishedAt 新闻未设置发布时间时，根据置顶时间或时间标签计算发布时间，
+// 相对时间以记录创建时间为基准；无法解析时保持为空
+func FillPublishedAt(news model.NewsInfo) model.NewsInfo {
+	if news.PublishedAt != "" {
+		return news
+	}
+
+	ref, err := time.ParseInLocation(publishedLayout, news.CreateTime, time.Local)
+	if err != nil {
+		ref = time.Now()
+	}
+	if published, ok := ParsePublishTime(news.Time, news.TopLineTime, ref); ok {
+		news.PublishedAt = published.Format(publishedLayout)
+	}
+	return news
+}
+
+// ParsePublishTime 将新闻的时间标签转换为北京时间
+// 优先使用精确的置顶时间，其次解析 "刚刚"、"3小时前"、"今天 10:32"、"01-05 08:00" 等相对时间标签，
+// 相对时间以 ref 为基准
+func ParsePublishTime(label, topLineTime string, ref time.Time) (time.Time, bool) {
+	ref = ref.In(cst)
+	if t, ok := parseAbsoluteTime(topLineTime); ok {
+		return t, true
+	}
+	return parseTimeLabel(label, ref)
+}
+
+// parseAbsoluteTime 解析带年份的北京时间字符串
+func parseAbsoluteTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	// 置顶时间可能带有小数秒或T分隔符
+	value = strings.Replace(value, "T", " ", 1)
+	if i := strings.IndexByte(value, '.'); i > 0 {
+		value = value[:i]
+	}
+	for _, layout := range absoluteLayouts {
+		if t, err := time.ParseInLocation(layout, value, cst); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseTimeLabel 解析相对时间标签，ref 为北京时间
+func parseTimeLabel(label string, ref time.Time) (time.Time, bool) {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return time.Time{}, false
+	}
+	if label == "刚刚" {
+		return ref.Truncate(time.Second), true
+	}
+	if t, ok := parseAbsoluteTime(label); ok {
+		return t, true
+	}
+
+	if m := agoLabelRegex.FindStringSubmatch(label); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		unit := map[string]time.Duration{
+			"秒":  time.Second,
+			"分钟": time.Minute,
+			"小时": time.Hour,
+			"天":  24 * time.Hour,
+		}[m[2]]
+		return ref.Add(-time.Duration(n) * unit).Truncate(time.Second), true
+	}
+
+	if m := dayLabelRegex.FindStringSubmatch(label); m != nil {
+		days := map[string]int{"今天": 0, "昨天": 1, "前天": 2}[m[1]]
+		day := ref.AddDate(0, 0, -days)
+		return atClock(day.Year(), day.Month(), day.Day(), m[2])
+	}
+
+	if m := monthDayLabelRegex.FindStringSubmatch(label); m != nil {
+		month, _ := strconv.Atoi(m[1])
+		dayOfMonth, _ := strconv.Atoi(m[2])
+		t, ok := atClock(ref.Year(), time.Month(month), dayOfMonth, m[3])
+		// 不带年份的日期晚于基准时间时属于上一年（如1月初看到的 "12-31"）
+		if ok && t.After(ref.Add(24*time.Hour)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t, ok
+	}
+
+	if clockLabelRegex.MatchString(label) {
+		return atClock(ref.Year(), ref.Month(), ref.Day(), label)
+	}
+
+	return time.Time{}, false
+}
+
+// atClock 组合日期和 "HH:MM" 时刻，时刻为空时取当天零点
+func atClock(year int, month time.Month, day int, clock string) (time.Time, bool) {
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	hour, minute := 0, 0
+	if clock != "" {
+		parts := strings.SplitN(clock, ":", 2)
+		hour, _ = strconv.Atoi(parts[0])
+		minute, _ = strconv.Atoi(parts[1])
+		if hour > 23 || minute > 59 {
+			return time.Time{}, false
+		}
+	}
+	return time.Date(year, month, day, hour, minute, 0, 0, cst), true
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"bili-comment/model"
+)
+
+func TestParsePublishTime(t *testing.T) {
+	ref := time.Date(2025, 1, 3, 9, 30, 15, 0, cst)
+
+	cases := []struct {
+		label, topLine string
+		want           string
+	}{
+		{"今天 10:32", "2025-01-03 08:12:40", "2025-01-03 08:12:40"},
+		{"刚刚", "", "2025-01-03 09:30:15"},
+		{"5分钟前", "", "2025-01-03 09:25:15"},
+		{"3小时前", "", "2025-01-03 06:30:15"},
+		{"2天前", "", "2025-01-01 09:30:15"},
+		{"今天 08:05", "", "2025-01-03 08:05:00"},
+		{"昨天 23:10", "", "2025-01-02 23:10:00"},
+		{"前天", "", "2025-01-01 00:00:00"},
+		{"12-31 18:00", "", "2024-12-31 18:00:00"},
+		{"01月02日 07:45", "", "2025-01-02 07:45:00"},
+		{"2024-11-20 14:03", "", "2024-11-20 14:03:00"},
+		{"07:20", "", "2025-01-03 07:20:00"},
+		{"置顶", "2025-01-02T21:00:00.000", "2025-01-02 21:00:00"},
+	}
+
+	for _, c := range cases {
+		got, ok := ParsePublishTime(c.label, c.topLine, ref)
+		if !ok {
+			t.Errorf("ParsePublishTime(%q, %q) 解析失败", c.label, c.topLine)
+			continue
+		}
+		if s := got.Format(publishedLayout); s != c.want {
+			t.Errorf("ParsePublishTime(%q, %q) = %s, 期望 %s", c.label, c.topLine, s, c.want)
+		}
+	}
+
+	for _, label := range []string{"", "置顶", "25:00", "13-40 10:00"} {
+		if got, ok := ParsePublishTime(label, "", ref); ok {
+			t.Errorf("ParsePublishTime(%q) = %v, 期望无法解析", label, got)
+		}
+	}
+}
+
+func TestQueryNewsOrdersByPublishedAt(t *testing.T) {
+	st := NewMemory()
+	news := []model.NewsInfo{
+		// 先发布后记录的新闻排在后面
+		{SID: "old", Time: "3小时前", CreateTime: "2025-01-03 10:00:00"},
+		{SID: "new", Time: "刚刚", CreateTime: "2025-01-03 09:00:00"},
+	}
+	for _, item := range news {
+		if _, err := st.SaveNews(item, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := st.QueryNews(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, item := range got {
+		order = append(order, item.SID)
+	}
+	if want := []string{"new", "old"}; len(order) != 2 || order[0] != want[0] || order[1] != want[1] {
+		t.Errorf("新闻顺序 = %v, 期望 %v", order, want)
+	}
+
+	// 重新爬取时相对时间标签变化，已有的发布时间不变
+	first := got[0].PublishedAt
+	if _, err := st.SaveNews(model.NewsInfo{SID: "new", Time: "1小时前", CreateTime: "2025-01-03 10:00:00"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = st.QueryNews(0, 1)
+	if got[0].PublishedAt != first {
+		t.Errorf("重新爬取后发布时间 = %s, 期望保持 %s", got[0].PublishedAt, first)
+	}
+}
@@ -0,0 +1,478 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 全文检索的数据来源
+const (
+	SourceBilibili = "bilibili" // B站评论
+	SourceGamersky = "gamersky" // Gamersky评论
+)
+
+// searchIndex 一张评论表上的FTS5全文索引，通过触发器与评论表保持同步
+type searchIndex struct {
+	table     string // 评论表
+	rowid     string // 评论表的整数主键，作为索引的 rowid
+	source    string // 数据来源
+	selectSQL string // 查询结果的 SELECT ... FROM 部分，评论表别名为 c
+}
+
+// searchIndexes 参与全文检索的评论表
+var searchIndexes = []searchIndex{
+	{
+		table:  "bilibili_comments",
+		rowid:  "comment_id",
+		source: SourceBilibili,
+		selectSQL: `SELECT c.comment_id, COALESCE(c.bv, ''), COALESCE(c.video_title, ''), COALESCE(c.username, ''),
+			COALESCE(c.content, ''), COALESCE(c.like_count, 0), COALESCE(c.comment_time, '')
+			FROM bilibili_comments c`,
+	},
+	{
+		table:  "gamersky_comments",
+		rowid:  "id",
+		source: SourceGamersky,
+		selectSQL: `SELECT c.id, c.article_id, COALESCE(n.title, ''), COALESCE(c.username, ''),
+			COALESCE(c.content, ''), COALESCE(c.support_count, 0), COALESCE(c.comment_time, '')
+			FROM gamersky_comments c LEFT JOIN gamersky_news n ON n.sid = c.article_id`,
+	},
+}
+
+// ftsTable 全文索引虚拟表名
+func (idx searchIndex) ftsTable() string {
+	return idx.table + "_fts"
+}
+
+// triggers 同步索引的触发器名及定义
+func (idx searchIndex) triggers() [][2]string {
+	fts := idx.ftsTable()
+	return [][2]string{
+		{fts + "_ai", fmt.Sprintf(`CREATE TRIGGER %s_ai AFTER INSERT ON %s BEGIN
+			INSERT INTO %s(rowid, content) VALUES (new.%s, new.content);
+		END`, fts, idx.table, fts, idx.rowid)},
+		{fts + "_ad", fmt.Sprintf(`CREATE TRIGGER %s_ad AFTER DELETE ON %s BEGIN
+			INSERT INTO %s(%s, rowid, content) VALUES ('delete', old.%s, old.content);
+		END`, fts, idx.table, fts, fts, idx.rowid)},
+		{fts + "_au", fmt.Sprintf(`CREATE TRIGGER %s_au AFTER UPDATE OF content ON %s BEGIN
+			INSERT INTO %s(%s, rowid, content) VALUES ('delete', old.%s, old.content);
+			INSERT INTO %s(rowid, content) VALUES (new.%s, new.content);
+		END`, fts, idx.table, fts, fts, idx.rowid, fts, idx.rowid)},
+	}
+}
+
+// FTSAvailable 判断当前程序链接的SQLite是否支持FTS5
+// go-sqlite3 需使用 -tags sqlite_fts5 构建才会启用FTS5
+func FTSAvailable(db *sql.DB) bool {
+	var used bool
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&used); err != nil {
+		return false
+	}
+	return used
+}
+
+// triggerExists 判断触发器是否存在
+func triggerExists(db *sql.DB, name string) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = ?`, name).Scan(&count)
+	return count > 0, err
+}
+
+// EnsureSearchIndex 创建评论全文索引及同步触发器，索引缺失或触发器不完整时重建索引
+// 不支持FTS5时删除同步触发器，避免写入评论失败；之后由支持FTS5的程序打开数据库时重建索引
+func EnsureSearchIndex(db *sql.DB) (bool, error) {
+	available := FTSAvailable(db)
+
+	for _, idx := range searchIndexes {
+		complete := true
+		for _, trigger := range idx.triggers() {
+			exists, err := triggerExists(db, trigger[0])
+			if err != nil {
+				return false, err
+			}
+			complete = complete && exists
+		}
+
+		if !available {
+			for _, trigger := range idx.triggers() {
+				if _, err := db.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS %s", trigger[0])); err != nil {
+					return false, err
+				}
+			}
+			continue
+		}
+
+		if complete {
+			continue
+		}
+		if err := rebuildSearchIndex(db, idx); err != nil {
+			return false, fmt.Errorf("重建全文索引 %s 失败: %v", idx.ftsTable(), err)
+		}
+	}
+
+	return available, nil
+}
+
+// rebuildSearchIndex 在事务中创建全文索引、从评论表重建索引内容并创建同步触发器
+func rebuildSearchIndex(db *sql.DB, idx searchIndex) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 外部内容表：索引只保存分词结果，评论内容仍从评论表读取
+	// trigram 分词按三个字符切分，适合没有空格分隔的中文
+	statements := []string{
+		fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(content, content='%s', content_rowid='%s', tokenize='trigram')`,
+			idx.ftsTable(), idx.table, idx.rowid),
+		fmt.Sprintf(`INSERT INTO %s(%s) VALUES ('rebuild')`, idx.ftsTable(), idx.ftsTable()),
+	}
+	for _, trigger := range idx.triggers() {
+		statements = append(statements, fmt.Sprintf("DROP TRIGGER IF EXISTS %s", trigger[0]), trigger[1])
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("已重建全文索引 %s", idx.ftsTable())
+	return nil
+}
+
+// SearchHit 一条全文检索结果
+type SearchHit struct {
+	Source   string // 数据来源：bilibili / gamersky
+	ID       int64  // 评论ID
+	TargetID string // 视频BV号或文章ID
+	Title    string // 视频标题或文章标题
+	Username string // 用户名
+	Content  string // 评论内容
+	Likes    int64  // 点赞数
+	Time     string // 评论时间
+}
+
+// SearchQuery 解析后的检索表达式
+// 语法：空格分隔的词默认为 AND，支持 OR、NOT、括号和 "短语"
+// trigram 索引按子串匹配，短语和普通词都匹配评论中任意位置出现的该字符串；
+// 兼容前缀写法 词*，末尾的 * 在解析时去掉，与不带 * 的词相同
+type SearchQuery struct {
+	op       string // AND / OR / NOT，叶子节点为空
+	children []*SearchQuery
+	term     string // 叶子节点的检索词
+}
+
+// ParseSearchQuery 解析检索表达式
+func ParseSearchQuery(query string) (*SearchQuery, error) {
+	tokens, err := tokenizeSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("检索词不能为空")
+	}
+
+	p := &searchParser{tokens: tokens}
+	q, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("检索表达式在 %q 处有多余内容", p.tokens[p.pos])
+	}
+	return q, nil
+}
+
+// Terms 获取表达式中所有非排除的检索词，用于高亮
+func (q *SearchQuery) Terms() []string {
+	var terms []string
+	var walk func(node *SearchQuery)
+	walk = func(node *SearchQuery) {
+		if node.op == "" {
+			terms = append(terms, node.term)
+			return
+		}
+		for i, child := range node.children {
+			// NOT 右侧是排除的词
+			if node.op == "NOT" && i > 0 {
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(q)
+	return terms
+}
+
+// searchToken 检索表达式的词法单元
+type searchToken struct {
+	kind string // word / phrase / ( / ) / AND / OR / NOT
+	text string
+}
+
+// String 用于错误信息
+func (t searchToken) String() string {
+	if t.text != "" {
+		return t.text
+	}
+	return t.kind
+}
+
+// tokenizeSearchQuery 将检索表达式切分为词法单元
+func tokenizeSearchQuery(query string) ([]searchToken, error) {
+	var tokens []searchToken
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, searchToken{kind: string(r)})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("检索表达式中的引号未闭合")
+			}
+			text := string(runes[i+1 : end])
+			i = end + 1
+			if i < len(runes) && runes[i] == '*' {
+				text += "*"
+				i++
+			}
+			tokens = append(tokens, searchToken{kind: "phrase", text: text})
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '(' && runes[end] != ')' && runes[end] != '"' {
+				end++
+			}
+			text := string(runes[i:end])
+			i = end
+
+			switch text {
+			case "AND", "OR", "NOT":
+				tokens = append(tokens, searchToken{kind: text})
+			default:
+				tokens = append(tokens, searchToken{kind: "word", text: text})
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+// searchParser 检索表达式语法分析，优先级从低到高为 OR、AND（含隐式 AND）、NOT
+type searchParser struct {
+	tokens []searchToken
+	pos    int
+}
+
+func (p *searchParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos].kind
+}
+
+func (p *searchParser) parseOr() (*SearchQuery, error) {
+	return p.parseBinary("OR", p.parseAnd)
+}
+
+func (p *searchParser) parseAnd() (*SearchQuery, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	node := left
+	for {
+		kind := p.peek()
+		if kind == "AND" {
+			p.pos++
+		} else if kind != "word" && kind != "phrase" && kind != "(" {
+			return node, nil
+		}
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		node = &SearchQuery{op: "AND", children: []*SearchQuery{node, right}}
+	}
+}
+
+func (p *searchParser) parseNot() (*SearchQuery, error) {
+	return p.parseBinary("NOT", p.parseUnary)
+}
+
+// parseBinary 解析左结合的二元运算
+func (p *searchParser) parseBinary(op string, next func() (*SearchQuery, error)) (*SearchQuery, error) {
+	node, err := next()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == op {
+		p.pos++
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		node = &SearchQuery{op: op, children: []*SearchQuery{node, right}}
+	}
+	return node, nil
+}
+
+func (p *searchParser) parseUnary() (*SearchQuery, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("检索表达式不完整")
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+
+	switch token.kind {
+	case "(":
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("检索表达式中的括号未闭合")
+		}
+		p.pos++
+		return node, nil
+	case "word", "phrase":
+		term := strings.TrimSuffix(token.text, "*")
+		if term == "" {
+			return nil, fmt.Errorf("检索词不能为空")
+		}
+		return &SearchQuery{term: term}, nil
+	default:
+		return nil, fmt.Errorf("检索表达式在 %q 处缺少检索词", token)
+	}
+}
+
+// minTrigramLength trigram 索引只能匹配至少三个字符的检索词
+const minTrigramLength = 3
+
+// toSQL 将检索表达式转换为 WHERE 条件
+// 三个字符及以上的词使用全文索引，较短的词或未启用FTS5时回退为 LIKE 扫描
+func (q *SearchQuery) toSQL(idx searchIndex, useFTS bool) (string, []interface{}) {
+	if q.op == "" {
+		if useFTS && utf8.RuneCountInString(q.term) >= minTrigramLength {
+			// 整个词作为短语匹配，双引号需转义
+			match := `"` + strings.ReplaceAll(q.term, `"`, `""`) + `"`
+			return fmt.Sprintf("c.%s IN (SELECT rowid FROM %s WHERE %s MATCH ?)", idx.rowid, idx.ftsTable(), idx.ftsTable()),
+				[]interface{}{match}
+		}
+
+		escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+		return `c.content LIKE ? ESCAPE '\'`, []interface{}{"%" + escaper.Replace(q.term) + "%"}
+	}
+
+	left, leftArgs := q.children[0].toSQL(idx, useFTS)
+	right, rightArgs := q.children[1].toSQL(idx, useFTS)
+	args := append(leftArgs, rightArgs...)
+
+	if q.op == "NOT" {
+		return fmt.Sprintf("(%s AND NOT %s)", left, right), args
+	}
+	return fmt.Sprintf("(%s %s %s)", left, q.op, right), args
+}
+
+// SearchIndexReady 判断数据库中的评论全文索引是否可以使用
+// 需要当前程序支持FTS5，且索引和同步触发器已由 EnsureSearchIndex 创建，否则检索回退为 LIKE 扫描
+func SearchIndexReady(db *sql.DB) bool {
+	if !FTSAvailable(db) {
+		return false
+	}
+
+	for _, idx := range searchIndexes {
+		for _, trigger := range idx.triggers() {
+			if exists, err := triggerExists(db, trigger[0]); err != nil || !exists {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SearchComments 全文检索评论，sources 为空时检索所有来源，结果按评论时间倒序
+// 只读取数据库，不执行迁移也不创建索引；索引不可用时使用 LIKE 扫描
+func SearchComments(db *sql.DB, query *SearchQuery, sources []string, limit int) ([]SearchHit, error) {
+	useFTS := SearchIndexReady(db)
+	var hits []SearchHit
+
+	for _, idx := range searchIndexes {
+		if len(sources) > 0 && !containsString(sources, idx.source) {
+			continue
+		}
+
+		where, args := query.toSQL(idx, useFTS)
+		sqlQuery := idx.selectSQL + " WHERE " + where + " ORDER BY c.comment_time DESC"
+		if limit > 0 {
+			sqlQuery += " LIMIT ?"
+			args = append(args, limit)
+		}
+
+		rows, err := db.Query(sqlQuery, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			hit := SearchHit{Source: idx.source}
+			err := rows.Scan(&hit.ID, &hit.TargetID, &hit.Title, &hit.Username, &hit.Content, &hit.Likes, &hit.Time)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			hits = append(hits, hit)
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rows.Close()
+	}
+
+	SortSearchHits(hits)
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// SortSearchHits 按评论时间倒序排列检索结果
+func SortSearchHits(hits []SearchHit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Time > hits[j].Time
+	})
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
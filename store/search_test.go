@@ -0,0 +1,75 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	idx := searchIndexes[1]
+	fts := func(term string) string {
+		return fmt.Sprintf("c.id IN (SELECT rowid FROM gamersky_comments_fts WHERE gamersky_comments_fts MATCH ?[%q])", `"`+term+`"`)
+	}
+	like := func(pattern string) string {
+		return fmt.Sprintf(`c.content LIKE ?[%q] ESCAPE '\'`, pattern)
+	}
+
+	cases := []struct {
+		query string
+		want  string   // 启用FTS5时生成的条件，参数以 [参数] 形式附在对应的 ? 之后
+		terms []string // 用于高亮的词
+	}{
+		{`显卡驱动`, fts("显卡驱动"), []string{"显卡驱动"}},
+		{`续航 价格`, "(" + like("%续航%") + " AND " + like("%价格%") + ")", []string{"续航", "价格"}},
+		{`续航 AND 价格`, "(" + like("%续航%") + " AND " + like("%价格%") + ")", []string{"续航", "价格"}},
+		{`充电宝 OR 续航`, "(" + fts("充电宝") + " OR " + like("%续航%") + ")", []string{"充电宝", "续航"}},
+		{`优化好 NOT 掉帧`, "(" + fts("优化好") + " AND NOT " + like("%掉帧%") + ")", []string{"优化好"}},
+		{`"显卡 驱动"`, fts("显卡 驱动"), []string{"显卡 驱动"}},
+		{`掉帧*`, like("%掉帧%"), []string{"掉帧"}},
+		{`(帧数 OR 优化) 掉帧`, "((" + like("%帧数%") + " OR " + like("%优化%") + ") AND " + like("%掉帧%") + ")", []string{"帧数", "优化", "掉帧"}},
+		// NOT 优先级高于隐式 AND，OR 优先级最低
+		{`a OR b c NOT d`, "(" + like("%a%") + " OR (" + like("%b%") + " AND (" + like("%c%") + " AND NOT " + like("%d%") + ")))", []string{"a", "b", "c"}},
+		// LIKE 通配符需转义
+		{`5%`, like(`%5\%%`), []string{"5%"}},
+	}
+
+	for _, c := range cases {
+		q, err := ParseSearchQuery(c.query)
+		if err != nil {
+			t.Errorf("ParseSearchQuery(%q) 失败: %v", c.query, err)
+			continue
+		}
+		if got := renderSearchSQL(q, idx, true); got != c.want {
+			t.Errorf("ParseSearchQuery(%q)\n条件 = %s\n期望 = %s", c.query, got, c.want)
+		}
+		if got := fmt.Sprint(q.Terms()); got != fmt.Sprint(c.terms) {
+			t.Errorf("ParseSearchQuery(%q) 高亮词 = %s, 期望 %v", c.query, got, c.terms)
+		}
+	}
+
+	// 未启用FTS5时所有词都使用 LIKE 扫描
+	q, _ := ParseSearchQuery(`显卡驱动`)
+	if got, want := renderSearchSQL(q, idx, false), like("%显卡驱动%"); got != want {
+		t.Errorf("未启用FTS5: 条件 = %s, 期望 %s", got, want)
+	}
+
+	for _, query := range []string{``, `   `, `"未闭合`, `(续航`, `续航)`, `续航 OR`, `NOT 续航`, `*`, `""`} {
+		if q, err := ParseSearchQuery(query); err == nil {
+			t.Errorf("ParseSearchQuery(%q) = %s, 期望返回错误", query, renderSearchSQL(q, idx, true))
+		}
+	}
+}
+
+// renderSearchSQL 将条件中的每个 ? 替换为 ?[参数]，便于比较条件和参数
+func renderSearchSQL(q *SearchQuery, idx searchIndex, useFTS bool) string {
+	where, args := q.toSQL(idx, useFTS)
+	var out []byte
+	for i := 0; i < len(where); i++ {
+		out = append(out, where[i])
+		if where[i] == '?' && len(args) > 0 {
+			out = append(out, fmt.Sprintf("[%q]", args[0])...)
+			args = args[1:]
+		}
+	}
+	return string(out)
+}
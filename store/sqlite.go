@@ -0,0 +1,501 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bili-comment/model"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteStore SQLite存储，写入由批量写入协程在事务中批量提交
+type SQLiteStore struct {
+	db     *sql.DB
+	writer *batchWriter
+}
+
+// SQLiteOptions SQLite存储的写入参数
+type SQLiteOptions struct {
+	BatchSize     int           // 每批最多写入的行数，<=1 时每行单独提交
+	FlushInterval time.Duration // 批次开始后最长多久提交
+}
+
+// OpenSQLite 使用默认写入参数打开SQLite数据库，并自动执行未执行的结构迁移
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	return OpenSQLiteWithOptions(path, SQLiteOptions{
+		BatchSize:     DefaultBatchSize,
+		FlushInterval: DefaultFlushInterval,
+	})
+}
+
+// OpenSQLiteWithOptions 使用指定写入参数打开SQLite数据库，并自动执行未执行的结构迁移
+// 数据库使用WAL日志模式和 synchronous=NORMAL，读取不会被批量写入的事务阻塞
+func OpenSQLiteWithOptions(path string, opts SQLiteOptions) (*SQLiteStore, error) {
+	// 创建目录
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	// 连接数据库
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_journal_mode=WAL&_synchronous=NORMAL")
+	if err != nil {
+		return nil, err
+	}
+
+	// 执行结构迁移
+	applied, err := Migrate(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if len(applied) > 0 {
+		log.Printf("数据库 %s 已迁移到版本 %d", path, applied[len(applied)-1].Version)
+	}
+
+	// 维护评论全文索引
+	if _, err := EnsureSearchIndex(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if opts.BatchSize < 1 {
+		opts.BatchSize = 1
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	writer, err := newBatchWriter(db, opts.BatchSize, opts.FlushInterval)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db, writer: writer}, nil
+}
+
+// Flush 立即提交批量写入协程中尚未提交的数据
+// 本批或之前定时提交的批次提交失败时返回错误，这些批次中报告为新插入的数据都没有保存
+func (s *SQLiteStore) Flush() error {
+	return s.writer.flush()
+}
+
+// DB 获取底层数据库连接，读取前会先提交尚未提交的写入
+func (s *SQLiteStore) DB() *sql.DB {
+	if err := s.Flush(); err != nil {
+		log.Printf("提交写入失败: %v", err)
+	}
+	return s.db
+}
+
+// inserted 根据 INSERT OR IGNORE 的执行结果判断是否插入了新行
+func inserted(result sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// SaveComment 保存B站评论
+func (s *SQLiteStore) SaveComment(comment model.CommentInfo, runID int64) (bool, error) {
+	sql := `
+	INSERT OR IGNORE INTO bilibili_comments
+	(serial_number, parent_id, comment_id, user_id, username, user_level, gender, content, comment_time, reply_count, like_count, signature, ip_location, is_vip, avatar, bv, video_title, run_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	return s.writer.write(sql,
+		comment.SerialNumber, comment.ParentID, comment.CommentID, comment.UserID,
+		comment.Username, comment.UserLevel, comment.Gender, comment.Content,
+		comment.CommentTime, comment.ReplyCount, comment.LikeCount, comment.Signature,
+		comment.IPLocation, comment.IsVIP, comment.Avatar, comment.BV, comment.VideoTitle,
+		runID)
+}
+
+// SaveVideo 保存视频搜索结果
+func (s *SQLiteStore) SaveVideo(video model.VideoInfo, runID int64) (bool, error) {
+	sql := `
+	INSERT OR IGNORE INTO bilibili_videos
+	(keyword, bvid, title, author, play, video_review, favorites, pubdate, duration, like_count, danmaku, description, pic, create_time)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	return s.writer.write(sql,
+		video.Keyword, video.BVID, video.Title, video.Author,
+		video.Play, video.VideoReview, video.Favorites, video.PubDate,
+		video.Duration, video.Like, video.Danmaku, video.Description,
+		video.Pic, video.CreateTime)
+}
+
+// QueryVideos 查询视频信息
+func (s *SQLiteStore) QueryVideos(keyword string, limit int) ([]model.VideoInfo, error) {
+	if err := s.Flush(); err != nil {
+		return nil, err
+	}
+
+	var sql string
+	var args []interface{}
+
+	if keyword != "" {
+		sql = `SELECT keyword, bvid, title, author, play, video_review, favorites, pubdate, duration, like_count, danmaku, description, pic, create_time
+			   FROM bilibili_videos WHERE keyword = ? ORDER BY play DESC LIMIT ?`
+		args = []interface{}{keyword, limit}
+	} else {
+		sql = `SELECT keyword, bvid, title, author, play, video_review, favorites, pubdate, duration, like_count, danmaku, description, pic, create_time
+			   FROM bilibili_videos ORDER BY play DESC LIMIT ?`
+		args = []interface{}{limit}
+	}
+
+	rows, err := s.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []model.VideoInfo
+	for rows.Next() {
+		var video model.VideoInfo
+		err := rows.Scan(
+			&video.Keyword, &video.BVID, &video.Title, &video.Author,
+			&video.Play, &video.VideoReview, &video.Favorites, &video.PubDate,
+			&video.Duration, &video.Like, &video.Danmaku, &video.Description,
+			&video.Pic, &video.CreateTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+
+	return videos, rows.Err()
+}
+
+// SaveNews 保存Gamersky新闻
+// 新闻已存在时更新标题、时间、评论数、链接、图片和频道，空值和为0的评论数不覆盖已有数据；
+// 发布时间只在首次计算出时保存，避免相对时间标签在重新爬取时产生偏差；
+// 评论数与上一次记录不同时追加到 gamersky_news_history
+func (s *SQLiteStore) SaveNews(news model.NewsInfo, runID int64) (bool, error) {
+	news = FillPublishedAt(news)
+
+	insertSQL := `
+	INSERT OR IGNORE INTO gamersky_news
+	(sid, title, time, comment_num, url, image_url, topline_time, create_time, channel, published_at, run_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	inserted, err := s.writer.write(insertSQL,
+		news.SID, news.Title, news.Time, news.CommentNum,
+		news.URL, news.ImageURL, news.TopLineTime, news.CreateTime,
+		news.Channel, news.PublishedAt, runID)
+	if err != nil {
+		return false, err
+	}
+
+	if !inserted {
+		updateSQL := `
+		UPDATE gamersky_news SET
+			title = COALESCE(NULLIF(?, ''), title),
+			time = COALESCE(NULLIF(?, ''), time),
+			comment_num = CASE WHEN ? > 0 THEN ? ELSE comment_num END,
+			url = COALESCE(NULLIF(?, ''), url),
+			image_url = COALESCE(NULLIF(?, ''), image_url),
+			topline_time = COALESCE(NULLIF(?, ''), topline_time),
+			channel = COALESCE(NULLIF(?, ''), channel),
+			published_at = COALESCE(NULLIF(published_at, ''), ?)
+		WHERE sid = ?
+		`
+		if _, err := s.writer.write(updateSQL,
+			news.Title, news.Time, news.CommentNum, news.CommentNum,
+			news.URL, news.ImageURL, news.TopLineTime, news.Channel,
+			news.PublishedAt, news.SID); err != nil {
+			return false, err
+		}
+	}
+
+	historySQL := `
+	INSERT INTO gamersky_news_history (sid, comment_num, run_id)
+	SELECT ?, ?, ?
+	WHERE ? > 0 AND ? IS NOT (SELECT comment_num FROM gamersky_news_history WHERE sid = ? ORDER BY recorded_at DESC, id DESC LIMIT 1)
+	`
+	if _, err := s.writer.write(historySQL,
+		news.SID, news.CommentNum, runID, news.CommentNum, news.CommentNum, news.SID); err != nil {
+		return false, err
+	}
+
+	return inserted, nil
+}
+
+// QueryNews 查询Gamersky新闻，按发布时间倒序，没有发布时间的新闻按记录时间排序
+func (s *SQLiteStore) QueryNews(offset, limit int) ([]model.NewsInfo, error) {
+	if err := s.Flush(); err != nil {
+		return nil, err
+	}
+
+	var query string
+	var args []interface{}
+
+	if limit > 0 {
+		query = `
+		SELECT sid, title, time, comment_num, url, image_url, topline_time, create_time, channel, published_at
+		FROM gamersky_news
+		ORDER BY COALESCE(NULLIF(published_at, ''), create_time) DESC, create_time DESC
+		LIMIT ? OFFSET ?`
+		args = append(args, limit, offset)
+	} else {
+		query = `
+		SELECT sid, title, time, comment_num, url, image_url, topline_time, create_time, channel, published_at
+		FROM gamersky_news
+		ORDER BY COALESCE(NULLIF(published_at, ''), create_time) DESC, create_time DESC`
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var news []model.NewsInfo
+	for rows.Next() {
+		var item model.NewsInfo
+		err := rows.Scan(
+			&item.SID, &item.Title, &item.Time, &item.CommentNum,
+			&item.URL, &item.ImageURL, &item.TopLineTime, &item.CreateTime, &item.Channel,
+			&item.PublishedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		news = append(news, item)
+	}
+
+	return news, rows.Err()
+}
+
+// SaveGamerskyComment 保存Gamersky评论
+func (s *SQLiteStore) SaveGamerskyComment(comment model.GamerskyComment, runID int64) (bool, error) {
+	// 使用 INSERT OR IGNORE 来实现去重
+	sql := `
+	INSERT OR IGNORE INTO gamersky_comments
+	(id, article_id, user_id, username, content, comment_time, support_count, reply_count, parent_id, answer_to_id, answer_to_name, user_avatar, user_level, ip_location, device_name, floor_number, is_tuijian, is_author, is_best, user_authentication, user_group_id, third_platform_bound, create_time, run_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	return s.writer.write(sql,
+		comment.ID, comment.ArticleID, comment.UserID, comment.Username,
+		comment.Content, comment.CommentTime, comment.SupportCount, comment.ReplyCount,
+		comment.ParentID, comment.AnswerToID, comment.AnswerToName, comment.UserAvatar, comment.UserLevel, comment.IPLocation,
+		comment.DeviceName, comment.FloorNumber, comment.IsTuijian, comment.IsAuthor,
+		comment.IsBest, comment.UserAuthentication, comment.UserGroupID, comment.ThirdPlatformBound,
+		comment.CreateTime, runID)
+}
+
+// SaveGamerskyCommentImage 保存Gamersky评论图片
+// 图片已存在时只在下载后补充内容哈希，哈希未变化时不算写入
+func (s *SQLiteStore) SaveGamerskyCommentImage(image model.GamerskyCommentImage, runID int64) (bool, error) {
+	sql := `
+	INSERT INTO gamersky_comment_images
+	(comment_id, article_id, image_order, url, width, height, sha256, run_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (comment_id, image_order) DO UPDATE SET sha256 = excluded.sha256
+	WHERE excluded.sha256 != '' AND excluded.sha256 != gamersky_comment_images.sha256
+	`
+
+	return s.writer.write(sql,
+		image.CommentID, image.ArticleID, image.ImageOrder, image.URL,
+		image.Width, image.Height, image.SHA256, runID)
+}
+
+// SaveGamerskyCommentOrder 记录一级评论在某种排序方式下的名次
+// 已记录时保留首次出现的时间，更新名次和最近一次出现的时间
+func (s *SQLiteStore) SaveGamerskyCommentOrder(order model.GamerskyCommentOrder, runID int64) error {
+	upsertSQL := `
+	INSERT INTO gamersky_comment_orders
+	(comment_id, article_id, order_mode, rank, first_seen_at, last_seen_at, run_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (comment_id, order_mode) DO UPDATE SET
+		rank = excluded.rank,
+		last_seen_at = excluded.last_seen_at,
+		run_id = excluded.run_id
+	`
+	_, err := s.writer.write(upsertSQL,
+		order.CommentID, order.ArticleID, order.OrderMode, order.Rank,
+		order.SeenAt, order.SeenAt, runID)
+	return err
+}
+
+// QueryGamerskyComments 查询Gamersky评论
+func (s *SQLiteStore) QueryGamerskyComments(filter CommentFilter) ([]model.GamerskyComment, error) {
+	if err := s.Flush(); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT id, article_id, user_id, username, content, comment_time, support_count, reply_count, parent_id, answer_to_id, answer_to_name, user_avatar, user_level, ip_location, device_name, floor_number, is_tuijian, is_author, is_best, user_authentication, user_group_id, third_platform_bound, create_time
+	FROM gamersky_comments`
+	var conditions []string
+	var args []interface{}
+
+	if filter.ArticleID != "" {
+		conditions = append(conditions, "article_id = ?")
+		args = append(args, filter.ArticleID)
+	}
+
+	// 评论时间以北京时间字符串保存，可直接按字符串比较
+	since, until := filter.timeBounds()
+	if since != "" {
+		conditions = append(conditions, "comment_time >= ?")
+		args = append(args, since)
+	}
+	if until != "" {
+		conditions = append(conditions, "comment_time < ?")
+		args = append(args, until)
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if filter.ArticleID != "" {
+		query += " ORDER BY comment_time DESC"
+	} else {
+		query += " ORDER BY create_time DESC"
+	}
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []model.GamerskyComment
+	for rows.Next() {
+		var comment model.GamerskyComment
+		err := rows.Scan(
+			&comment.ID, &comment.ArticleID, &comment.UserID, &comment.Username,
+			&comment.Content, &comment.CommentTime, &comment.SupportCount, &comment.ReplyCount,
+			&comment.ParentID, &comment.AnswerToID, &comment.AnswerToName, &comment.UserAvatar, &comment.UserLevel, &comment.IPLocation,
+			&comment.DeviceName, &comment.FloorNumber, &comment.IsTuijian, &comment.IsAuthor,
+			&comment.IsBest, &comment.UserAuthentication, &comment.UserGroupID, &comment.ThirdPlatformBound,
+			&comment.CreateTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// SaveArticle 保存Gamersky文章正文，文章已存在时覆盖为新的内容
+func (s *SQLiteStore) SaveArticle(article model.GamerskyArticle, runID int64) (bool, error) {
+	exists, err := s.HasArticle(article.SID)
+	if err != nil {
+		return false, err
+	}
+
+	sql := `
+	INSERT INTO gamersky_articles
+	(sid, url, title, author, editor, source, publish_time, tags, paragraphs, images, pages, run_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (sid) DO UPDATE SET
+		url = excluded.url, title = excluded.title, author = excluded.author, editor = excluded.editor,
+		source = excluded.source, publish_time = excluded.publish_time, tags = excluded.tags,
+		paragraphs = excluded.paragraphs, images = excluded.images, pages = excluded.pages,
+		create_time = CURRENT_TIMESTAMP, run_id = excluded.run_id
+	`
+
+	_, err = s.writer.write(sql,
+		article.SID, article.URL, article.Title, article.Author, article.Editor, article.Source,
+		article.PublishTime, jsonArray(article.Tags), jsonArray(article.Paragraphs), jsonArray(article.Images),
+		article.Pages, runID)
+	if err != nil {
+		return false, err
+	}
+	return !exists, nil
+}
+
+// HasArticle 判断是否已保存文章正文
+func (s *SQLiteStore) HasArticle(sid string) (bool, error) {
+	if err := s.Flush(); err != nil {
+		return false, err
+	}
+
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM gamersky_articles WHERE sid = ?", sid).Scan(&count)
+	return count > 0, err
+}
+
+// SaveCrawlState 记录新闻评论的爬取状态，已存在时覆盖
+func (s *SQLiteStore) SaveCrawlState(state model.GamerskyCrawlState, runID int64) error {
+	upsertSQL := `
+	INSERT INTO gamersky_comment_crawls (sid, comment_num, crawled_at, run_id)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT (sid) DO UPDATE SET
+		comment_num = excluded.comment_num,
+		crawled_at = excluded.crawled_at,
+		run_id = excluded.run_id
+	`
+	_, err := s.writer.write(upsertSQL, state.SID, state.CommentNum, state.CrawledAt, runID)
+	return err
+}
+
+// QueryCrawlStates 查询所有新闻的评论爬取状态
+func (s *SQLiteStore) QueryCrawlStates() (map[string]model.GamerskyCrawlState, error) {
+	if err := s.Flush(); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query("SELECT sid, comment_num, crawled_at FROM gamersky_comment_crawls")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[string]model.GamerskyCrawlState)
+	for rows.Next() {
+		var state model.GamerskyCrawlState
+		if err := rows.Scan(&state.SID, &state.CommentNum, &state.CrawledAt); err != nil {
+			return nil, err
+		}
+		states[state.SID] = state
+	}
+	return states, rows.Err()
+}
+
+// jsonArray 将字符串列表编码为JSON数组，空列表编码为 []
+func jsonArray(items []string) string {
+	if len(items) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(items)
+	return string(data)
+}
+
+// Close 提交剩余数据并关闭数据库连接
+func (s *SQLiteStore) Close() error {
+	if s.db == nil {
+		return nil
+	}
+
+	writeErr := s.writer.close()
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	return writeErr
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bili-comment/model"
+)
+
+// benchCommentCount 基准测试写入的合成评论数
+const benchCommentCount = 100000
+
+// syntheticComments 生成合成的B站评论
+func syntheticComments(n int) []model.CommentInfo {
+	comments := make([]model.CommentInfo, n)
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	for i := range comments {
+		comments[i] = model.CommentInfo{
+			SerialNumber: i/20 + 1,
+			ParentID:     int64(i / 20),
+			CommentID:    int64(i + 1),
+			UserID:       int64(i % 5000),
+			Username:     fmt.Sprintf("用户%d", i%5000),
+			UserLevel:    i % 7,
+			Gender:       "保密",
+			Content:      fmt.Sprintf("第%d条合成评论，续航和价格都还不错，就是充电有点慢", i),
+			CommentTime:  base.Add(time.Duration(i) * time.Second).Format("2006-01-02 15:04:05"),
+			ReplyCount:   i % 10,
+			LikeCount:    i % 1000,
+			Signature:    "这个人很懒，什么都没有写",
+			IPLocation:   "上海",
+			IsVIP:        "否",
+			Avatar:       "https://i0.hdslb.com/bfs/face/member/noface.jpg",
+			BV:           fmt.Sprintf("BV1bench%04d", i%100),
+			VideoTitle:   "基准测试视频",
+		}
+	}
+	return comments
+}
+
+// BenchmarkSaveCommentsAutocommit 旧的写入方式：回滚日志模式，每条评论单独执行并自动提交
+func BenchmarkSaveCommentsAutocommit(b *testing.B) {
+	comments := syntheticComments(benchCommentCount)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		path := filepath.Join(b.TempDir(), "autocommit.db")
+		db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := Migrate(db); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		for _, comment := range comments {
+			_, err := db.Exec(`
+			INSERT OR IGNORE INTO bilibili_comments
+			(serial_number, parent_id, comment_id, user_id, username, user_level, gender, content, comment_time, reply_count, like_count, signature, ip_location, is_vip, avatar, bv, video_title, run_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				comment.SerialNumber, comment.ParentID, comment.CommentID, comment.UserID,
+				comment.Username, comment.UserLevel, comment.Gender, comment.Content,
+				comment.CommentTime, comment.ReplyCount, comment.LikeCount, comment.Signature,
+				comment.IPLocation, comment.IsVIP, comment.Avatar, comment.BV, comment.VideoTitle, 0)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		b.StopTimer()
+		db.Close()
+		b.StartTimer()
+	}
+
+	reportThroughput(b)
+}
+
+// BenchmarkSaveCommentsBatched 批量写入：WAL模式、预编译语句，按批次提交事务
+func BenchmarkSaveCommentsBatched(b *testing.B) {
+	comments := syntheticComments(benchCommentCount)
+
+	for _, batchSize := range []int{1, 100, DefaultBatchSize, 2000} {
+		b.Run(fmt.Sprintf("batch=%d", batchSize), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				st, err := OpenSQLiteWithOptions(filepath.Join(b.TempDir(), "batched.db"), SQLiteOptions{
+					BatchSize:     batchSize,
+					FlushInterval: DefaultFlushInterval,
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+
+				for _, comment := range comments {
+					if _, err := st.SaveComment(comment, 0); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if err := st.Flush(); err != nil {
+					b.Fatal(err)
+				}
+
+				b.StopTimer()
+				st.Close()
+				b.StartTimer()
+			}
+
+			reportThroughput(b)
+		})
+	}
+}
+
+// reportThroughput 报告每秒写入的评论数
+func reportThroughput(b *testing.B) {
+	b.ReportMetric(float64(benchCommentCount*b.N)/b.Elapsed().Seconds(), "comments/s")
+}
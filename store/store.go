@@ -0,0 +1,194 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"bili-comment/model"
+)
+
+// CommentStore 评论存储
+type CommentStore interface {
+	// SaveComment 保存B站评论，评论已存在时忽略并返回 false
+	SaveComment(comment model.CommentInfo, runID int64) (bool, error)
+	// SaveGamerskyComment 保存Gamersky评论，评论已存在时忽略并返回 false
+	SaveGamerskyComment(comment model.GamerskyComment, runID int64) (bool, error)
+	// SaveGamerskyCommentImage 保存Gamersky评论图片，图片已存在时只补充下载后的内容哈希
+	SaveGamerskyCommentImage(image model.GamerskyCommentImage, runID int64) (bool, error)
+	// SaveGamerskyCommentOrder 记录一级评论在某种排序方式下的名次，已记录时更新为最新的名次和时间
+	SaveGamerskyCommentOrder(order model.GamerskyCommentOrder, runID int64) error
+	// QueryGamerskyComments 按条件查询Gamersky评论
+	QueryGamerskyComments(filter CommentFilter) ([]model.GamerskyComment, error)
+	Close() error
+}
+
+// VideoStore B站视频存储
+type VideoStore interface {
+	// SaveVideo 保存视频搜索结果，同一关键词下视频已存在时忽略并返回 false
+	SaveVideo(video model.VideoInfo, runID int64) (bool, error)
+	// QueryVideos 按播放量倒序查询视频，keyword 为空时查询全部
+	QueryVideos(keyword string, limit int) ([]model.VideoInfo, error)
+	Close() error
+}
+
+// NewsStore Gamersky新闻存储
+type NewsStore interface {
+	// SaveNews 保存新闻，新闻已存在时更新标题、评论数等信息并返回 false，空值不覆盖已有数据
+	SaveNews(news model.NewsInfo, runID int64) (bool, error)
+	// QueryNews 按发布时间倒序查询新闻，没有发布时间的按记录时间排序，limit<=0 时查询全部
+	QueryNews(offset, limit int) ([]model.NewsInfo, error)
+	Close() error
+}
+
+// ArticleStore Gamersky文章正文存储
+type ArticleStore interface {
+	// SaveArticle 保存文章正文，文章已存在时覆盖为新的内容，返回文章是否为新文章
+	SaveArticle(article model.GamerskyArticle, runID int64) (bool, error)
+	// HasArticle 判断是否已保存文章正文
+	HasArticle(sid string) (bool, error)
+	Close() error
+}
+
+// CrawlStateStore Gamersky新闻评论爬取状态存储
+type CrawlStateStore interface {
+	// SaveCrawlState 记录新闻评论的爬取状态，已存在时覆盖
+	SaveCrawlState(state model.GamerskyCrawlState, runID int64) error
+	// QueryCrawlStates 查询所有新闻的评论爬取状态，以新闻ID为key
+	QueryCrawlStates() (map[string]model.GamerskyCrawlState, error)
+	Close() error
+}
+
+// Store 同时实现所有存储接口的存储后端
+type Store interface {
+	CommentStore
+	VideoStore
+	NewsStore
+	ArticleStore
+	CrawlStateStore
+}
+
+// SaveRecord 按记录类型保存到对应的数据表，记录已存在时返回 false
+func SaveRecord(st Store, record interface{}, runID int64) (bool, error) {
+	switch r := record.(type) {
+	case model.CommentInfo:
+		return st.SaveComment(r, runID)
+	case model.GamerskyComment:
+		return st.SaveGamerskyComment(r, runID)
+	case model.GamerskyCommentImage:
+		return st.SaveGamerskyCommentImage(r, runID)
+	case model.GamerskyCommentOrder:
+		// 排序记录只更新名次和时间，不计为新记录
+		return false, st.SaveGamerskyCommentOrder(r, runID)
+	case model.VideoInfo:
+		return st.SaveVideo(r, runID)
+	case model.NewsInfo:
+		return st.SaveNews(r, runID)
+	case model.GamerskyArticle:
+		return st.SaveArticle(r, runID)
+	default:
+		return false, fmt.Errorf("不支持保存的记录类型: %T", record)
+	}
+}
+
+// CommentFilter Gamersky评论查询条件
+type CommentFilter struct {
+	ArticleID string    // 文章ID，为空时查询所有文章
+	Since     time.Time // 评论时间下限 (零值表示不限制)
+	Until     time.Time // 评论时间上限，不含 (零值表示不限制)
+	Limit     int       // 返回数量，<=0 表示不限制
+}
+
+// cst Gamersky评论时间以北京时间字符串保存
+var cst = time.FixedZone("CST", 8*3600)
+
+// timeBounds 将时间范围转换为与评论时间相同格式的字符串，零值时间返回空字符串
+func (f CommentFilter) timeBounds() (since, until string) {
+	if !f.Since.IsZero() {
+		since = f.Since.In(cst).Format("2006-01-02 15:04:05")
+	}
+	if !f.Until.IsZero() {
+		until = f.Until.In(cst).Format("2006-01-02 15:04:05")
+	}
+	return since, until
+}
+
+// match 判断评论是否满足查询条件（不含数量限制）
+func (f CommentFilter) match(comment model.GamerskyComment) bool {
+	if f.ArticleID != "" && comment.ArticleID != f.ArticleID {
+		return false
+	}
+
+	since, until := f.timeBounds()
+	if since != "" && comment.CommentTime < since {
+		return false
+	}
+	if until != "" && comment.CommentTime >= until {
+		return false
+	}
+	return true
+}
+
+// 存储类型
+const (
+	KindSQLite = "sqlite" // SQLite数据库文件
+	KindJSONL  = "jsonl"  // 只追加的JSONL目录
+	KindMemory = "memory" // 内存存储
+)
+
+// ParseDSN 解析存储DSN，格式为 "类型:路径"，如 sqlite:./data/crawler.db、jsonl:./data/jsonl、memory:
+// dsn 为空时使用 defaultPath 下的SQLite数据库，不带类型前缀时视为SQLite文件路径
+func ParseDSN(dsn, defaultPath string) (kind, path string, err error) {
+	if dsn == "" {
+		return KindSQLite, defaultPath, nil
+	}
+
+	kind, path, found := strings.Cut(dsn, ":")
+	if !found {
+		return KindSQLite, dsn, nil
+	}
+	path = strings.TrimPrefix(path, "//")
+
+	switch kind {
+	case KindSQLite:
+		if path == "" {
+			path = defaultPath
+		}
+		return kind, path, nil
+	case KindJSONL:
+		if path == "" {
+			return "", "", fmt.Errorf("jsonl 存储需要指定目录，如 jsonl:./data/jsonl")
+		}
+		return kind, path, nil
+	case KindMemory:
+		return kind, "", nil
+	default:
+		return "", "", fmt.Errorf("不支持的存储类型: %s", kind)
+	}
+}
+
+// SQLitePath 获取DSN对应的SQLite文件路径，非SQLite存储时 ok 为 false
+func SQLitePath(dsn, defaultPath string) (path string, ok bool) {
+	kind, path, err := ParseDSN(dsn, defaultPath)
+	if err != nil || kind != KindSQLite {
+		return "", false
+	}
+	return path, true
+}
+
+// Open 根据DSN打开存储
+func Open(dsn, defaultPath string) (Store, error) {
+	kind, path, err := ParseDSN(dsn, defaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case KindJSONL:
+		return OpenJSONL(path)
+	case KindMemory:
+		return NewMemory(), nil
+	default:
+		return OpenSQLite(path)
+	}
+}
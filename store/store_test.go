@@ -0,0 +1,237 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bili-comment/model"
+)
+
+// TestStoreConformance 对每种存储后端执行相同的保存、去重和查询检查
+func TestStoreConformance(t *testing.T) {
+	dir := t.TempDir()
+	backends := []struct {
+		kind string
+		dsn  string
+	}{
+		{KindSQLite, "sqlite:" + filepath.Join(dir, "conformance.db")},
+		{KindJSONL, "jsonl:" + filepath.Join(dir, "jsonl")},
+		{KindMemory, "memory:"},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.kind, func(t *testing.T) {
+			st, err := Open(backend.dsn, "")
+			if err != nil {
+				t.Fatalf("打开存储失败: %v", err)
+			}
+			defer st.Close()
+
+			// JSONL文件只追加，已有的记录不会被更新
+			appendOnly := backend.kind == KindJSONL
+
+			testStoreComments(t, st)
+			testStoreGamerskyComments(t, st, appendOnly)
+			testStoreVideos(t, st)
+			testStoreNews(t, st, appendOnly)
+			testStoreArticles(t, st)
+			testStoreCrawlStates(t, st)
+		})
+	}
+}
+
+// saved 检查保存结果是否为新记录
+func saved(t *testing.T, what string, inserted bool, err error, want bool) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("保存%s失败: %v", what, err)
+	}
+	if inserted != want {
+		t.Errorf("保存%s: inserted = %t, 期望 %t", what, inserted, want)
+	}
+}
+
+func testStoreComments(t *testing.T, st Store) {
+	comment := model.CommentInfo{CommentID: 1, BV: "BV1", Username: "a", Content: "第一条", CommentTime: "2025-01-01 10:00:00"}
+	inserted, err := st.SaveComment(comment, 1)
+	saved(t, "B站评论", inserted, err, true)
+
+	comment.Content = "重复"
+	inserted, err = st.SaveComment(comment, 1)
+	saved(t, "重复的B站评论", inserted, err, false)
+}
+
+func testStoreGamerskyComments(t *testing.T, st Store, appendOnly bool) {
+	comments := []model.GamerskyComment{
+		{ID: 1, ArticleID: "100", Username: "a", CommentTime: "2025-01-01 09:00:00"},
+		{ID: 2, ArticleID: "100", Username: "b", CommentTime: "2025-01-02 09:00:00", ParentID: 1, AnswerToID: 1},
+		{ID: 3, ArticleID: "100", Username: "c", CommentTime: "2025-01-03 09:00:00"},
+		{ID: 4, ArticleID: "200", Username: "d", CommentTime: "2025-01-02 12:00:00"},
+	}
+	for _, comment := range comments {
+		inserted, err := st.SaveGamerskyComment(comment, 1)
+		saved(t, "Gamersky评论", inserted, err, true)
+	}
+	inserted, err := st.SaveGamerskyComment(comments[0], 2)
+	saved(t, "重复的Gamersky评论", inserted, err, false)
+
+	image := model.GamerskyCommentImage{CommentID: 1, ArticleID: "100", URL: "https://img/1.jpg"}
+	inserted, err = st.SaveGamerskyCommentImage(image, 1)
+	saved(t, "评论图片", inserted, err, true)
+	inserted, err = st.SaveGamerskyCommentImage(image, 1)
+	saved(t, "重复的评论图片", inserted, err, false)
+
+	// 下载图片后补充内容哈希
+	image.SHA256 = "abc"
+	inserted, err = st.SaveGamerskyCommentImage(image, 1)
+	saved(t, "补充哈希的评论图片", inserted, err, !appendOnly)
+
+	order := model.GamerskyCommentOrder{CommentID: 1, ArticleID: "100", OrderMode: "latest", Rank: 1, SeenAt: "2025-01-03 10:00:00"}
+	if err := st.SaveGamerskyCommentOrder(order, 1); err != nil {
+		t.Fatalf("保存评论名次失败: %v", err)
+	}
+
+	ids := func(filter CommentFilter) []int64 {
+		t.Helper()
+		got, err := st.QueryGamerskyComments(filter)
+		if err != nil {
+			t.Fatalf("查询Gamersky评论失败: %v", err)
+		}
+		var ids []int64
+		for _, comment := range got {
+			ids = append(ids, comment.ID)
+		}
+		return ids
+	}
+	cst := time.FixedZone("CST", 8*3600)
+	cases := []struct {
+		name   string
+		filter CommentFilter
+		want   []int64
+	}{
+		{"按文章", CommentFilter{ArticleID: "100"}, []int64{3, 2, 1}},
+		{"时间范围", CommentFilter{
+			ArticleID: "100",
+			Since:     time.Date(2025, 1, 2, 0, 0, 0, 0, cst),
+			Until:     time.Date(2025, 1, 3, 9, 0, 0, 0, cst),
+		}, []int64{2}},
+		{"数量限制", CommentFilter{ArticleID: "100", Limit: 2}, []int64{3, 2}},
+	}
+	for _, c := range cases {
+		if got := ids(c.filter); !equalIDs(got, c.want) {
+			t.Errorf("%s: 评论 = %v, 期望 %v", c.name, got, c.want)
+		}
+	}
+	if got := ids(CommentFilter{}); len(got) != 4 {
+		t.Errorf("全部评论 = %v, 期望 4 条", got)
+	}
+}
+
+// equalIDs 判断两个ID列表是否相同
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testStoreVideos(t *testing.T, st Store) {
+	videos := []model.VideoInfo{
+		{Keyword: "go", BVID: "BV1", Title: "少", Play: 10},
+		{Keyword: "go", BVID: "BV2", Title: "多", Play: 1000},
+		{Keyword: "rust", BVID: "BV1", Title: "少", Play: 10},
+	}
+	for _, video := range videos {
+		inserted, err := st.SaveVideo(video, 1)
+		saved(t, "视频", inserted, err, true)
+	}
+	inserted, err := st.SaveVideo(videos[0], 1)
+	saved(t, "重复的视频", inserted, err, false)
+
+	got, err := st.QueryVideos("go", 10)
+	if err != nil {
+		t.Fatalf("查询视频失败: %v", err)
+	}
+	if len(got) != 2 || got[0].BVID != "BV2" || got[1].BVID != "BV1" {
+		t.Errorf("关键词 go 的视频 = %+v, 期望按播放量倒序的 BV2、BV1", got)
+	}
+	if got, _ := st.QueryVideos("", 1); len(got) != 1 || got[0].BVID != "BV2" {
+		t.Errorf("播放量最高的视频 = %+v, 期望 BV2", got)
+	}
+}
+
+func testStoreNews(t *testing.T, st Store, appendOnly bool) {
+	news := model.NewsInfo{SID: "1", Title: "旧标题", Time: "2025-01-01 08:00", CommentNum: 3, URL: "https://news/1", CreateTime: "2025-01-01 09:00:00"}
+	inserted, err := st.SaveNews(news, 1)
+	saved(t, "新闻", inserted, err, true)
+
+	// 已有新闻更新标题和评论数，空值不覆盖已有数据
+	inserted, err = st.SaveNews(model.NewsInfo{SID: "1", Title: "新标题", CommentNum: 8, CreateTime: "2025-01-01 10:00:00"}, 1)
+	saved(t, "已有的新闻", inserted, err, false)
+
+	inserted, err = st.SaveNews(model.NewsInfo{SID: "2", Title: "第二条", Time: "2025-01-02 08:00", CreateTime: "2025-01-02 09:00:00"}, 1)
+	saved(t, "新闻", inserted, err, true)
+
+	got, err := st.QueryNews(0, 0)
+	if err != nil {
+		t.Fatalf("查询新闻失败: %v", err)
+	}
+	if len(got) != 2 || got[0].SID != "2" || got[1].SID != "1" {
+		t.Fatalf("新闻 = %+v, 期望按发布时间倒序的 2、1", got)
+	}
+	want := news
+	if !appendOnly {
+		want.Title, want.CommentNum = "新标题", 8
+	}
+	if first := got[1]; first.Title != want.Title || first.CommentNum != want.CommentNum || first.URL != want.URL {
+		t.Errorf("更新后的新闻 = %+v, 期望 %+v", first, want)
+	}
+	if got, _ := st.QueryNews(1, 1); len(got) != 1 || got[0].SID != "1" {
+		t.Errorf("分页查询 = %+v, 期望新闻 1", got)
+	}
+}
+
+func testStoreArticles(t *testing.T, st Store) {
+	if ok, err := st.HasArticle("1"); err != nil || ok {
+		t.Fatalf("HasArticle = %t, %v, 期望不存在", ok, err)
+	}
+
+	article := model.GamerskyArticle{SID: "1", Title: "正文", Paragraphs: []string{"第一段"}, Pages: 1}
+	inserted, err := st.SaveArticle(article, 1)
+	saved(t, "文章", inserted, err, true)
+
+	article.Paragraphs = append(article.Paragraphs, "第二段")
+	inserted, err = st.SaveArticle(article, 1)
+	saved(t, "已有的文章", inserted, err, false)
+
+	if ok, err := st.HasArticle("1"); err != nil || !ok {
+		t.Errorf("HasArticle = %t, %v, 期望已保存", ok, err)
+	}
+}
+
+func testStoreCrawlStates(t *testing.T, st Store) {
+	states := []model.GamerskyCrawlState{
+		{SID: "1", CommentNum: 3, CrawledAt: "2025-01-01 10:00:00"},
+		{SID: "2", CommentNum: 5, CrawledAt: "2025-01-01 10:00:00"},
+		{SID: "1", CommentNum: 8, CrawledAt: "2025-01-02 10:00:00"},
+	}
+	for _, state := range states {
+		if err := st.SaveCrawlState(state, 1); err != nil {
+			t.Fatalf("保存爬取状态失败: %v", err)
+		}
+	}
+
+	got, err := st.QueryCrawlStates()
+	if err != nil {
+		t.Fatalf("查询爬取状态失败: %v", err)
+	}
+	if len(got) != 2 || got["1"] != states[2] || got["2"] != states[1] {
+		t.Errorf("爬取状态 = %+v, 期望以最后一次为准", got)
+	}
+}